@@ -0,0 +1,837 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"math/bits"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DecadeBucket представляет количество значений в одном десятке (0–9, 10–19, ...)
+type DecadeBucket struct {
+	Decade int64  `json:"decade"`
+	Range  string `json:"range"`
+	Count  int    `json:"count"`
+}
+
+// handleDecades возвращает количество значений, сгруппированных по десяткам.
+// Номер десятка вычисляется округлением вниз (floor), поэтому отрицательные
+// значения попадают в отрицательные десятки: -1 относится к десятку -1 (-10..-1),
+// а не к десятку 0, как было бы при целочисленном делении с отбрасыванием дробной части.
+// При USE_MATVIEW количества суммируются по материализованному представлению
+func (app *App) handleDecades(w http.ResponseWriter, r *http.Request) {
+	query := `
+		SELECT FLOOR(value / 10.0)::bigint AS decade, COUNT(*)
+		FROM live_numbers
+		GROUP BY decade
+		ORDER BY decade ASC`
+	if app.Config.UseMatview {
+		query = `
+		SELECT FLOOR(value / 10.0)::bigint AS decade, SUM(count)::bigint
+		FROM ` + valueCountsView + `
+		GROUP BY decade
+		ORDER BY decade ASC`
+	}
+
+	rows, err := app.DB.Query(query)
+	if err != nil {
+		log.Printf("Error getting decades: %v", err)
+		http.Error(w, "Failed to retrieve decades", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	buckets := []DecadeBucket{}
+	for rows.Next() {
+		var b DecadeBucket
+		if err := rows.Scan(&b.Decade, &b.Count); err != nil {
+			log.Printf("Error scanning decade: %v", err)
+			http.Error(w, "Failed to retrieve decades", http.StatusInternalServerError)
+			return
+		}
+		b.Range = decadeRange(b.Decade)
+		buckets = append(buckets, b)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating decades: %v", err)
+		http.Error(w, "Failed to retrieve decades", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, buckets)
+}
+
+// decadeRange формирует подпись диапазона десятка, например "10..19" или "-10..-1"
+func decadeRange(decade int64) string {
+	return fmt.Sprintf("%d..%d", decade*10, decade*10+9)
+}
+
+// defaultPairwiseMaxRows ограничивает размер данных для попарных вычислений, так как
+// количество пар растет квадратично
+const defaultPairwiseMaxRows = 2000
+
+// PairwiseDiffStats представляет статистику абсолютных разностей по всем парам значений.
+// Поля равны null, если значений меньше двух. Разность двух bigint может не поместиться в int64,
+// поэтому Min и Max передаются числами произвольной длины, как сумма в Stats
+type PairwiseDiffStats struct {
+	Pairs   int      `json:"pairs"`
+	Min     *big.Int `json:"min"`
+	Max     *big.Int `json:"max"`
+	Average *float64 `json:"average"`
+}
+
+// handlePairwiseDiffStats возвращает минимальную, максимальную и среднюю абсолютную разность
+// по всем неупорядоченным парам значений. При превышении лимита строк возвращает 413.
+// Разность считается в numeric: в bigint вычитание крайних значений переполнялось бы
+func (app *App) handlePairwiseDiffStats(w http.ResponseWriter, r *http.Request) {
+	limit := app.Config.PairwiseMaxRows
+	if limit <= 0 {
+		limit = defaultPairwiseMaxRows
+	}
+
+	var count int
+	if err := app.DB.QueryRow("SELECT COUNT(*) FROM live_numbers").Scan(&count); err != nil {
+		log.Printf("Error counting numbers: %v", err)
+		http.Error(w, "Failed to compute pairwise differences", http.StatusInternalServerError)
+		return
+	}
+	if count > limit {
+		http.Error(w, fmt.Sprintf("Dataset too large for pairwise statistics (%d rows, limit %d)", count, limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var (
+		stats   PairwiseDiffStats
+		minDiff sql.NullString
+		maxDiff sql.NullString
+		average sql.NullFloat64
+	)
+	err := app.DB.QueryRow(`
+		SELECT COUNT(*), MIN(d)::text, MAX(d)::text, AVG(d)::float8
+		FROM (
+			SELECT ABS(a.value::numeric - b.value::numeric) AS d
+			FROM live_numbers a
+			JOIN live_numbers b ON a.id < b.id
+		) diffs`).Scan(&stats.Pairs, &minDiff, &maxDiff, &average)
+	if err == nil && minDiff.Valid {
+		stats.Min, err = parseBigInt(minDiff.String)
+	}
+	if err == nil && maxDiff.Valid {
+		stats.Max, err = parseBigInt(maxDiff.String)
+	}
+	if err != nil {
+		log.Printf("Error computing pairwise differences: %v", err)
+		http.Error(w, "Failed to compute pairwise differences", http.StatusInternalServerError)
+		return
+	}
+
+	if average.Valid {
+		stats.Average = &average.Float64
+	}
+
+	writeJSON(w, stats)
+}
+
+// maxRollingWindow ограничивает размер окна для скользящих вычислений
+const maxRollingWindow = 1000
+
+// RollingStddev представляет значение и стандартное отклонение окна, которое им заканчивается.
+// Window - фактический размер окна: в начале последовательности он меньше запрошенного
+type RollingStddev struct {
+	Value  int64   `json:"value"`
+	Stddev float64 `json:"stddev"`
+	Window int     `json:"window"`
+}
+
+// handleRollingStddev возвращает скользящее стандартное отклонение (генеральной совокупности)
+// по последним window значениям в порядке добавления
+func (app *App) handleRollingStddev(w http.ResponseWriter, r *http.Request) {
+	window, err := strconv.Atoi(r.URL.Query().Get("window"))
+	if err != nil || window < 1 || window > maxRollingWindow {
+		http.Error(w, fmt.Sprintf("window must be an integer between 1 and %d", maxRollingWindow), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT value, STDDEV_POP(value) OVER win, COUNT(*) OVER win
+		FROM live_numbers
+		WINDOW win AS (ORDER BY created_at, id ROWS BETWEEN $1 PRECEDING AND CURRENT ROW)
+		ORDER BY created_at, id`, window-1)
+	if err != nil {
+		log.Printf("Error computing rolling stddev: %v", err)
+		http.Error(w, "Failed to compute rolling stddev", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	result := []RollingStddev{}
+	for rows.Next() {
+		var item RollingStddev
+		if err := rows.Scan(&item.Value, &item.Stddev, &item.Window); err != nil {
+			log.Printf("Error scanning rolling stddev: %v", err)
+			http.Error(w, "Failed to compute rolling stddev", http.StatusInternalServerError)
+			return
+		}
+		result = append(result, item)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating rolling stddev: %v", err)
+		http.Error(w, "Failed to compute rolling stddev", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+// HarmonicMean представляет среднее гармоническое значений; null для пустой таблицы
+type HarmonicMean struct {
+	Count        int      `json:"count"`
+	HarmonicMean *float64 `json:"harmonic_mean"`
+}
+
+// handleHarmonicMean возвращает среднее гармоническое COUNT(*) / SUM(1/value).
+// Оно определено только для строго положительных значений, поэтому при наличии нуля или
+// отрицательных чисел запрос отклоняется с 422, а не отбрасывает их молча
+func (app *App) handleHarmonicMean(w http.ResponseWriter, r *http.Request) {
+	var (
+		nonPositive int
+		result      HarmonicMean
+		reciprocals sql.NullFloat64
+	)
+	err := app.DB.QueryRow(`
+		SELECT
+			COUNT(*) FILTER (WHERE value <= 0),
+			COUNT(*) FILTER (WHERE value > 0),
+			SUM(1.0 / CASE WHEN value > 0 THEN value END)::float8
+		FROM live_numbers`).Scan(&nonPositive, &result.Count, &reciprocals)
+	if err != nil {
+		log.Printf("Error computing harmonic mean: %v", err)
+		http.Error(w, "Failed to compute harmonic mean", http.StatusInternalServerError)
+		return
+	}
+
+	if nonPositive > 0 {
+		http.Error(w, fmt.Sprintf("Harmonic mean is undefined: %d values are not positive", nonPositive), http.StatusUnprocessableEntity)
+		return
+	}
+	if reciprocals.Valid && reciprocals.Float64 > 0 {
+		mean := float64(result.Count) / reciprocals.Float64
+		result.HarmonicMean = &mean
+	}
+
+	writeJSON(w, result)
+}
+
+// PopcountBucket представляет количество значений с заданным числом единичных битов
+type PopcountBucket struct {
+	Bits  int `json:"bits"`
+	Count int `json:"count"`
+}
+
+// handlePopcount возвращает распределение неотрицательных значений по числу единичных битов.
+// Отрицательные значения исключаются: в дополнительном коде их popcount зависит от разрядности
+// типа, а не от самого числа
+func (app *App) handlePopcount(w http.ResponseWriter, r *http.Request) {
+	numbers, err := app.queryNumbers("SELECT value FROM live_numbers WHERE value >= 0")
+	if err != nil {
+		log.Printf("Error getting numbers for popcount: %v", err)
+		http.Error(w, "Failed to compute popcount distribution", http.StatusInternalServerError)
+		return
+	}
+
+	counts := make(map[int]int)
+	for _, n := range numbers {
+		counts[bits.OnesCount64(uint64(n))]++
+	}
+
+	buckets := make([]PopcountBucket, 0, len(counts))
+	for b, c := range counts {
+		buckets = append(buckets, PopcountBucket{Bits: b, Count: c})
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Bits < buckets[j].Bits })
+
+	writeJSON(w, buckets)
+}
+
+// DigitalRootBucket представляет количество значений с заданным цифровым корнем
+type DigitalRootBucket struct {
+	Root  int `json:"root"`
+	Count int `json:"count"`
+}
+
+// handleDigitalRoots возвращает распределение значений по цифровому корню, по возрастанию root
+func (app *App) handleDigitalRoots(w http.ResponseWriter, r *http.Request) {
+	numbers, err := app.queryNumbers("SELECT value FROM live_numbers")
+	if err != nil {
+		log.Printf("Error getting numbers for digital roots: %v", err)
+		http.Error(w, "Failed to compute digital roots", http.StatusInternalServerError)
+		return
+	}
+
+	counts := make(map[int]int)
+	for _, n := range numbers {
+		counts[digitalRoot(n)]++
+	}
+
+	buckets := make([]DigitalRootBucket, 0, len(counts))
+	for root, c := range counts {
+		buckets = append(buckets, DigitalRootBucket{Root: root, Count: c})
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Root < buckets[j].Root })
+
+	writeJSON(w, buckets)
+}
+
+// digitalRoot возвращает повторную сумму цифр числа до одной цифры: 1 + (n-1) % 9 для
+// положительных, 0 для нуля. Для отрицательных используется абсолютное значение
+func digitalRoot(n int64) int {
+	// Модуль в uint64 определен и для math.MinInt64
+	u := uint64(n)
+	if n < 0 {
+		u = -u
+	}
+	if u == 0 {
+		return 0
+	}
+	return int(1 + (u-1)%9)
+}
+
+// Tertiles представляет две границы, делящие значения на три равные по частоте группы,
+// и количество значений в каждой группе. Для пустой таблицы boundaries пуст
+type Tertiles struct {
+	Boundaries []float64 `json:"boundaries"`
+	Counts     []int     `json:"counts"`
+}
+
+// handleTertiles возвращает границы терцилей (percentile_cont по 0.333 и 0.667) и количество
+// значений в группах: до первой границы включительно, до второй включительно и выше второй.
+// На малых наборах границы интерполируются, поэтому группы могут быть неравными или пустыми
+func (app *App) handleTertiles(w http.ResponseWriter, r *http.Request) {
+	var (
+		lower, upper sql.NullFloat64
+		counts       = make([]int, 3)
+	)
+	err := app.DB.QueryRow(`
+		WITH b AS (
+			SELECT percentile_cont(ARRAY[0.333, 0.667]) WITHIN GROUP (ORDER BY value) AS p
+			FROM live_numbers
+		)
+		SELECT b.p[1], b.p[2],
+			COUNT(n.value) FILTER (WHERE n.value <= b.p[1]),
+			COUNT(n.value) FILTER (WHERE n.value > b.p[1] AND n.value <= b.p[2]),
+			COUNT(n.value) FILTER (WHERE n.value > b.p[2])
+		FROM b
+		LEFT JOIN live_numbers n ON true
+		GROUP BY b.p`).Scan(&lower, &upper, &counts[0], &counts[1], &counts[2])
+	if err != nil {
+		log.Printf("Error computing tertiles: %v", err)
+		http.Error(w, "Failed to compute tertiles", http.StatusInternalServerError)
+		return
+	}
+
+	result := Tertiles{Boundaries: []float64{}, Counts: counts}
+	if lower.Valid && upper.Valid {
+		result.Boundaries = []float64{lower.Float64, upper.Float64}
+	}
+
+	writeJSON(w, result)
+}
+
+// WeightsRequest представляет веса, сопоставляемые отсортированным значениям
+type WeightsRequest struct {
+	Weights []float64 `json:"weights"`
+}
+
+// WeightedSum представляет взвешенную сумму значений
+type WeightedSum struct {
+	Count       int     `json:"count"`
+	WeightedSum float64 `json:"weighted_sum"`
+}
+
+// handleWeightedSum возвращает сумму value[i] * weights[i] по значениям, отсортированным по
+// возрастанию. Количество весов должно совпадать с количеством значений, иначе возвращается 400
+func (app *App) handleWeightedSum(w http.ResponseWriter, r *http.Request) {
+	var req WeightsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	numbers, err := app.getAllNumbers()
+	if err != nil {
+		log.Printf("Error getting numbers for weighted sum: %v", err)
+		http.Error(w, "Failed to compute weighted sum", http.StatusInternalServerError)
+		return
+	}
+
+	if len(req.Weights) != len(numbers) {
+		http.Error(w, fmt.Sprintf("Expected %d weights, got %d", len(numbers), len(req.Weights)), http.StatusBadRequest)
+		return
+	}
+
+	result := WeightedSum{Count: len(numbers)}
+	for i, n := range numbers {
+		result.WeightedSum += float64(n) * req.Weights[i]
+	}
+
+	writeJSON(w, result)
+}
+
+// LogBucket представляет количество значений в диапазоне [lower, upper) логарифмической шкалы,
+// где lower = base^bucket, upper = base^(bucket+1)
+type LogBucket struct {
+	Bucket int     `json:"bucket"`
+	Lower  float64 `json:"lower"`
+	Upper  float64 `json:"upper"`
+	Count  int     `json:"count"`
+}
+
+// handleLogBuckets группирует положительные значения по FLOOR(LOG(base, value)).
+// Логарифм вычисляется в numeric, чтобы точные степени основания (например, 1000 при base=10)
+// не попадали в предыдущий интервал из-за погрешности. Неположительные значения исключаются
+func (app *App) handleLogBuckets(w http.ResponseWriter, r *http.Request) {
+	base := 10.0
+	if v := r.URL.Query().Get("base"); v != "" {
+		var err error
+		base, err = strconv.ParseFloat(v, 64)
+		if err != nil || math.IsInf(base, 0) || !(base > 1) {
+			http.Error(w, "base must be a finite number greater than 1", http.StatusBadRequest)
+			return
+		}
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT FLOOR(LOG($1::numeric, value::numeric))::int AS bucket, COUNT(*)
+		FROM live_numbers
+		WHERE value > 0
+		GROUP BY bucket
+		ORDER BY bucket ASC`, base)
+	if err != nil {
+		log.Printf("Error getting log buckets: %v", err)
+		http.Error(w, "Failed to retrieve log buckets", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	buckets := []LogBucket{}
+	for rows.Next() {
+		var b LogBucket
+		if err := rows.Scan(&b.Bucket, &b.Count); err != nil {
+			log.Printf("Error scanning log bucket: %v", err)
+			http.Error(w, "Failed to retrieve log buckets", http.StatusInternalServerError)
+			return
+		}
+		b.Lower = math.Pow(base, float64(b.Bucket))
+		b.Upper = math.Pow(base, float64(b.Bucket+1))
+		buckets = append(buckets, b)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating log buckets: %v", err)
+		http.Error(w, "Failed to retrieve log buckets", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, buckets)
+}
+
+// Category описывает именованный диапазон значений для /numbers/categories: значения не больше
+// Max (включительно) и больше границы предыдущей категории. У последней категории Max не задан
+type Category struct {
+	Name string `json:"name"`
+	Max  *int64 `json:"max,omitempty"`
+}
+
+// defaultCategories используются, если VALUE_CATEGORIES не задан
+var defaultCategories = []Category{
+	{Name: "low", Max: int64Ptr(9)},
+	{Name: "medium", Max: int64Ptr(99)},
+	{Name: "high"},
+}
+
+func int64Ptr(n int64) *int64 { return &n }
+
+// parseCategories разбирает JSON список категорий и проверяет, что границы строго возрастают,
+// имена уникальны, а последняя категория (и только она) не имеет границы
+func parseCategories(data string) ([]Category, error) {
+	var categories []Category
+	if err := json.Unmarshal([]byte(data), &categories); err != nil {
+		return nil, fmt.Errorf("VALUE_CATEGORIES must be a JSON array of {\"name\", \"max\"}: %w", err)
+	}
+	if len(categories) == 0 {
+		return nil, fmt.Errorf("VALUE_CATEGORIES must not be empty")
+	}
+
+	seen := make(map[string]bool)
+	for i, c := range categories {
+		if c.Name == "" || seen[c.Name] {
+			return nil, fmt.Errorf("VALUE_CATEGORIES: category %d must have a unique non-empty name", i)
+		}
+		seen[c.Name] = true
+
+		last := i == len(categories)-1
+		switch {
+		case last && c.Max != nil:
+			return nil, fmt.Errorf("VALUE_CATEGORIES: the last category %q must not have a max", c.Name)
+		case !last && c.Max == nil:
+			return nil, fmt.Errorf("VALUE_CATEGORIES: category %q must have a max", c.Name)
+		case i > 0 && !last && *c.Max <= *categories[i-1].Max:
+			return nil, fmt.Errorf("VALUE_CATEGORIES: max values must be sorted in ascending order")
+		}
+	}
+	return categories, nil
+}
+
+// categoryCaseSQL строит выражение CASE, возвращающее номер категории значения.
+// Границы передаются параметрами запроса
+func categoryCaseSQL(categories []Category) (string, []interface{}) {
+	var (
+		b    strings.Builder
+		args []interface{}
+	)
+	b.WriteString("CASE")
+	for i, c := range categories[:len(categories)-1] {
+		args = append(args, *c.Max)
+		fmt.Fprintf(&b, " WHEN value <= $%d THEN %d", len(args), i)
+	}
+	fmt.Fprintf(&b, " ELSE %d END", len(categories)-1)
+	return b.String(), args
+}
+
+// CategoryCount представляет количество значений в категории
+type CategoryCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// handleCategories возвращает количество значений в каждой категории из VALUE_CATEGORIES
+// в порядке их объявления, включая пустые категории
+func (app *App) handleCategories(w http.ResponseWriter, r *http.Request) {
+	categories := app.Config.Categories
+	if len(categories) == 0 {
+		categories = defaultCategories
+	}
+
+	expr, args := categoryCaseSQL(categories)
+	rows, err := app.DB.Query(`
+		SELECT category, COUNT(*)
+		FROM (SELECT `+expr+` AS category FROM live_numbers) c
+		GROUP BY category`, args...)
+	if err != nil {
+		log.Printf("Error getting categories: %v", err)
+		http.Error(w, "Failed to retrieve categories", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	result := make([]CategoryCount, len(categories))
+	for i, c := range categories {
+		result[i].Name = c.Name
+	}
+	for rows.Next() {
+		var index, count int
+		if err := rows.Scan(&index, &count); err != nil {
+			log.Printf("Error scanning category: %v", err)
+			http.Error(w, "Failed to retrieve categories", http.StatusInternalServerError)
+			return
+		}
+		result[index].Count = count
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating categories: %v", err)
+		http.Error(w, "Failed to retrieve categories", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+// growthGranularities - допустимые шаги временного ряда для /numbers/growth (аргумент date_trunc)
+var growthGranularities = map[string]bool{"minute": true, "hour": true, "day": true}
+
+// GrowthPoint представляет количество значений, добавленных к концу интервала, начинающегося в Time
+type GrowthPoint struct {
+	Time       time.Time `json:"time"`
+	Cumulative int       `json:"cumulative"`
+}
+
+// handleGrowth возвращает накопленное количество значений по интервалам времени добавления.
+// Интервалы без вставок пропускаются; granularity - minute, hour (по умолчанию) или day
+func (app *App) handleGrowth(w http.ResponseWriter, r *http.Request) {
+	granularity := r.URL.Query().Get("granularity")
+	if granularity == "" {
+		granularity = "hour"
+	}
+	if !growthGranularities[granularity] {
+		http.Error(w, "granularity must be \"minute\", \"hour\" or \"day\"", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT t, (SUM(n) OVER (ORDER BY t))::bigint
+		FROM (
+			SELECT date_trunc($1, created_at) AS t, COUNT(*) AS n
+			FROM live_numbers
+			WHERE created_at IS NOT NULL
+			GROUP BY 1
+		) g
+		ORDER BY t ASC`, granularity)
+	if err != nil {
+		log.Printf("Error computing growth: %v", err)
+		http.Error(w, "Failed to compute growth", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	result := []GrowthPoint{}
+	for rows.Next() {
+		var p GrowthPoint
+		if err := rows.Scan(&p.Time, &p.Cumulative); err != nil {
+			log.Printf("Error scanning growth point: %v", err)
+			http.Error(w, "Failed to compute growth", http.StatusInternalServerError)
+			return
+		}
+		result = append(result, p)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating growth points: %v", err)
+		http.Error(w, "Failed to compute growth", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+// LongestArithmetic представляет самую длинную арифметическую прогрессию среди различных значений.
+// Difference равен null, если значений меньше двух
+type LongestArithmetic struct {
+	Length      int     `json:"length"`
+	Difference  *int64  `json:"difference"`
+	Subsequence []int64 `json:"subsequence"`
+}
+
+// handleLongestArithmetic возвращает самую длинную арифметическую подпоследовательность
+// отсортированных различных значений. Вычисление квадратично, поэтому при превышении
+// PAIRWISE_MAX_ROWS различных значений возвращает 413
+func (app *App) handleLongestArithmetic(w http.ResponseWriter, r *http.Request) {
+	limit := app.Config.PairwiseMaxRows
+	if limit <= 0 {
+		limit = defaultPairwiseMaxRows
+	}
+
+	values, err := app.queryNumbers("SELECT DISTINCT value FROM live_numbers ORDER BY value ASC LIMIT $1", limit+1)
+	if err != nil {
+		log.Printf("Error fetching values for arithmetic subsequence: %v", err)
+		http.Error(w, "Failed to compute arithmetic subsequence", http.StatusInternalServerError)
+		return
+	}
+	if len(values) > limit {
+		http.Error(w, fmt.Sprintf("Dataset too large for arithmetic subsequence (more than %d distinct values)", limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	writeJSON(w, longestArithmetic(values))
+}
+
+// longestArithmetic находит самую длинную арифметическую подпоследовательность в строго
+// возрастающем срезе. lengths[i][d] - длина прогрессии с шагом d, оканчивающейся на sorted[i].
+// При равной длине выбирается прогрессия, которая заканчивается раньше
+func longestArithmetic(sorted []int64) LongestArithmetic {
+	if len(sorted) < 2 {
+		return LongestArithmetic{Length: len(sorted), Subsequence: append([]int64{}, sorted...)}
+	}
+
+	lengths := make([]map[int64]int, len(sorted))
+	bestLen, bestEnd, bestDiff := 0, 0, int64(0)
+	for i := range sorted {
+		lengths[i] = make(map[int64]int, i)
+		for j := 0; j < i; j++ {
+			d := sorted[i] - sorted[j]
+			n := lengths[j][d] + 1
+			if n == 1 {
+				n = 2
+			}
+			lengths[i][d] = n
+			if n > bestLen {
+				bestLen, bestEnd, bestDiff = n, i, d
+			}
+		}
+	}
+
+	subsequence := make([]int64, bestLen)
+	for k := range subsequence {
+		subsequence[k] = sorted[bestEnd] - int64(bestLen-1-k)*bestDiff
+	}
+	return LongestArithmetic{Length: bestLen, Difference: &bestDiff, Subsequence: subsequence}
+}
+
+// Shape представляет форму распределения: коэффициенты асимметрии и эксцесса.
+// Поля равны null, если значений меньше двух или все значения одинаковы (нулевая дисперсия)
+type Shape struct {
+	Count    int      `json:"count"`
+	Skewness *float64 `json:"skewness"`
+	Kurtosis *float64 `json:"kurtosis"`
+}
+
+// handleShape возвращает асимметрию и избыточный эксцесс значений (см. distributionShape)
+func (app *App) handleShape(w http.ResponseWriter, r *http.Request) {
+	numbers, err := app.queryNumbers("SELECT value FROM live_numbers")
+	if err != nil {
+		log.Printf("Error fetching values for distribution shape: %v", err)
+		http.Error(w, "Failed to compute distribution shape", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, distributionShape(numbers))
+}
+
+// distributionShape вычисляет выборочные коэффициенты по центральным моментам
+// m_k = Σ(x - mean)^k / n:
+//
+//	skewness = m3 / m2^(3/2)
+//	kurtosis = m4 / m2^2 - 3
+//
+// Эксцесс избыточный, то есть для нормального распределения близок к нулю
+func distributionShape(values []int64) Shape {
+	result := Shape{Count: len(values)}
+	if len(values) < 2 {
+		return result
+	}
+
+	n := float64(len(values))
+	var sum float64
+	for _, v := range values {
+		sum += float64(v)
+	}
+	mean := sum / n
+
+	var m2, m3, m4 float64
+	for _, v := range values {
+		d := float64(v) - mean
+		m2 += d * d
+		m3 += d * d * d
+		m4 += d * d * d * d
+	}
+	m2, m3, m4 = m2/n, m3/n, m4/n
+	if m2 == 0 {
+		return result
+	}
+
+	skewness := m3 / math.Pow(m2, 1.5)
+	kurtosis := m4/(m2*m2) - 3
+	result.Skewness = &skewness
+	result.Kurtosis = &kurtosis
+	return result
+}
+
+// ClosestPair представляет два различных значения с наименьшей разностью
+type ClosestPair struct {
+	First      int64 `json:"first"`
+	Second     int64 `json:"second"`
+	Difference int64 `json:"difference"`
+}
+
+// handleClosestPair возвращает пару различных значений с наименьшей разностью. После сортировки
+// ближайшие значения стоят рядом, поэтому достаточно одного прохода по соседним парам; при
+// равных разностях выбирается пара с меньшими значениями. Если различных значений меньше двух,
+// возвращает 404
+func (app *App) handleClosestPair(w http.ResponseWriter, r *http.Request) {
+	values, err := app.queryNumbers("SELECT DISTINCT value FROM live_numbers ORDER BY value ASC")
+	if err != nil {
+		log.Printf("Error fetching values for closest pair: %v", err)
+		http.Error(w, "Failed to find closest pair", http.StatusInternalServerError)
+		return
+	}
+
+	pair, ok := closestPair(values)
+	if !ok {
+		http.Error(w, "At least two distinct values are required", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, pair)
+}
+
+// closestPair находит соседние значения строго возрастающего среза с наименьшей разностью
+func closestPair(sorted []int64) (ClosestPair, bool) {
+	if len(sorted) < 2 {
+		return ClosestPair{}, false
+	}
+	best := ClosestPair{First: sorted[0], Second: sorted[1], Difference: sorted[1] - sorted[0]}
+	for i := 2; i < len(sorted); i++ {
+		if d := sorted[i] - sorted[i-1]; d < best.Difference {
+			best = ClosestPair{First: sorted[i-1], Second: sorted[i], Difference: d}
+		}
+	}
+	return best, true
+}
+
+// Stats представляет основные статистики значений; для пустой таблицы все поля, кроме
+// count и sum, равны null
+type Stats struct {
+	Count  int      `json:"count"`
+	Min    *int64   `json:"min"`
+	Max    *int64   `json:"max"`
+	Sum    *big.Int `json:"sum"`
+	Mean   *float64 `json:"mean"`
+	Median *float64 `json:"median"`
+}
+
+// handleStats считает количество, минимум, максимум, сумму, среднее и медиану одним запросом.
+// Медиана - PERCENTILE_CONT(0.5): для четного количества это среднее двух центральных значений.
+// Сумма значений bigint в PostgreSQL имеет тип numeric и может не поместиться в int64, поэтому
+// читается текстом и передается числом произвольной длины
+func (app *App) handleStats(w http.ResponseWriter, r *http.Request) {
+	app.writeStats(w, "live_numbers")
+}
+
+// writeStats считает и отправляет статистику выборки from: таблицы или представления,
+// возможно с условием WHERE, параметры которого передаются в args
+func (app *App) writeStats(w http.ResponseWriter, from string, args ...interface{}) {
+	result, err := app.computeStats(from, args...)
+	if err != nil {
+		log.Printf("Error computing stats: %v", err)
+		http.Error(w, "Failed to compute stats", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+// computeStats считает статистику выборки from одним запросом. Общая точка для HTTP и gRPC
+func (app *App) computeStats(from string, args ...interface{}) (Stats, error) {
+	var (
+		result Stats
+		sum    string
+	)
+	err := app.DB.QueryRow(`
+		SELECT
+			COUNT(*),
+			MIN(value),
+			MAX(value),
+			COALESCE(SUM(value), 0)::text,
+			AVG(value)::float8,
+			PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY value)
+		FROM `+from, args...).Scan(&result.Count, &result.Min, &result.Max, &sum, &result.Mean, &result.Median)
+	if err != nil {
+		return result, err
+	}
+	result.Sum, err = parseBigInt(sum)
+	return result, err
+}
+
+// parseBigInt разбирает целое numeric, прочитанное текстом
+func parseBigInt(s string) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", s)
+	}
+	return n, nil
+}
@@ -0,0 +1,798 @@
+package main
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDecades тестирует группировку значений по десяткам, включая отрицательные
+func TestDecades(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{1, 5, 9, 12, 19, 35, -1, -10, -11} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/numbers/decades", nil)
+	w := httptest.NewRecorder()
+
+	app.routes().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var buckets []DecadeBucket
+	if err := json.NewDecoder(w.Body).Decode(&buckets); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &buckets)
+
+	expected := []DecadeBucket{
+		{Decade: -2, Range: "-20..-11", Count: 1},
+		{Decade: -1, Range: "-10..-1", Count: 2},
+		{Decade: 0, Range: "0..9", Count: 3},
+		{Decade: 1, Range: "10..19", Count: 2},
+		{Decade: 3, Range: "30..39", Count: 1},
+	}
+	if len(buckets) != len(expected) {
+		t.Fatalf("Expected buckets %v, got %v", expected, buckets)
+	}
+	for i, b := range expected {
+		if buckets[i] != b {
+			t.Errorf("Expected buckets %v, got %v", expected, buckets)
+			break
+		}
+	}
+}
+
+// TestPairwiseDiffStats тестирует статистику попарных разностей на небольшом наборе
+func TestPairwiseDiffStats(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	// Разности пар: |1-4| = 3, |1-10| = 9, |4-10| = 6
+	for _, num := range []int{1, 4, 10} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/numbers/pairwise-diff-stats", nil)
+	w := httptest.NewRecorder()
+
+	app.routes().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var stats PairwiseDiffStats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &stats)
+
+	if stats.Pairs != 3 {
+		t.Errorf("Expected 3 pairs, got %d", stats.Pairs)
+	}
+	if stats.Min == nil || stats.Min.Int64() != 3 {
+		t.Errorf("Expected min 3, got %v", stats.Min)
+	}
+	if stats.Max == nil || stats.Max.Int64() != 9 {
+		t.Errorf("Expected max 9, got %v", stats.Max)
+	}
+	if stats.Average == nil || *stats.Average != 6 {
+		t.Errorf("Expected average 6, got %v", stats.Average)
+	}
+
+	// При превышении лимита строк ожидается 413
+	app.Config.PairwiseMaxRows = 2
+	w = httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/pairwise-diff-stats", nil))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status %d, got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+}
+
+// TestPairwiseDiffStatsBigint тестирует, что разность считается в numeric и крайние значения
+// bigint дают разность больше int64 без ошибки
+func TestPairwiseDiffStatsBigint(t *testing.T) {
+	fc := &fakeConnector{
+		query: func(query string, _ []driver.NamedValue) (*fakeRows, error) {
+			if strings.HasPrefix(query, "SELECT COUNT(*) FROM live_numbers") {
+				return &fakeRows{columns: []string{"count"}, values: [][]driver.Value{{int64(2)}}}, nil
+			}
+			if !strings.Contains(query, "a.value::numeric - b.value::numeric") {
+				t.Errorf("Expected the difference to be computed in numeric, got %s", query)
+			}
+			// Пара math.MinInt64 и math.MaxInt64
+			return &fakeRows{
+				columns: []string{"count", "min", "max", "avg"},
+				values:  [][]driver.Value{{int64(1), "18446744073709551615", "18446744073709551615", 1.8446744073709552e19}},
+			}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/pairwise-diff-stats", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	want := `{"pairs":1,"min":18446744073709551615,"max":18446744073709551615,"average":18446744073709552000}`
+	if got := strings.TrimSpace(w.Body.String()); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+// TestRollingStddev тестирует скользящее стандартное отклонение, включая неполные окна в начале
+func TestRollingStddev(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{2, 4, 6, 6, 6} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/numbers/rolling-stddev?window=3", nil)
+	w := httptest.NewRecorder()
+
+	app.routes().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result []RollingStddev
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &result)
+
+	// Окна: [2], [2 4], [2 4 6], [4 6 6], [6 6 6]
+	expected := []RollingStddev{
+		{Value: 2, Stddev: 0, Window: 1},
+		{Value: 4, Stddev: 1, Window: 2},
+		{Value: 6, Stddev: math.Sqrt(8.0 / 3), Window: 3},
+		{Value: 6, Stddev: math.Sqrt(8.0 / 9), Window: 3},
+		{Value: 6, Stddev: 0, Window: 3},
+	}
+	if len(result) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, result)
+	}
+	for i, e := range expected {
+		got := result[i]
+		if got.Value != e.Value || got.Window != e.Window || math.Abs(got.Stddev-e.Stddev) > 1e-9 {
+			t.Errorf("Row %d: expected %+v, got %+v", i, e, got)
+		}
+	}
+}
+
+// TestRollingStddevInvalidWindow тестирует проверку размера окна
+func TestRollingStddevInvalidWindow(t *testing.T) {
+	app := &App{}
+
+	for _, window := range []string{"", "0", "-3", "abc", "100000"} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/rolling-stddev?window="+window, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("window=%q: expected status %d, got %d", window, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestHarmonicMean тестирует среднее гармоническое на наборе положительных чисел
+func TestHarmonicMean(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	// 3 / (1/1 + 1/2 + 1/4) = 3 / 1.75
+	for _, num := range []int{1, 2, 4} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/harmonic-mean", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result HarmonicMean
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Count != 3 {
+		t.Errorf("Expected count 3, got %d", result.Count)
+	}
+	if result.HarmonicMean == nil || math.Abs(*result.HarmonicMean-3/1.75) > 1e-9 {
+		t.Errorf("Expected harmonic mean %v, got %v", 3/1.75, result.HarmonicMean)
+	}
+}
+
+// TestHarmonicMeanRejectsZero тестирует отказ при наличии нуля в данных
+func TestHarmonicMeanRejectsZero(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{1, 0, 4} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/harmonic-mean", nil))
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
+
+// TestPopcount тестирует распределение по числу единичных битов без учета отрицательных значений
+func TestPopcount(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	// 0 -> 0 бит; 1, 2, 8 -> 1 бит; 3, 5 -> 2 бита; 7 -> 3 бита; -1 исключается
+	for _, num := range []int{0, 1, 2, 8, 3, 5, 7, -1} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/popcount", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result []PopcountBucket
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &result)
+
+	expected := []PopcountBucket{{Bits: 0, Count: 1}, {Bits: 1, Count: 3}, {Bits: 2, Count: 2}, {Bits: 3, Count: 1}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
+// TestDigitalRoot тестирует цифровой корень, включая ноль и отрицательные значения
+func TestDigitalRoot(t *testing.T) {
+	tests := map[int64]int{0: 0, 1: 1, 9: 9, 10: 1, 38: 2, 999: 9, 2147483647: 1, -38: 2, -9: 9, math.MaxInt64: 7, math.MinInt64: 8}
+	for n, want := range tests {
+		if got := digitalRoot(n); got != want {
+			t.Errorf("digitalRoot(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+// TestDigitalRoots тестирует распределение значений по цифровому корню
+func TestDigitalRoots(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	// 0 -> 0; 11, 38, -38 -> 2; 9, 18 -> 9
+	for _, num := range []int{0, 9, 18, 38, -38, 11} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/digital-roots", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result []DigitalRootBucket
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &result)
+
+	expected := []DigitalRootBucket{{Root: 0, Count: 1}, {Root: 2, Count: 3}, {Root: 9, Count: 2}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
+// TestTertiles тестирует границы терцилей и количество значений в группах
+func TestTertiles(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	// Пустая таблица: границ нет, группы пусты
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/tertiles", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var empty Tertiles
+	if err := json.NewDecoder(w.Body).Decode(&empty); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &empty)
+	if len(empty.Boundaries) != 0 || !reflect.DeepEqual(empty.Counts, []int{0, 0, 0}) {
+		t.Errorf("Expected no boundaries and zero counts, got %+v", empty)
+	}
+
+	for num := 1; num <= 9; num++ {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w = httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/tertiles", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result Tertiles
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	// Позиции 0.333 * 8 = 2.664 и 0.667 * 8 = 5.336 между значениями 1..9
+	expected := []float64{3.664, 6.336}
+	if len(result.Boundaries) != 2 {
+		t.Fatalf("Expected 2 boundaries, got %v", result.Boundaries)
+	}
+	for i, b := range expected {
+		if math.Abs(result.Boundaries[i]-b) > 1e-9 {
+			t.Errorf("Expected boundaries %v, got %v", expected, result.Boundaries)
+			break
+		}
+	}
+	if !reflect.DeepEqual(result.Counts, []int{3, 3, 3}) {
+		t.Errorf("Expected counts [3 3 3], got %v", result.Counts)
+	}
+}
+
+// TestWeightedSum тестирует взвешенную сумму отсортированных значений
+func TestWeightedSum(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{3, 1, 2} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	// 1*0.5 + 2*0.3 + 3*0.2 = 1.7
+	body := bytes.NewBufferString(`{"weights": [0.5, 0.3, 0.2]}`)
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers/weighted-sum", body))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result WeightedSum
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Count != 3 || math.Abs(result.WeightedSum-1.7) > 1e-9 {
+		t.Errorf("Expected count 3 and weighted sum 1.7, got %+v", result)
+	}
+}
+
+// TestWeightedSumLengthMismatch тестирует отказ при несовпадении количества весов и значений
+func TestWeightedSumLengthMismatch(t *testing.T) {
+	// Фиктивная база возвращает пустую таблицу
+	app := &App{DB: newFakeDB(t, &fakeConnector{})}
+
+	body := bytes.NewBufferString(`{"weights": [0.5, 0.5]}`)
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers/weighted-sum", body))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestLogBuckets тестирует группировку по порядкам величины, включая точные степени основания
+func TestLogBuckets(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{1, 9, 10, 99, 100, 999, 1000, 123456, 0, -5} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/log-buckets?base=10", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result []LogBucket
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &result)
+
+	expected := []LogBucket{
+		{Bucket: 0, Lower: 1, Upper: 10, Count: 2},
+		{Bucket: 1, Lower: 10, Upper: 100, Count: 2},
+		{Bucket: 2, Lower: 100, Upper: 1000, Count: 2},
+		{Bucket: 3, Lower: 1000, Upper: 10000, Count: 1},
+		{Bucket: 5, Lower: 100000, Upper: 1000000, Count: 1},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
+// TestLogBucketsInvalidBase тестирует проверку основания логарифма
+func TestLogBucketsInvalidBase(t *testing.T) {
+	app := &App{}
+
+	for _, base := range []string{"1", "0.5", "-10", "abc", "NaN", "Inf"} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/log-buckets?base="+base, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("base=%q: expected status %d, got %d", base, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestCategories тестирует количество значений по настроенным категориям
+func TestCategories(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	categories, err := parseCategories(`[{"name": "negative", "max": -1}, {"name": "small", "max": 10}, {"name": "medium", "max": 20}, {"name": "large"}]`)
+	if err != nil {
+		t.Fatalf("Failed to parse categories: %v", err)
+	}
+	app := &App{DB: db, Config: Config{Categories: categories}}
+
+	for _, num := range []int{-5, 0, 10, 21, 50, 500} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/categories", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result []CategoryCount
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &result)
+
+	// Границы включаются в категорию; пустая категория тоже присутствует в ответе
+	expected := []CategoryCount{{"negative", 1}, {"small", 2}, {"medium", 0}, {"large", 3}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
+// TestParseCategories тестирует проверку порядка границ и обязательных полей категорий
+func TestParseCategories(t *testing.T) {
+	invalid := []string{
+		`not json`,
+		`[]`,
+		`[{"name": "a", "max": 10}, {"name": "b", "max": 5}, {"name": "c"}]`,
+		`[{"name": "a", "max": 10}, {"name": "b", "max": 10}, {"name": "c"}]`,
+		`[{"name": "a", "max": 10}, {"name": "b", "max": 20}]`,
+		`[{"name": "a"}, {"name": "b"}]`,
+		`[{"name": "a", "max": 1}, {"name": "a"}]`,
+		`[{"max": 1}, {"name": "b"}]`,
+	}
+	for _, data := range invalid {
+		if _, err := parseCategories(data); err == nil {
+			t.Errorf("Expected error for %s", data)
+		}
+	}
+
+	expr, args := categoryCaseSQL(defaultCategories)
+	if expr != "CASE WHEN value <= $1 THEN 0 WHEN value <= $2 THEN 1 ELSE 2 END" || !reflect.DeepEqual(args, []interface{}{int64(9), int64(99)}) {
+		t.Errorf("Unexpected CASE expression %q with args %v", expr, args)
+	}
+}
+
+// TestGrowth тестирует накопленное количество значений по часам
+func TestGrowth(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, row := range []struct {
+		value     int
+		createdAt string
+	}{
+		{1, "2024-01-01 10:05:00"},
+		{2, "2024-01-01 10:40:00"},
+		{3, "2024-01-01 12:00:00"},
+		{4, "2024-01-02 09:59:59"},
+		{5, "2024-01-02 09:00:00"},
+	} {
+		app.DB.Exec("INSERT INTO numbers (value, created_at) VALUES ($1, $2)", row.value, row.createdAt)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/growth?granularity=hour", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result []GrowthPoint
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &result)
+
+	expected := []struct {
+		hour       string
+		cumulative int
+	}{
+		{"2024-01-01T10:00:00Z", 2},
+		{"2024-01-01T12:00:00Z", 3},
+		{"2024-01-02T09:00:00Z", 5},
+	}
+	if len(result) != len(expected) {
+		t.Fatalf("Expected %d points, got %v", len(expected), result)
+	}
+	for i, e := range expected {
+		if got := result[i].Time.UTC().Format(time.RFC3339); got != e.hour || result[i].Cumulative != e.cumulative {
+			t.Errorf("Point %d: expected %s=%d, got %s=%d", i, e.hour, e.cumulative, got, result[i].Cumulative)
+		}
+	}
+}
+
+// TestGrowthInvalidGranularity тестирует проверку шага временного ряда
+func TestGrowthInvalidGranularity(t *testing.T) {
+	app := &App{}
+
+	for _, g := range []string{"second", "week", "HOUR"} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/growth?granularity="+g, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("granularity=%q: expected status %d, got %d", g, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestLongestArithmetic тестирует поиск самой длинной арифметической подпоследовательности
+func TestLongestArithmetic(t *testing.T) {
+	tests := []struct {
+		sorted     []int64
+		length     int
+		difference *int64
+		want       []int64
+	}{
+		{[]int64{}, 0, nil, []int64{}},
+		{[]int64{5}, 1, nil, []int64{5}},
+		{[]int64{1, 3, 4, 5, 7, 9, 10}, 5, int64Ptr(2), []int64{1, 3, 5, 7, 9}},
+		{[]int64{-9, -4, 0, 1, 6, 11}, 5, int64Ptr(5), []int64{-9, -4, 1, 6, 11}},
+		{[]int64{1, 2, 4, 8}, 2, int64Ptr(1), []int64{1, 2}},
+	}
+	for _, tt := range tests {
+		got := longestArithmetic(tt.sorted)
+		if got.Length != tt.length || !reflect.DeepEqual(got.Difference, tt.difference) || !reflect.DeepEqual(got.Subsequence, tt.want) {
+			t.Errorf("longestArithmetic(%v) = %+v, want length %d subsequence %v", tt.sorted, got, tt.length, tt.want)
+		}
+	}
+}
+
+// TestLongestArithmeticEndpoint тестирует эндпоинт на наборе с повторяющимися значениями
+func TestLongestArithmeticEndpoint(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{10, 1, 3, 3, 4, 5, 7, 9, 9} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/longest-arithmetic", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result LongestArithmetic
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Length != 5 || !reflect.DeepEqual(result.Subsequence, []int64{1, 3, 5, 7, 9}) {
+		t.Errorf("Expected [1 3 5 7 9], got %+v", result)
+	}
+
+	// При превышении лимита различных значений ожидается 413
+	app.Config.PairwiseMaxRows = 6
+	w = httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/longest-arithmetic", nil))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status %d, got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+}
+
+// TestDistributionShape тестирует асимметрию и эксцесс, включая неопределенные случаи
+func TestDistributionShape(t *testing.T) {
+	shape := distributionShape([]int64{1, 2, 3, 10})
+	if shape.Skewness == nil || *shape.Skewness <= 0 {
+		t.Fatalf("Expected positive skewness for a right-skewed dataset, got %v", shape.Skewness)
+	}
+	if math.Abs(*shape.Skewness-1.0182337649086284) > 1e-9 {
+		t.Errorf("Expected skewness 1.0182, got %v", *shape.Skewness)
+	}
+	if shape.Kurtosis == nil || math.Abs(*shape.Kurtosis+0.7696) > 1e-9 {
+		t.Errorf("Expected kurtosis -0.7696, got %v", shape.Kurtosis)
+	}
+
+	// Симметричный набор не имеет асимметрии
+	if shape := distributionShape([]int64{1, 2, 3, 4, 5}); shape.Skewness == nil || math.Abs(*shape.Skewness) > 1e-12 {
+		t.Errorf("Expected zero skewness for a symmetric dataset, got %v", shape.Skewness)
+	}
+
+	for _, values := range [][]int64{{}, {7}, {4, 4, 4}} {
+		if shape := distributionShape(values); shape.Skewness != nil || shape.Kurtosis != nil || shape.Count != len(values) {
+			t.Errorf("distributionShape(%v): expected null moments, got %+v", values, shape)
+		}
+	}
+}
+
+// TestShapeEndpoint тестирует положительную асимметрию на наборе с длинным правым хвостом
+func TestShapeEndpoint(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{1, 1, 2, 2, 3, 50} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/shape", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var shape Shape
+	if err := json.NewDecoder(w.Body).Decode(&shape); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if shape.Count != 6 || shape.Skewness == nil || *shape.Skewness <= 0 {
+		t.Errorf("Expected positive skewness over 6 values, got %+v", shape)
+	}
+}
+
+// TestClosestPair тестирует поиск ближайшей пары, включая равные разности и повторы
+func TestClosestPair(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{30, 1, 15, 10, 14, 30, -20} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/closest-pair", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var pair ClosestPair
+	if err := json.NewDecoder(w.Body).Decode(&pair); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if pair != (ClosestPair{First: 14, Second: 15, Difference: 1}) {
+		t.Errorf("Expected 14 and 15, got %+v", pair)
+	}
+}
+
+// TestClosestPairTooFewValues тестирует 404, если различных значений меньше двух
+func TestClosestPairTooFewValues(t *testing.T) {
+	for _, values := range [][]driver.Value{nil, {int64(5)}} {
+		rows := &fakeRows{columns: []string{"value"}}
+		for _, v := range values {
+			rows.values = append(rows.values, []driver.Value{v})
+		}
+		app := &App{DB: newFakeDB(t, &fakeConnector{query: func(string, []driver.NamedValue) (*fakeRows, error) {
+			return rows, nil
+		}})}
+
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/closest-pair", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%d values: expected status %d, got %d", len(values), http.StatusNotFound, w.Code)
+		}
+	}
+
+	if pair, ok := closestPair([]int64{-5, 0, 5, 6, 11, 12}); !ok || pair != (ClosestPair{First: 5, Second: 6, Difference: 1}) {
+		t.Errorf("Expected the first of equally close pairs, got %+v", pair)
+	}
+}
+
+// TestStats тестирует статистики значений, включая медиану четного количества
+func TestStats(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{10, 1, 3, 2} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers/stats", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result Stats
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Count != 4 || result.Sum == nil || result.Sum.Int64() != 16 {
+		t.Errorf("Expected count 4 and sum 16, got %+v", result)
+	}
+	if result.Min == nil || *result.Min != 1 || result.Max == nil || *result.Max != 10 {
+		t.Errorf("Expected min 1 and max 10, got %v and %v", result.Min, result.Max)
+	}
+	if result.Mean == nil || *result.Mean != 4 {
+		t.Errorf("Expected mean 4, got %v", result.Mean)
+	}
+	if result.Median == nil || *result.Median != 2.5 {
+		t.Errorf("Expected median 2.5, got %v", result.Median)
+	}
+}
+
+// TestStatsEmpty тестирует null в статистиках пустой таблицы
+func TestStatsEmpty(t *testing.T) {
+	fc := &fakeConnector{
+		query: func(string, []driver.NamedValue) (*fakeRows, error) {
+			return &fakeRows{
+				columns: []string{"count", "min", "max", "sum", "avg", "median"},
+				values:  [][]driver.Value{{int64(0), nil, nil, int64(0), nil, nil}},
+			}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers/stats", nil))
+	expected := `{"count":0,"min":null,"max":null,"sum":0,"mean":null,"median":null}`
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != expected {
+		t.Errorf("Expected %s, got %d %s", expected, w.Code, w.Body.String())
+	}
+}
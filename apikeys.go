@@ -0,0 +1,165 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// APIKeyUsage представляет клиентский API ключ и статистику его использования
+type APIKeyUsage struct {
+	Name         string     `json:"name"`
+	RequestCount int64      `json:"request_count"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+}
+
+// CreateAPIKeyRequest представляет запрос на выпуск нового API ключа
+type CreateAPIKeyRequest struct {
+	Name string `json:"name"`
+}
+
+// CreateAPIKeyResponse содержит выпущенный ключ; он показывается только один раз
+type CreateAPIKeyResponse struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// hashAPIKey возвращает SHA-256 хэш ключа: в базе данных ключи в открытом виде не хранятся
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Ошибки проверки клиентского API ключа
+var (
+	errAPIKeyRequired = errors.New("API key is required")
+	errInvalidAPIKey  = errors.New("Invalid API key")
+)
+
+// useAPIKey проверяет ключ по таблице api_keys и увеличивает счетчик его запросов. Пустой ключ
+// возвращает errAPIKeyRequired, неизвестный - errInvalidAPIKey
+func (app *App) useAPIKey(key string) error {
+	if key == "" {
+		return errAPIKeyRequired
+	}
+	var name string
+	err := app.DB.QueryRow(`
+		UPDATE api_keys
+		SET request_count = request_count + 1, last_used_at = CURRENT_TIMESTAMP
+		WHERE key_hash = $1
+		RETURNING name`, hashAPIKey(key)).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errInvalidAPIKey
+	}
+	return err
+}
+
+// requireAPIKey проверяет заголовок X-API-Key по таблице api_keys и увеличивает счетчик
+// запросов ключа. Неизвестный ключ отклоняется с 401. Административные и отладочные
+// эндпоинты защищены ADMIN_TOKEN и ключ не требуют, как и /health. Если API_KEYS_ENABLED не задан,
+// запросы пропускаются без проверки
+func (app *App) requireAPIKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !app.Config.APIKeysEnabled || r.URL.Path == "/health" || strings.HasPrefix(r.URL.Path, "/admin/") || strings.HasPrefix(r.URL.Path, "/debug/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		err := app.useAPIKey(r.Header.Get("X-API-Key"))
+		switch {
+		case errors.Is(err, errAPIKeyRequired), errors.Is(err, errInvalidAPIKey):
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		case err != nil:
+			log.Printf("Error checking API key: %v", err)
+			http.Error(w, "Failed to check API key", http.StatusInternalServerError)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleAPIKeys обрабатывает административный эндпоинт /admin/api-keys:
+// GET возвращает статистику использования ключей, POST выпускает новый ключ
+func (app *App) handleAPIKeys(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		app.listAPIKeys(w, r)
+	case http.MethodPost:
+		app.createAPIKey(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// listAPIKeys возвращает все ключи со счетчиками запросов, начиная с самых используемых
+func (app *App) listAPIKeys(w http.ResponseWriter, r *http.Request) {
+	rows, err := app.DB.Query(`
+		SELECT name, request_count, created_at, last_used_at
+		FROM api_keys
+		ORDER BY request_count DESC, name ASC`)
+	if err != nil {
+		log.Printf("Error listing API keys: %v", err)
+		http.Error(w, "Failed to list API keys", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	keys := []APIKeyUsage{}
+	for rows.Next() {
+		var (
+			k        APIKeyUsage
+			lastUsed sql.NullTime
+		)
+		if err := rows.Scan(&k.Name, &k.RequestCount, &k.CreatedAt, &lastUsed); err != nil {
+			log.Printf("Error scanning API key: %v", err)
+			http.Error(w, "Failed to list API keys", http.StatusInternalServerError)
+			return
+		}
+		if lastUsed.Valid {
+			k.LastUsedAt = &lastUsed.Time
+		}
+		keys = append(keys, k)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating API keys: %v", err)
+		http.Error(w, "Failed to list API keys", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, keys)
+}
+
+// createAPIKey выпускает случайный ключ для клиента и сохраняет его хэш
+func (app *App) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		log.Printf("Error generating API key: %v", err)
+		http.Error(w, "Failed to create API key", http.StatusInternalServerError)
+		return
+	}
+	key := hex.EncodeToString(raw)
+
+	if _, err := app.DB.Exec("INSERT INTO api_keys (key_hash, name) VALUES ($1, $2)", hashAPIKey(key), req.Name); err != nil {
+		log.Printf("Error creating API key: %v", err)
+		http.Error(w, "Failed to create API key", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSONStatus(w, http.StatusCreated, CreateAPIKeyResponse{Name: req.Name, Key: key})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestAPIKeyAuthentication тестирует доступ с действительным и недействительным ключом
+// и увеличение счетчика использования
+func TestAPIKeyAuthentication(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	defer db.Exec("DELETE FROM api_keys")
+
+	app := &App{DB: db, Config: Config{APIKeysEnabled: true, AdminToken: "admin-token"}}
+	handler := app.routes()
+
+	// Выпуск ключа через административный эндпоинт
+	req := httptest.NewRequest(http.MethodPost, "/admin/api-keys", bytes.NewBufferString(`{"name": "client-a"}`))
+	req.Header.Set("Authorization", "Bearer admin-token")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var created CreateAPIKeyResponse
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	// Действительный ключ
+	for i := 0; i < 2; i++ {
+		req = httptest.NewRequest(http.MethodGet, "/numbers", nil)
+		req.Header.Set("X-API-Key", created.Key)
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d for valid key, got %d", http.StatusOK, w.Code)
+		}
+	}
+
+	// Недействительный ключ
+	req = httptest.NewRequest(http.MethodGet, "/numbers", nil)
+	req.Header.Set("X-API-Key", "unknown-key")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d for invalid key, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	// Счетчик использования увеличился на число успешных запросов
+	req = httptest.NewRequest(http.MethodGet, "/admin/api-keys", nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var usage []APIKeyUsage
+	if err := json.NewDecoder(w.Body).Decode(&usage); err != nil {
+		t.Fatalf("Failed to decode usage: %v", err)
+	}
+	assertNoNullArrays(t, &usage)
+	if len(usage) != 1 || usage[0].Name != "client-a" || usage[0].RequestCount != 2 {
+		t.Errorf("Expected client-a with 2 requests, got %+v", usage)
+	}
+	if usage[0].LastUsedAt == nil {
+		t.Error("Expected last_used_at to be set")
+	}
+}
+
+// TestAPIKeyMissing тестирует отказ без заголовка X-API-Key и доступ к административным
+// эндпоинтам без ключа
+func TestAPIKeyMissing(t *testing.T) {
+	app := &App{Config: Config{APIKeysEnabled: true}}
+	handler := app.routes()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	// Административные эндпоинты проверяются по ADMIN_TOKEN, а не по API ключу
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/api-keys", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for disabled admin endpoint, got %d", http.StatusNotFound, w.Code)
+	}
+}
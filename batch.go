@@ -0,0 +1,262 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultBatchChunkSize - количество значений в одной транзакции пакетной вставки по умолчанию
+	defaultBatchChunkSize = 1000
+
+	// maxBatchChunkSize ограничен числом параметров одного запроса PostgreSQL
+	maxBatchChunkSize = 65535
+)
+
+// defaultMaxBatchBodyBytes ограничивает размер тела пакетной вставки по умолчанию (64 MiB)
+const defaultMaxBatchBodyBytes = 64 << 20
+
+// BatchResult описывает итог пакетной вставки. Части сохраняются по порядку в отдельных
+// транзакциях; при ошибке оставшиеся части не выполняются, поэтому первые Inserted+Skipped
+// значений запроса обработаны, а остальные Failed - нет, и клиент может повторить запрос с
+// этого места. Skipped - значения, уже сохраненные в режиме UNIQUE_NUMBERS. С atomic=true
+// при ошибке Inserted равно 0
+type BatchResult struct {
+	Inserted int    `json:"inserted"`
+	Skipped  int    `json:"skipped,omitempty"`
+	Failed   int    `json:"failed"`
+	Chunks   int    `json:"chunks"`
+	Error    string `json:"error,omitempty"`
+}
+
+// handleBatch сохраняет JSON массив чисел частями по BATCH_CHUNK_SIZE значений. Массив
+// читается потоково, по одному элементу, поэтому в памяти находится не больше одной части
+// независимо от размера пакета. Каждое значение проверяется валидаторами при чтении, полная
+// часть сразу сохраняется. После первой ошибки (валидация, вставка части) вставка прекращается,
+// но массив дочитывается, чтобы сообщить количество несохраненных значений. В режиме MONOTONIC
+// часть с невозрастающим значением отклоняется с 409. Тело больше MAX_BATCH_BODY_BYTES
+// отклоняется с 413.
+//
+// С параметром atomic=true все части выполняются в одной транзакции без повторов, и любая
+// ошибка откатывает весь пакет. Добавленные значения публикуются в /numbers/stream только
+// после фиксации, поэтому до нее хранятся в памяти
+func (app *App) handleBatch(w http.ResponseWriter, r *http.Request) {
+	if limit := app.Config.MaxBatchBodyBytes; limit > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+	}
+
+	dec := json.NewDecoder(r.Body)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		if batchDecodeStatus(err) == http.StatusRequestEntityTooLarge {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid JSON: expected an array of integers", http.StatusBadRequest)
+		return
+	}
+
+	// tx - общая транзакция пакета с atomic=true, pending - добавленные в ней значения
+	var (
+		tx      *sql.Tx
+		pending []int64
+	)
+	if r.URL.Query().Get("atomic") == "true" {
+		var err error
+		if tx, err = app.DB.Begin(); err != nil {
+			log.Printf("Error starting batch transaction: %v", maskError(err))
+			http.Error(w, "Failed to save numbers", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+	}
+
+	chunkSize := app.batchChunkSize()
+	chunk := make([]int64, 0, chunkSize)
+	result := BatchResult{}
+	status := http.StatusOK
+	read := 0
+
+	// saveFailed выставляет статус ошибки базы данных: 503 с Retry-After, если база доступна
+	// только для чтения, иначе 500
+	saveFailed := func(message string, err error) {
+		result.Error = message
+		status = http.StatusInternalServerError
+		if isReadOnlyError(err) {
+			w.Header().Set("Retry-After", strconv.Itoa(app.readOnlyRetryAfter()))
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	// flush сохраняет накопленную часть, начинающуюся с индекса start
+	flush := func(start int) {
+		var inserted []int64
+		var err error
+		if tx != nil {
+			// Ошибка прерывает транзакцию PostgreSQL, поэтому часть атомарного пакета не повторяется
+			inserted, err = app.insertChunkTx(tx, chunk)
+		} else {
+			err = app.withRetry(func() (err error) {
+				inserted, err = app.insertChunk(chunk)
+				return err
+			})
+		}
+		var merr monotonicError
+		if errors.As(err, &merr) {
+			result.Error = fmt.Sprintf("Chunk starting at index %d: %v", start, err)
+			status = http.StatusConflict
+		} else if err != nil {
+			log.Printf("Error inserting batch chunk %d (indexes %d..%d): %v", result.Chunks+1, start, start+len(chunk)-1, maskError(err))
+			saveFailed("Failed to save chunk starting at index "+strconv.Itoa(start), err)
+		} else {
+			result.Inserted += len(inserted)
+			result.Skipped += len(chunk) - len(inserted)
+			result.Chunks++
+			if tx != nil {
+				pending = append(pending, inserted...)
+			} else {
+				app.Stream.publish(inserted...)
+			}
+		}
+		chunk = chunk[:0]
+	}
+
+	for dec.More() {
+		var v int64
+		if err := dec.Decode(&v); err != nil {
+			// Поток не восстановить: остаток массива не учитывается в Failed
+			if status == http.StatusOK {
+				status, result.Error = batchDecodeStatus(err), fmt.Sprintf("Invalid JSON at index %d: expected an integer", read)
+			}
+			break
+		}
+		index := read
+		read++
+		if status != http.StatusOK {
+			continue
+		}
+
+		if err := app.validateNumber(v); err != nil {
+			status, result.Error = http.StatusBadRequest, fmt.Sprintf("Value at index %d: %v", index, err)
+			continue
+		}
+		chunk = append(chunk, v)
+		if len(chunk) == chunkSize {
+			flush(index + 1 - chunkSize)
+		}
+	}
+	if status == http.StatusOK {
+		if _, err := dec.Token(); err != nil {
+			status, result.Error = batchDecodeStatus(err), "Invalid JSON: expected an array of integers"
+		} else if len(chunk) > 0 {
+			flush(read - len(chunk))
+		}
+	}
+
+	if tx != nil {
+		if status == http.StatusOK {
+			if err := tx.Commit(); err != nil {
+				log.Printf("Error committing batch of %d values: %v", read, maskError(err))
+				saveFailed("Failed to commit batch", err)
+			}
+		}
+		if status != http.StatusOK {
+			// Транзакция откатывается целиком: не сохранено ни одно значение
+			result.Inserted, result.Skipped, result.Chunks = 0, 0, 0
+		} else {
+			app.Stream.publish(pending...)
+		}
+	}
+
+	if result.Inserted > 0 {
+		app.dataChanged()
+	}
+	if status != http.StatusOK {
+		result.Failed = read - result.Inserted - result.Skipped
+		writeJSONStatus(w, status, result)
+		return
+	}
+	writeJSON(w, result)
+}
+
+// batchDecodeStatus возвращает 413 для тела больше MAX_BATCH_BODY_BYTES и 400 для прочих ошибок разбора
+func batchDecodeStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// batchChunkSize возвращает размер части пакетной вставки
+func (app *App) batchChunkSize() int {
+	if app.Config.BatchChunkSize <= 0 {
+		return defaultBatchChunkSize
+	}
+	return app.Config.BatchChunkSize
+}
+
+// insertChunk сохраняет значения одним многострочным INSERT в отдельной транзакции и возвращает
+// добавленные. В режиме UNIQUE_NUMBERS уже сохраненные значения пропускаются. В режиме MONOTONIC
+// часть сохраняется через insertMonotonic и отклоняется целиком, если значения не возрастают
+func (app *App) insertChunk(values []int64) ([]int64, error) {
+	tx, err := app.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	inserted, err := app.insertChunkTx(tx, values)
+	if err != nil {
+		return nil, err
+	}
+	return inserted, tx.Commit()
+}
+
+// insertChunkTx выполняет вставку insertChunk в транзакции вызывающего без фиксации
+func (app *App) insertChunkTx(tx *sql.Tx, values []int64) ([]int64, error) {
+	if app.Config.Monotonic {
+		if err := app.insertMonotonicTx(tx, values, 0, nil); err != nil {
+			return nil, err
+		}
+		return values, nil
+	}
+
+	placeholders := make([]string, len(values))
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		placeholders[i] = "($" + strconv.Itoa(i+1) + ")"
+		args[i] = v
+	}
+
+	query := "INSERT INTO numbers (value) VALUES " + strings.Join(placeholders, ", ")
+	if !app.Config.UniqueNumbers {
+		if _, err := tx.Exec(query, args...); err != nil {
+			return nil, err
+		}
+		return values, nil
+	}
+
+	if err := purgeExpiredValues(tx, values...); err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(query+" ON CONFLICT (value) DO NOTHING RETURNING value", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	inserted := make([]int64, 0, len(values))
+	for rows.Next() {
+		var v int64
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		inserted = append(inserted, v)
+	}
+	return inserted, rows.Err()
+}
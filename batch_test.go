@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// recordingConnector возвращает фиктивную базу, запоминающую значения каждого INSERT.
+// Вставка с номером failOn (начиная с 1) завершается ошибкой
+func recordingConnector(failOn int) (*fakeConnector, *[][]int) {
+	var (
+		mu     sync.Mutex
+		chunks [][]int
+	)
+	fc := &fakeConnector{
+		exec: func(query string, args []driver.NamedValue) (driver.Result, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if len(chunks)+1 == failOn {
+				chunks = append(chunks, nil)
+				return nil, errors.New("connection reset")
+			}
+			values := make([]int, len(args))
+			for i, a := range args {
+				values[i] = int(a.Value.(int64))
+			}
+			chunks = append(chunks, values)
+			return driver.RowsAffected(len(values)), nil
+		},
+	}
+	return fc, &chunks
+}
+
+// TestBatchChunks тестирует разбиение пакета на несколько частей и сохранение всех значений
+func TestBatchChunks(t *testing.T) {
+	fc, chunks := recordingConnector(0)
+	app := &App{DB: newFakeDB(t, fc), Config: Config{BatchChunkSize: 2}}
+
+	body := bytes.NewBufferString(`[5, 3, 8, 1, 9]`)
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers/batch", body))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var result BatchResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result != (BatchResult{Inserted: 5, Chunks: 3}) {
+		t.Errorf("Expected 5 inserted in 3 chunks, got %+v", result)
+	}
+
+	expected := [][]int{{5, 3}, {8, 1}, {9}}
+	if !reflect.DeepEqual(*chunks, expected) {
+		t.Errorf("Expected chunks %v, got %v", expected, *chunks)
+	}
+}
+
+// TestBatchAtomic тестирует, что с atomic=true ошибка в последней части откатывает уже
+// вставленные части той же транзакции
+func TestBatchAtomic(t *testing.T) {
+	tests := []struct {
+		name   string
+		failOn int
+		body   string
+		status int
+	}{
+		{"insert error", 3, `[5, 3, 8, 1, 9]`, http.StatusInternalServerError},
+		{"invalid value", 0, `[5, 3, 8, 1, -9]`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc, chunks := recordingConnector(tt.failOn)
+			app := &App{DB: newFakeDB(t, fc), Config: Config{BatchChunkSize: 2}, Validators: []Validator{NonNegativeValidator{}}}
+
+			w := httptest.NewRecorder()
+			app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers/batch?atomic=true", bytes.NewBufferString(tt.body)))
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			var result BatchResult
+			if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if result.Inserted != 0 || result.Failed != 5 || result.Chunks != 0 || result.Error == "" {
+				t.Errorf("Expected the whole batch to fail, got %+v", result)
+			}
+
+			// Первая часть была вставлена, но транзакция откатилась без фиксации
+			if len(*chunks) < 2 || !reflect.DeepEqual((*chunks)[0], []int{5, 3}) {
+				t.Errorf("Expected the first chunks to run in the transaction, got %v", *chunks)
+			}
+			if fc.commits.Load() != 0 || fc.rollbacks.Load() != 1 {
+				t.Errorf("Expected 1 rollback and no commits, got %d commits and %d rollbacks", fc.commits.Load(), fc.rollbacks.Load())
+			}
+		})
+	}
+
+	t.Run("success", func(t *testing.T) {
+		fc, chunks := recordingConnector(0)
+		app := &App{DB: newFakeDB(t, fc), Config: Config{BatchChunkSize: 2}}
+
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers/batch?atomic=true", bytes.NewBufferString(`[5, 3, 8, 1, 9]`)))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var result BatchResult
+		if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if result != (BatchResult{Inserted: 5, Chunks: 3}) {
+			t.Errorf("Expected 5 inserted in 3 chunks, got %+v", result)
+		}
+		if len(*chunks) != 3 || fc.commits.Load() != 1 {
+			t.Errorf("Expected 3 chunks in one committed transaction, got %v and %d commits", *chunks, fc.commits.Load())
+		}
+	})
+}
+
+// TestBatchPartialFailure тестирует отчет о частичном сохранении при ошибке в средней части
+func TestBatchPartialFailure(t *testing.T) {
+	fc, chunks := recordingConnector(2)
+	app := &App{DB: newFakeDB(t, fc), Config: Config{BatchChunkSize: 2}}
+
+	body := bytes.NewBufferString(`[5, 3, 8, 1, 9]`)
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers/batch", body))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+
+	var result BatchResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Inserted != 2 || result.Failed != 3 || result.Chunks != 1 || result.Error == "" {
+		t.Errorf("Expected 2 inserted and 3 failed, got %+v", result)
+	}
+
+	// Части после ошибки не выполняются
+	if len(*chunks) != 2 {
+		t.Errorf("Expected processing to stop after the failed chunk, got %v", *chunks)
+	}
+}
+
+// TestBatchValidation тестирует отказ без вставки, если одно из значений не проходит проверку
+func TestBatchValidation(t *testing.T) {
+	fc, chunks := recordingConnector(0)
+	app := &App{DB: newFakeDB(t, fc), Validators: []Validator{NonNegativeValidator{}}}
+
+	for _, body := range []string{`[1, -2, 3]`, `{"number": 1}`, `[1, "x"]`} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers/batch", bytes.NewBufferString(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Body %s: expected status %d, got %d", body, http.StatusBadRequest, w.Code)
+		}
+	}
+	if len(*chunks) != 0 {
+		t.Errorf("Expected nothing inserted, got %v", *chunks)
+	}
+}
+
+// countingReader считает прочитанные из тела байты
+type countingReader struct {
+	r    io.Reader
+	read atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read.Add(int64(n))
+	return n, err
+}
+
+// TestBatchStreaming тестирует потоковую вставку большого массива: все значения сохраняются,
+// в памяти не больше одной части, а первая часть сохраняется до прочтения всего тела
+func TestBatchStreaming(t *testing.T) {
+	const total, chunkSize = 200000, 1000
+
+	var (
+		body           strings.Builder
+		inserted       atomic.Int64
+		maxChunk       atomic.Int64
+		readAtFirstIns atomic.Int64
+	)
+	body.WriteString("[")
+	for i := 0; i < total; i++ {
+		if i > 0 {
+			body.WriteString(",")
+		}
+		fmt.Fprint(&body, i)
+	}
+	body.WriteString("]")
+	reader := &countingReader{r: strings.NewReader(body.String())}
+
+	fc := &fakeConnector{
+		exec: func(query string, args []driver.NamedValue) (driver.Result, error) {
+			if inserted.Load() == 0 {
+				readAtFirstIns.Store(reader.read.Load())
+			}
+			inserted.Add(int64(len(args)))
+			if n := int64(len(args)); n > maxChunk.Load() {
+				maxChunk.Store(n)
+			}
+			return driver.RowsAffected(len(args)), nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc), Config: Config{BatchChunkSize: chunkSize}}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers/batch", io.NopCloser(reader)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var result BatchResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result != (BatchResult{Inserted: total, Chunks: total / chunkSize}) || inserted.Load() != total {
+		t.Errorf("Expected %d values in %d chunks, got %+v (%d inserted)", total, total/chunkSize, result, inserted.Load())
+	}
+	if maxChunk.Load() > chunkSize {
+		t.Errorf("Expected at most %d values per insert, got %d", chunkSize, maxChunk.Load())
+	}
+	if got := readAtFirstIns.Load(); got >= int64(body.Len()) {
+		t.Errorf("Expected the first chunk to be inserted before the whole body was read (%d of %d bytes)", got, body.Len())
+	}
+}
+
+// TestBatchStreamingValidation тестирует отчет о сохраненных частях при ошибке валидации в середине
+func TestBatchStreamingValidation(t *testing.T) {
+	fc, chunks := recordingConnector(0)
+	app := &App{DB: newFakeDB(t, fc), Config: Config{BatchChunkSize: 2}, Validators: []Validator{NonNegativeValidator{}}}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers/batch", bytes.NewBufferString(`[1, 2, 3, -4, 5]`)))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	var result BatchResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Inserted != 2 || result.Failed != 3 || !strings.Contains(result.Error, "index 3") {
+		t.Errorf("Expected 2 inserted and 3 failed at index 3, got %+v", result)
+	}
+	if !reflect.DeepEqual(*chunks, [][]int{{1, 2}}) {
+		t.Errorf("Expected only the first chunk to be inserted, got %v", *chunks)
+	}
+}
+
+// TestBatchBodyLimit тестирует отказ 413 для тела больше MAX_BATCH_BODY_BYTES
+func TestBatchBodyLimit(t *testing.T) {
+	fc, chunks := recordingConnector(0)
+	app := &App{DB: newFakeDB(t, fc), Config: Config{BatchChunkSize: 2, MaxBatchBodyBytes: 16}}
+
+	for _, body := range []string{`[1, 2, 3, 4, 5, 6, 7, 8, 9]`, `              [1]`} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers/batch", bytes.NewBufferString(body)))
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("Body %s: expected status %d, got %d", body, http.StatusRequestEntityTooLarge, w.Code)
+		}
+	}
+
+	// Части, прочитанные до превышения лимита, уже сохранены
+	if len(*chunks) == 0 {
+		t.Errorf("Expected chunks read before the limit to be inserted")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strconv"
+)
+
+var (
+	// errInvalidNumber - число записано не как целое в десятичной системе
+	errInvalidNumber = errors.New("Invalid number format")
+
+	// errNumberRange - целое число не помещается в BIGINT
+	errNumberRange = errors.New("number must fit in a signed 64-bit integer")
+)
+
+// parseNumber разбирает целое число из параметра запроса в int64. Слишком большое по модулю
+// число дает errNumberRange, а не ошибку формата
+func parseNumber(s string) (int64, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return 0, errNumberRange
+	}
+	if err != nil {
+		return 0, errInvalidNumber
+	}
+	return n, nil
+}
+
+// parseJSONNumber разбирает целое число из JSON. Кроме числа допускается строка с десятичной
+// записью ("9007199254740993"): клиенты на JavaScript теряют точность чисел больше 2^53 и
+// передают их строками. Значение разбирается как big.Int и должно помещаться в int64
+func parseJSONNumber(raw json.RawMessage) (int64, error) {
+	digits := string(raw)
+	if bytes.HasPrefix(raw, []byte(`"`)) {
+		if err := json.Unmarshal(raw, &digits); err != nil {
+			return 0, errInvalidNumber
+		}
+	}
+
+	n, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return 0, errInvalidNumber
+	}
+	if !n.IsInt64() {
+		return 0, errNumberRange
+	}
+	return n.Int64(), nil
+}
+
+// UnmarshalJSON читает поле number через parseJSONNumber, остальные поля - как обычно.
+// Отсутствующее или null число остается нулем
+func (r *NumberRequest) UnmarshalJSON(data []byte) error {
+	type plain NumberRequest
+	aux := struct {
+		*plain
+		Number json.RawMessage `json:"number"`
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if len(aux.Number) == 0 || string(aux.Number) == "null" {
+		return nil
+	}
+
+	n, err := parseJSONNumber(aux.Number)
+	if err != nil {
+		return err
+	}
+	r.Number = n
+	return nil
+}
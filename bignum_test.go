@@ -0,0 +1,126 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// TestParseNumber тестирует разбор параметра запроса на границах int64
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+		err  error
+	}{
+		{"42", 42, nil},
+		{"-9223372036854775808", math.MinInt64, nil},
+		{"9223372036854775807", math.MaxInt64, nil},
+		{"9223372036854775808", 0, errNumberRange},
+		{"-9223372036854775809", 0, errNumberRange},
+		{"1.5", 0, errInvalidNumber},
+		{"abc", 0, errInvalidNumber},
+		{"", 0, errInvalidNumber},
+	}
+	for _, tt := range tests {
+		got, err := parseNumber(tt.in)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("parseNumber(%q) = %d, %v; expected %d, %v", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+// TestParseJSONNumber тестирует числа и строки с десятичной записью в JSON
+func TestParseJSONNumber(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want int64
+		err  error
+	}{
+		{`7`, 7, nil},
+		{`"9007199254740993"`, 9007199254740993, nil},
+		{`"-9223372036854775808"`, math.MinInt64, nil},
+		{`9223372036854775807`, math.MaxInt64, nil},
+		{`9223372036854775808`, 0, errNumberRange},
+		{`"123456789012345678901234567890"`, 0, errNumberRange},
+		{`1.5`, 0, errInvalidNumber},
+		{`1e3`, 0, errInvalidNumber},
+		{`"abc"`, 0, errInvalidNumber},
+		{`" 1"`, 0, errInvalidNumber},
+		{`true`, 0, errInvalidNumber},
+	}
+	for _, tt := range tests {
+		got, err := parseJSONNumber(json.RawMessage(tt.raw))
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("parseJSONNumber(%s) = %d, %v; expected %d, %v", tt.raw, got, err, tt.want, tt.err)
+		}
+	}
+
+	var req NumberRequest
+	if err := json.Unmarshal([]byte(`{"number": null, "ttl_seconds": 5}`), &req); err != nil || req.Number != 0 || req.TTLSeconds == nil || *req.TTLSeconds != 5 {
+		t.Errorf("Expected zero number and ttl 5, got %+v (%v)", req, err)
+	}
+}
+
+// TestAddBigNumberFake тестирует POST /numbers с числами на границе int64 без обращения
+// к реальной базе
+func TestAddBigNumberFake(t *testing.T) {
+	var inserted atomic.Value
+	fc := &fakeConnector{
+		exec: func(q string, args []driver.NamedValue) (driver.Result, error) {
+			if strings.HasPrefix(q, "INSERT INTO numbers") {
+				inserted.Store(args[0].Value)
+			}
+			return driver.RowsAffected(1), nil
+		},
+		query: func(q string, args []driver.NamedValue) (*fakeRows, error) {
+			if strings.HasPrefix(q, "INSERT INTO numbers") {
+				inserted.Store(args[0].Value)
+				return &fakeRows{columns: []string{"id"}, values: [][]driver.Value{{int64(1)}}}, nil
+			}
+			return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{int64(math.MaxInt64)}}}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	for _, body := range []string{`{"number": "9223372036854775807"}`, `{"number": 9223372036854775807}`} {
+		inserted.Store(int64(0))
+		req := httptest.NewRequest(http.MethodPost, "/numbers", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := serveChecked(t, app.routes(), req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d: %s", body, http.StatusOK, w.Code, w.Body.String())
+		}
+		if got := inserted.Load(); got != int64(math.MaxInt64) {
+			t.Errorf("%s: expected %d to be inserted, got %v", body, int64(math.MaxInt64), got)
+		}
+		if expected := `{"numbers":[9223372036854775807]}`; strings.TrimSpace(w.Body.String()) != expected {
+			t.Errorf("%s: expected %s, got %s", body, expected, w.Body.String())
+		}
+	}
+
+	for _, tt := range []struct {
+		target, body, message string
+	}{
+		{"/numbers", `{"number": 9223372036854775808}`, errNumberRange.Error()},
+		{"/numbers", `{"number": "-9223372036854775809"}`, errNumberRange.Error()},
+		{"/numbers", `{"number": "1.5"}`, errInvalidNumber.Error()},
+		{"/numbers?number=99999999999999999999", "", errNumberRange.Error()},
+	} {
+		req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+		if tt.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest || strings.TrimSpace(w.Body.String()) != tt.message {
+			t.Errorf("%s %s: expected status %d with %q, got %d %q", tt.target, tt.body, http.StatusBadRequest, tt.message, w.Code, w.Body.String())
+		}
+	}
+}
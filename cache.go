@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// responseCache хранит ответы читающих эндпоинтов в памяти. Каждая запись помнит версию
+// данных, при которой была получена; любая запись в базу увеличивает версию, поэтому
+// устаревшие ответы не отдаются даже до истечения TTL
+type responseCache struct {
+	ttl     time.Duration
+	version atomic.Uint64
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// cacheEntry представляет сохраненный ответ эндпоинта
+type cacheEntry struct {
+	version     uint64
+	expires     time.Time
+	contentType string
+	body        []byte
+}
+
+// newResponseCache создает кэш с заданным временем жизни записей
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{ttl: ttl, entries: make(map[string]cacheEntry)}
+}
+
+// invalidate помечает все сохраненные ответы устаревшими. Безопасно вызывать для nil кэша
+func (c *responseCache) invalidate() {
+	if c == nil {
+		return
+	}
+	c.version.Add(1)
+
+	c.mu.Lock()
+	c.entries = make(map[string]cacheEntry)
+	c.mu.Unlock()
+}
+
+// get возвращает актуальную запись по ключу
+func (c *responseCache) get(key string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || entry.version != c.version.Load() || time.Now().After(entry.expires) {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// put сохраняет ответ, если с начала его вычисления данные не менялись
+func (c *responseCache) put(key string, entry cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry.version != c.version.Load() {
+		return
+	}
+	entry.expires = time.Now().Add(c.ttl)
+	c.entries[key] = entry
+}
+
+// cacheRecorder перехватывает ответ обработчика для сохранения в кэш
+type cacheRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *cacheRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *cacheRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// cached оборачивает читающий обработчик кэшем по пути и параметрам запроса.
+// Если кэш отключен (CACHE_TTL не задан), обработчик вызывается напрямую
+func (app *App) cached(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := app.Cache
+		if c == nil {
+			h(w, r)
+			return
+		}
+
+		key := r.URL.Path + "?" + r.URL.Query().Encode()
+		if entry, ok := c.get(key); ok {
+			w.Header().Set("Content-Type", entry.contentType)
+			w.Header().Set("X-Cache", "HIT")
+			w.Write(entry.body)
+			return
+		}
+
+		version := c.version.Load()
+		w.Header().Set("X-Cache", "MISS")
+		rec := &cacheRecorder{ResponseWriter: w, status: http.StatusOK}
+		h(rec, r)
+
+		// Кэшируются только успешные ответы
+		if rec.status == http.StatusOK {
+			c.put(key, cacheEntry{
+				version:     version,
+				contentType: w.Header().Get("Content-Type"),
+				body:        rec.body.Bytes(),
+			})
+		}
+	}
+}
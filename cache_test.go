@@ -0,0 +1,80 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// decadesFakeDB возвращает фиктивную базу, отвечающую на запрос десятков
+func decadesFakeDB(t *testing.T) (*fakeConnector, *App) {
+	fc := &fakeConnector{
+		query: func(string, []driver.NamedValue) (*fakeRows, error) {
+			return &fakeRows{columns: []string{"decade", "count"}, values: [][]driver.Value{{int64(0), int64(3)}}}, nil
+		},
+	}
+	return fc, &App{DB: newFakeDB(t, fc), Cache: newResponseCache(time.Minute)}
+}
+
+// TestCacheHitSkipsQuery тестирует, что повторный запрос обслуживается из кэша без обращения к базе
+func TestCacheHitSkipsQuery(t *testing.T) {
+	fc, app := decadesFakeDB(t)
+	handler := app.routes()
+
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/numbers/decades", nil))
+	second := httptest.NewRecorder()
+	handler.ServeHTTP(second, httptest.NewRequest(http.MethodGet, "/numbers/decades", nil))
+
+	if got := fc.queries.Load(); got != 1 {
+		t.Errorf("Expected 1 query, got %d", got)
+	}
+	if second.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("Expected cache hit, got X-Cache %q", second.Header().Get("X-Cache"))
+	}
+	if first.Body.String() != second.Body.String() {
+		t.Errorf("Cached body %q differs from original %q", second.Body.String(), first.Body.String())
+	}
+	if second.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected JSON content type, got %q", second.Header().Get("Content-Type"))
+	}
+}
+
+// TestCacheInvalidatedByWrite тестирует, что запись в базу делает кэш недействительным
+func TestCacheInvalidatedByWrite(t *testing.T) {
+	fc, app := decadesFakeDB(t)
+	handler := app.routes()
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/numbers/decades", nil))
+
+	if err := app.storeNumber(5); err != nil {
+		t.Fatalf("storeNumber failed: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/decades", nil))
+
+	if got := fc.queries.Load(); got != 2 {
+		t.Errorf("Expected 2 queries after invalidation, got %d", got)
+	}
+	if w.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("Expected cache miss after write, got X-Cache %q", w.Header().Get("X-Cache"))
+	}
+}
+
+// TestCacheExpires тестирует истечение времени жизни записи
+func TestCacheExpires(t *testing.T) {
+	fc, app := decadesFakeDB(t)
+	app.Cache = newResponseCache(time.Millisecond)
+	handler := app.routes()
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/numbers/decades", nil))
+	time.Sleep(5 * time.Millisecond)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/numbers/decades", nil))
+
+	if got := fc.queries.Load(); got != 2 {
+		t.Errorf("Expected 2 queries after TTL expiry, got %d", got)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Политики DUPLICATE_POLICY для одинаковых одновременных вставок
+const (
+	// duplicatePolicyCount сохраняет каждую вставку отдельной строкой
+	duplicatePolicyCount = "count"
+
+	// duplicatePolicyIgnore сохраняет значение один раз; в режиме UNIQUE_NUMBERS уже
+	// сохраненное значение не добавляется
+	duplicatePolicyIgnore = "ignore"
+)
+
+// defaultCoalesceWindow - окно объединения вставок по умолчанию
+const defaultCoalesceWindow = 10 * time.Millisecond
+
+// insertCoalescer объединяет одинаковые вставки, пришедшие одновременно (например, при
+// шторме повторных запросов), в одну операцию с базой данных. Первая вставка значения
+// открывает окно; все вставки того же значения в пределах окна ждут и выполняются
+// одним запросом, получая общий результат
+type insertCoalescer struct {
+	window time.Duration
+	flush  func(value int64, count int) (int, error)
+
+	mu      sync.Mutex
+	pending map[int64]*insertBatch
+}
+
+// insertBatch накапливает количество одинаковых вставок в текущем окне
+type insertBatch struct {
+	count int
+	added int
+	err   error
+	done  chan struct{}
+}
+
+// newInsertCoalescer создает объединитель вставок; flush сохраняет count вставок значения и
+// возвращает количество добавленных строк
+func newInsertCoalescer(window time.Duration, flush func(value int64, count int) (int, error)) *insertCoalescer {
+	return &insertCoalescer{window: window, flush: flush, pending: make(map[int64]*insertBatch)}
+}
+
+// insert добавляет вставку значения в текущее окно и ждет ее выполнения. Возвращает true,
+// если для этой вставки добавлена строка: строки достаются вставкам в порядке их прихода
+func (c *insertCoalescer) insert(value int64) (bool, error) {
+	c.mu.Lock()
+	b, ok := c.pending[value]
+	if !ok {
+		b = &insertBatch{done: make(chan struct{})}
+		c.pending[value] = b
+		time.AfterFunc(c.window, func() {
+			// После удаления из pending к пакету больше никто не присоединится
+			c.mu.Lock()
+			delete(c.pending, value)
+			count := b.count
+			c.mu.Unlock()
+
+			b.added, b.err = c.flush(value, count)
+			close(b.done)
+		})
+	}
+	position := b.count
+	b.count++
+	c.mu.Unlock()
+
+	<-b.done
+	return position < b.added, b.err
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// TestCoalesceConcurrentInserts тестирует объединение одинаковых одновременных вставок в один запрос
+func TestCoalesceConcurrentInserts(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		copies []int64
+	)
+	fc := &fakeConnector{
+		exec: func(_ string, args []driver.NamedValue) (driver.Result, error) {
+			mu.Lock()
+			copies = append(copies, args[1].Value.(int64))
+			mu.Unlock()
+			return driver.RowsAffected(args[1].Value.(int64)), nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc), Config: Config{DuplicatePolicy: duplicatePolicyCount}}
+	app.Coalescer = newInsertCoalescer(50*time.Millisecond, app.insertCoalesced)
+
+	const requests = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- app.storeNumber(7)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("storeNumber failed: %v", err)
+		}
+	}
+
+	// Все вставки сохранены, но запросов к базе значительно меньше, чем вставок
+	execs := fc.execs.Load()
+	if execs >= requests/2 {
+		t.Errorf("Expected coalesced inserts, got %d DB operations for %d requests", execs, requests)
+	}
+	var total int64
+	for _, c := range copies {
+		total += c
+	}
+	if total != requests {
+		t.Errorf("Expected %d rows inserted, got %d", requests, total)
+	}
+}
+
+// TestCoalesceDistinctValues тестирует, что разные значения не объединяются
+func TestCoalesceDistinctValues(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		flushed = map[int64]int{}
+	)
+	c := newInsertCoalescer(20*time.Millisecond, func(value int64, count int) (int, error) {
+		mu.Lock()
+		flushed[value] += count
+		mu.Unlock()
+		return count, nil
+	})
+
+	var wg sync.WaitGroup
+	for _, v := range []int64{1, 2, 1, 3, 1} {
+		wg.Add(1)
+		go func(v int64) {
+			defer wg.Done()
+			c.insert(v)
+		}(v)
+	}
+	wg.Wait()
+
+	if flushed[1] != 3 || flushed[2] != 1 || flushed[3] != 1 {
+		t.Errorf("Expected counts {1:3 2:1 3:1}, got %v", flushed)
+	}
+}
+
+// TestCoalesceIgnoreUnique тестирует политику ignore в режиме UNIQUE_NUMBERS: одновременные
+// вставки значения выполняются одной вставкой ON CONFLICT DO NOTHING, которая повторяется
+// после временной ошибки, и добавленной считается только одна из них
+func TestCoalesceIgnoreUnique(t *testing.T) {
+	var inserts []string
+	fc := &fakeConnector{
+		query: func(q string, _ []driver.NamedValue) (*fakeRows, error) {
+			if !strings.HasPrefix(q, "INSERT") {
+				return &fakeRows{columns: []string{"value"}}, nil
+			}
+			inserts = append(inserts, q)
+			if len(inserts) == 1 {
+				return nil, &pq.Error{Code: "40001"}
+			}
+			return &fakeRows{columns: []string{"id"}, values: [][]driver.Value{{int64(1)}}}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc), Config: Config{UniqueNumbers: true, DuplicatePolicy: duplicatePolicyIgnore, DBRetries: 1}, RetryBudget: newRetryBudget(1, 0)}
+	app.Coalescer = newInsertCoalescer(50*time.Millisecond, app.insertCoalesced)
+
+	const requests = 10
+	var (
+		wg    sync.WaitGroup
+		added atomic.Int64
+	)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := app.storeExpiringNumber(7, 0)
+			if err != nil {
+				t.Errorf("storeExpiringNumber failed: %v", err)
+			}
+			if ok {
+				added.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(inserts) != 2 || !strings.Contains(inserts[1], "ON CONFLICT (value) DO NOTHING") {
+		t.Errorf("Expected one retried ON CONFLICT insert, got %v", inserts)
+	}
+	if added.Load() != 1 {
+		t.Errorf("Expected exactly one request to report the value as added, got %d", added.Load())
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+// TestDatabaseURLFromDiscreteVars тестирует сборку строки подключения из переменных PG*
+func TestDatabaseURLFromDiscreteVars(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected string
+	}{
+		{
+			name: "all discrete variables",
+			env: map[string]string{
+				"PGHOST": "db.internal", "PGPORT": "6432", "PGUSER": "app",
+				"PGPASSWORD": "s3cret", "PGDATABASE": "numbersdb", "PGSSLMODE": "require",
+			},
+			expected: "host=db.internal port=6432 user=app password=s3cret dbname=numbersdb sslmode=require",
+		},
+		{
+			name:     "values with spaces and quotes are quoted",
+			env:      map[string]string{"PGHOST": "localhost", "PGPASSWORD": `it's a \secret`},
+			expected: `host=localhost password='it\'s a \\secret'`,
+		},
+		{
+			name:     "DATABASE_URL takes precedence",
+			env:      map[string]string{"DATABASE_URL": "postgres://u@h/db", "PGHOST": "ignored"},
+			expected: "postgres://u@h/db",
+		},
+		{
+			name:     "default without any variables",
+			env:      map[string]string{},
+			expected: defaultDatabaseURL,
+		},
+	}
+
+	for _, tt := range tests {
+		getenv := func(key string) string { return tt.env[key] }
+		if got := databaseURL(getenv); got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+)
+
+// defaultScanChunkSize - размер части scanNumbers без CURSOR_FETCH_SIZE
+const defaultScanChunkSize = 1000
+
+// scanNumbers передает все числа по возрастанию в fn частями, не собирая их в памяти: через
+// курсор scanCursor частями по CURSOR_FETCH_SIZE, если он задан, иначе одним запросом, строки
+// которого драйвер читает по мере обработки, частями по defaultScanChunkSize
+func (app *App) scanNumbers(ctx context.Context, fn func(chunk []int64) error) error {
+	const query = "SELECT value FROM live_numbers ORDER BY value ASC"
+	if app.Config.CursorFetchSize > 0 {
+		return app.scanCursor(ctx, query, app.Config.CursorFetchSize, fn)
+	}
+
+	rows, err := app.DB.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	chunk := make([]int64, 0, defaultScanChunkSize)
+	for rows.Next() {
+		var num int64
+		if err := rows.Scan(&num); err != nil {
+			return err
+		}
+		chunk = append(chunk, num)
+		if len(chunk) == defaultScanChunkSize {
+			if err := fn(chunk); err != nil {
+				return err
+			}
+			chunk = chunk[:0]
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(chunk) > 0 {
+		return fn(chunk)
+	}
+	return nil
+}
+
+// scanCursor читает результат запроса частями по fetchSize строк через серверный курсор
+// (DECLARE ... FETCH) в одной транзакции REPEATABLE READ. Все части относятся к одному снимку
+// данных, поэтому вставки, выполненные во время чтения, в результат не попадают. Каждая часть
+// передается в fn и после этого не удерживается
+func (app *App) scanCursor(ctx context.Context, query string, fetchSize int, fn func(chunk []int64) error) error {
+	tx, err := app.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DECLARE numbers_cursor NO SCROLL CURSOR FOR "+query); err != nil {
+		return err
+	}
+
+	fetch := "FETCH FORWARD " + strconv.Itoa(fetchSize) + " FROM numbers_cursor"
+	chunk := make([]int64, 0, fetchSize)
+	for {
+		chunk, err = fetchChunk(ctx, tx, fetch, chunk[:0])
+		if err != nil {
+			return err
+		}
+		if len(chunk) == 0 {
+			break
+		}
+		if err := fn(chunk); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// fetchChunk выполняет один FETCH и добавляет прочитанные значения в chunk
+func fetchChunk(ctx context.Context, tx *sql.Tx, fetch string, chunk []int64) ([]int64, error) {
+	rows, err := tx.QueryContext(ctx, fetch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var num int64
+		if err := rows.Scan(&num); err != nil {
+			return nil, err
+		}
+		chunk = append(chunk, num)
+	}
+	return chunk, rows.Err()
+}
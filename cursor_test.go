@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestGetAllNumbersCursor тестирует чтение таблицы частями через DECLARE и FETCH
+func TestGetAllNumbersCursor(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		statements []string
+		remaining  = []int{1, 2, 3, 4, 5}
+	)
+	fc := &fakeConnector{
+		exec: func(query string, _ []driver.NamedValue) (driver.Result, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			statements = append(statements, query)
+			return driver.RowsAffected(0), nil
+		},
+		query: func(query string, _ []driver.NamedValue) (*fakeRows, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			statements = append(statements, query)
+			rows := &fakeRows{columns: []string{"value"}}
+			for len(remaining) > 0 && len(rows.values) < 2 {
+				rows.values = append(rows.values, []driver.Value{int64(remaining[0])})
+				remaining = remaining[1:]
+			}
+			return rows, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc), Config: Config{CursorFetchSize: 2}}
+
+	numbers, err := app.getAllNumbers()
+	if err != nil {
+		t.Fatalf("getAllNumbers failed: %v", err)
+	}
+	if !reflect.DeepEqual(numbers, []int64{1, 2, 3, 4, 5}) {
+		t.Errorf("Expected all values, got %v", numbers)
+	}
+
+	// DECLARE и FETCH до пустой части: 2 + 2 + 1 + 0 строк
+	if len(statements) != 5 || !strings.HasPrefix(statements[0], "DECLARE numbers_cursor") {
+		t.Fatalf("Expected DECLARE followed by 4 FETCH statements, got %q", statements)
+	}
+	for _, s := range statements[1:] {
+		if s != "FETCH FORWARD 2 FROM numbers_cursor" {
+			t.Errorf("Unexpected statement %q", s)
+		}
+	}
+}
+
+// TestCursorSnapshotConsistency тестирует, что вставки во время чтения курсором не попадают
+// в результат: все части читаются из одного снимка
+func TestCursorSnapshotConsistency(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db, Config: Config{CursorFetchSize: 3}}
+
+	for i := 1; i <= 10; i++ {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", i)
+	}
+
+	var read []int64
+	err := app.scanCursor(context.Background(), "SELECT value FROM live_numbers ORDER BY value ASC", 3, func(chunk []int64) error {
+		if len(read) == 0 {
+			// Одновременная запись в отдельном соединении во время чтения
+			for i := 0; i < 5; i++ {
+				if _, err := app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", 100+i); err != nil {
+					t.Errorf("Concurrent insert failed: %v", err)
+				}
+			}
+		}
+		read = append(read, chunk...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("scanCursor failed: %v", err)
+	}
+
+	expected := []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	if !reflect.DeepEqual(read, expected) {
+		t.Errorf("Expected the snapshot %v, got %v", expected, read)
+	}
+
+	// Вставленные значения видны следующему чтению
+	numbers, err := app.getAllNumbers()
+	if err != nil {
+		t.Fatalf("getAllNumbers failed: %v", err)
+	}
+	if len(numbers) != 15 {
+		t.Errorf("Expected 15 values after the concurrent inserts, got %v", numbers)
+	}
+}
+
+// cursorConnector возвращает фиктивную базу, отдающую values через курсор по fetchSize строк.
+// FETCH с номером failFetch (начиная с 1) завершается ошибкой. Запрос MIN/MAX возвращает крайние значения
+func cursorConnector(values []int64, fetchSize, failFetch int) *fakeConnector {
+	var (
+		mu      sync.Mutex
+		fetches int
+		pos     int
+	)
+	return &fakeConnector{
+		query: func(query string, _ []driver.NamedValue) (*fakeRows, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if strings.HasPrefix(query, "SELECT MIN(value), MAX(value)") {
+				return &fakeRows{columns: []string{"min", "max"}, values: [][]driver.Value{{values[0], values[len(values)-1]}}}, nil
+			}
+			fetches++
+			if fetches == failFetch {
+				return nil, errors.New("connection reset")
+			}
+			rows := &fakeRows{columns: []string{"value"}}
+			for pos < len(values) && len(rows.values) < fetchSize {
+				rows.values = append(rows.values, []driver.Value{values[pos]})
+				pos++
+			}
+			return rows, nil
+		},
+	}
+}
+
+// TestScanNumbersChunks тестирует, что scanNumbers передает значения частями: по
+// CURSOR_FETCH_SIZE через курсор и по defaultScanChunkSize из одного запроса
+func TestScanNumbersChunks(t *testing.T) {
+	values := make([]int64, 2*defaultScanChunkSize+5)
+	for i := range values {
+		values[i] = int64(i)
+	}
+
+	tests := []struct {
+		name      string
+		fetchSize int
+		rows      int
+		expected  []int
+	}{
+		{"cursor", 2, 5, []int{2, 2, 1}},
+		{"single query", 0, len(values), []int{defaultScanChunkSize, defaultScanChunkSize, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Без курсора все строки приходят одним запросом
+			rowsPerQuery := tt.fetchSize
+			if rowsPerQuery == 0 {
+				rowsPerQuery = tt.rows
+			}
+			app := &App{DB: newFakeDB(t, cursorConnector(values[:tt.rows], rowsPerQuery, 0)), Config: Config{CursorFetchSize: tt.fetchSize}}
+
+			var sizes []int
+			read := 0
+			err := app.scanNumbers(context.Background(), func(chunk []int64) error {
+				sizes = append(sizes, len(chunk))
+				read += len(chunk)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("scanNumbers failed: %v", err)
+			}
+			if !reflect.DeepEqual(sizes, tt.expected) || read != tt.rows {
+				t.Errorf("Expected chunks %v, got %v", tt.expected, sizes)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// Коды ошибок PostgreSQL, которые приложение обрабатывает особым образом
+const (
+	pgReadOnlySQLTransaction = "25006"
+	pgNumericValueOutOfRange = "22003"
+	pgUniqueViolation        = "23505"
+)
+
+// transientErrorCodes - ошибки, после которых операцию можно безопасно повторить: запрос не
+// был применен (конфликт сериализации, взаимоблокировка, отказ в подключении)
+var transientErrorCodes = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"57P03": true, // cannot_connect_now
+	"08001": true, // sqlclient_unable_to_establish_sqlconnection
+	"08004": true, // sqlserver_rejected_establishment_of_sqlconnection
+}
+
+// defaultReadOnlyRetryAfter задает значение Retry-After (в секундах) для базы в режиме только чтения
+const defaultReadOnlyRetryAfter = 30
+
+// pgErrorCode возвращает SQLSTATE код ошибки PostgreSQL или пустую строку для прочих ошибок
+func pgErrorCode(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+	return ""
+}
+
+// isReadOnlyError сообщает, что запись отклонена, так как база данных доступна только для чтения
+// (например, подключение к реплике или переключение при отказе)
+func isReadOnlyError(err error) bool {
+	return pgErrorCode(err) == pgReadOnlySQLTransaction
+}
+
+// isOutOfRangeError сообщает, что результат вычисления не помещается в тип столбца
+func isOutOfRangeError(err error) bool {
+	return pgErrorCode(err) == pgNumericValueOutOfRange
+}
+
+// isUniqueViolation сообщает, что запись нарушила уникальный индекс
+func isUniqueViolation(err error) bool {
+	return pgErrorCode(err) == pgUniqueViolation
+}
+
+// isTransientError сообщает, что операция не была применена и ее можно повторить
+func isTransientError(err error) bool {
+	return errors.Is(err, driver.ErrBadConn) || transientErrorCodes[pgErrorCode(err)]
+}
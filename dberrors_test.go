@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+// TestAddNumberReadOnlyDatabase тестирует ответ 503 при записи в базу в режиме только чтения
+func TestAddNumberReadOnlyDatabase(t *testing.T) {
+	fc := &fakeConnector{
+		exec: func(string, []driver.NamedValue) (driver.Result, error) {
+			return nil, &pq.Error{Code: pgReadOnlySQLTransaction, Message: "cannot execute INSERT in a read-only transaction"}
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc), Config: Config{ReadOnlyRetryAfter: 15}}
+
+	req := httptest.NewRequest(http.MethodPost, "/numbers", bytes.NewBufferString(`{"number": 1}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	app.addNumber(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "15" {
+		t.Errorf("Expected Retry-After 15, got %q", got)
+	}
+	if !bytes.Contains(w.Body.Bytes(), []byte("read-only")) {
+		t.Errorf("Expected read-only message, got %q", w.Body.String())
+	}
+}
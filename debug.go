@@ -0,0 +1,59 @@
+package main
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// DebugConfigResponse представляет диагностическую информацию о развертывании
+type DebugConfigResponse struct {
+	Config          Config `json:"config"`
+	DatabaseVersion string `json:"database_version"`
+	SchemaVersion   int    `json:"schema_version"`
+	LatestSchema    int    `json:"latest_schema_version"`
+}
+
+// requireAdmin пропускает только запросы с заголовком "Authorization: Bearer <ADMIN_TOKEN>".
+// Если ADMIN_TOKEN не задан, административные эндпоинты отключены и возвращают 404
+func (app *App) requireAdmin(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if app.Config.AdminToken == "" {
+			http.NotFound(w, r)
+			return
+		}
+		// Токен без схемы Bearer не принимается
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(app.Config.AdminToken)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// handleDebugConfig возвращает действующую конфигурацию (без секретов), версию PostgreSQL
+// и состояние миграций схемы для диагностики неверно настроенных окружений
+func (app *App) handleDebugConfig(w http.ResponseWriter, r *http.Request) {
+	response := DebugConfigResponse{
+		Config:       app.Config.redacted(),
+		LatestSchema: latestSchemaVersion(),
+	}
+
+	if err := app.DB.QueryRow("SELECT version()").Scan(&response.DatabaseVersion); err != nil {
+		log.Printf("Error getting database version: %v", err)
+		http.Error(w, "Failed to retrieve database version", http.StatusInternalServerError)
+		return
+	}
+
+	version, err := schemaVersion(app.DB)
+	if err != nil {
+		log.Printf("Error getting schema version: %v", err)
+		http.Error(w, "Failed to retrieve schema version", http.StatusInternalServerError)
+		return
+	}
+	response.SchemaVersion = version
+
+	writeJSON(w, response)
+}
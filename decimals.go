@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const (
+	// defaultDecimalScale задает число знаков после запятой у дробных значений по умолчанию
+	defaultDecimalScale = 6
+
+	// maxDecimalScale - наибольшее допустимое значение DECIMAL_SCALE
+	maxDecimalScale = 30
+)
+
+// decimalPattern задает допустимую запись дробного числа: знак, цифры, дробная часть и
+// экспонента не длиннее трех цифр. NaN и бесконечности, которые принимает NUMERIC, отклоняются
+var decimalPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?([eE][+-]?[0-9]{1,3})?$`)
+
+// DecimalRequest представляет запрос на добавление дробного числа
+type DecimalRequest struct {
+	Number json.Number `json:"number"`
+}
+
+// DecimalsResponse представляет отсортированные дробные значения. Значения передаются JSON
+// числами без потери точности
+type DecimalsResponse struct {
+	Numbers []json.Number `json:"numbers"`
+}
+
+// handleDecimals обрабатывает запросы к дробным значениям /decimals: GET возвращает страницу
+// отсортированных значений, POST добавляет значение. Дробные значения хранятся в отдельной
+// таблице NUMERIC и округляются до DECIMAL_SCALE знаков после запятой
+func (app *App) handleDecimals(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		app.getDecimals(w, r)
+	case http.MethodPost:
+		app.addDecimal(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// getDecimals возвращает страницу дробных значений; параметры order, limit и offset работают
+// так же, как в GET /numbers
+func (app *App) getDecimals(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	desc, err := parseOrder(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var page numberFilter
+	if err := app.parsePage(q, &page); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if page.Keyset {
+		http.Error(w, "after is not supported for decimals", http.StatusBadRequest)
+		return
+	}
+
+	numbers, err := app.queryDecimals(desc, page.Limit, page.Offset)
+	if err != nil {
+		log.Printf("Error getting decimals: %v", err)
+		http.Error(w, "Failed to retrieve numbers", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, DecimalsResponse{Numbers: numbers})
+}
+
+// addDecimal сохраняет дробное число из JSON тела или параметра number и возвращает первую
+// страницу отсортированных значений
+func (app *App) addDecimal(w http.ResponseWriter, r *http.Request) {
+	value, err := parseDecimalRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = app.withRetry(func() error {
+		_, err := app.DB.Exec("INSERT INTO decimal_numbers (value) VALUES (ROUND($1::numeric, $2))", value, app.decimalScale())
+		return err
+	})
+	if err != nil {
+		app.writeStoreError(w, err)
+		return
+	}
+
+	numbers, err := app.queryDecimals(false, app.pageLimit(), 0)
+	if err != nil {
+		log.Printf("Error getting decimals: %v", err)
+		http.Error(w, "Failed to retrieve numbers", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, DecimalsResponse{Numbers: numbers})
+}
+
+// parseDecimalRequest читает дробное число из JSON тела или параметра number
+func parseDecimalRequest(r *http.Request) (string, error) {
+	value := r.URL.Query().Get("number")
+	if r.Header.Get("Content-Type") == "application/json" {
+		var req DecimalRequest
+		dec := json.NewDecoder(r.Body)
+		dec.UseNumber()
+		if err := dec.Decode(&req); err != nil {
+			return "", errors.New("Invalid JSON")
+		}
+		value = req.Number.String()
+	}
+
+	if value == "" {
+		return "", errors.New("Number is required")
+	}
+	if !decimalPattern.MatchString(value) {
+		return "", errors.New("Invalid number format")
+	}
+	return value, nil
+}
+
+// queryDecimals читает страницу дробных значений в порядке возрастания или убывания
+func (app *App) queryDecimals(desc bool, limit, offset int) ([]json.Number, error) {
+	order := "ASC"
+	if desc {
+		order = "DESC"
+	}
+	query := fmt.Sprintf("SELECT value::text FROM decimal_numbers ORDER BY value %s, id %s LIMIT $1 OFFSET $2", order, order)
+
+	rows, err := app.DB.Query(query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	numbers := []json.Number{}
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		numbers = append(numbers, json.Number(trimDecimal(s)))
+	}
+
+	return numbers, rows.Err()
+}
+
+// trimDecimal убирает незначащие нули дробной части, которые NUMERIC сохраняет после
+// округления: "1.250000" становится "1.25", "2.000000" - "2"
+func trimDecimal(s string) string {
+	if !strings.Contains(s, ".") {
+		return s
+	}
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// decimalScale возвращает число знаков после запятой у сохраняемых дробных значений
+func (app *App) decimalScale() int {
+	if app.Config.DecimalScale == nil {
+		return defaultDecimalScale
+	}
+	return *app.Config.DecimalScale
+}
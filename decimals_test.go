@@ -0,0 +1,125 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// TestTrimDecimal тестирует удаление незначащих нулей дробной части
+func TestTrimDecimal(t *testing.T) {
+	for in, expected := range map[string]string{
+		"1.250000": "1.25",
+		"2.000000": "2",
+		"-0.5":     "-0.5",
+		"100":      "100",
+	} {
+		if got := trimDecimal(in); got != expected {
+			t.Errorf("trimDecimal(%q): expected %q, got %q", in, expected, got)
+		}
+	}
+}
+
+// TestDecimals тестирует сохранение и округление дробных значений в реальной базе
+func TestDecimals(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if _, err := db.Exec("DELETE FROM decimal_numbers"); err != nil {
+		t.Fatalf("Failed to clean decimals: %v", err)
+	}
+	scale := 2
+	app := &App{DB: db, Config: Config{DecimalScale: &scale}}
+
+	for _, body := range []string{`{"number": 2.5}`, `{"number": -0.125}`, `{"number": 10}`} {
+		req := httptest.NewRequest(http.MethodPost, "/decimals", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+	}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/decimals", nil))
+	if expected := `{"numbers":[-0.13,2.5,10]}`; strings.TrimSpace(w.Body.String()) != expected {
+		t.Errorf("Expected %s, got %s", expected, w.Body.String())
+	}
+}
+
+// TestDecimalsFake тестирует разбор дробных значений, масштаб округления и вывод без обращения
+// к реальной базе
+func TestDecimalsFake(t *testing.T) {
+	var insert atomic.Value
+	fc := &fakeConnector{
+		exec: func(q string, args []driver.NamedValue) (driver.Result, error) {
+			insert.Store([]driver.Value{args[0].Value, args[1].Value})
+			return driver.RowsAffected(1), nil
+		},
+		query: func(q string, args []driver.NamedValue) (*fakeRows, error) {
+			if !strings.HasPrefix(q, "SELECT value::text FROM decimal_numbers ORDER BY value") {
+				t.Errorf("Unexpected query %q", q)
+			}
+			return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{"-1.500000"}, {"3.141593"}, {"7.000000"}}}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	req := httptest.NewRequest(http.MethodPost, "/decimals", strings.NewReader(`{"number": 3.14159265}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := serveChecked(t, app.routes(), req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if got, _ := insert.Load().([]driver.Value); !reflect.DeepEqual(got, []driver.Value{"3.14159265", int64(defaultDecimalScale)}) {
+		t.Errorf("Expected the exact value with the default scale, got %v", got)
+	}
+	var resp DecimalsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !reflect.DeepEqual(resp.Numbers, []json.Number{"-1.5", "3.141593", "7"}) {
+		t.Errorf("Expected trimmed values, got %v", resp.Numbers)
+	}
+
+	scale := 0
+	app.Config.DecimalScale = &scale
+	w = serveChecked(t, app.routes(), httptest.NewRequest(http.MethodPost, "/decimals?number=-2.5e2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if got, _ := insert.Load().([]driver.Value); !reflect.DeepEqual(got, []driver.Value{"-2.5e2", int64(0)}) {
+		t.Errorf("Expected the query value with scale 0, got %v", got)
+	}
+
+	if w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/decimals?order=desc&limit=3", nil)); w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	for _, tt := range []struct {
+		method, target, body string
+	}{
+		{http.MethodPost, "/decimals?number=NaN", ""},
+		{http.MethodPost, "/decimals?number=1e10000", ""},
+		{http.MethodPost, "/decimals?number=1.", ""},
+		{http.MethodPost, "/decimals", ""},
+		{http.MethodPost, "/decimals", `{"number": "abc"}`},
+		{http.MethodGet, "/decimals?after=", ""},
+		{http.MethodGet, "/decimals?order=up", ""},
+	} {
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		if tt.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s %s %s: expected status %d, got %d", tt.method, tt.target, tt.body, http.StatusBadRequest, w.Code)
+		}
+	}
+}
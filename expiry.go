@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// defaultExpirySweepInterval задает период удаления истекших значений
+const defaultExpirySweepInterval = time.Minute
+
+// sweepExpired удаляет значения, срок жизни которых истек, и возвращает количество удаленных строк
+func (app *App) sweepExpired(ctx context.Context) (int64, error) {
+	res, err := app.DB.ExecContext(ctx, "DELETE FROM numbers WHERE expires_at <= now()")
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		app.dataChanged()
+	}
+	return deleted, nil
+}
+
+// runExpirySweeper периодически удаляет истекшие значения и ключи идемпотентности старше
+// IDEMPOTENCY_KEY_TTL до отмены контекста. Истекшие значения скрыты из чтений и до удаления,
+// очистка лишь освобождает место в таблице
+func (app *App) runExpirySweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := app.sweepExpired(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Error deleting expired numbers: %v", err)
+				}
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("Deleted %d expired numbers", deleted)
+			}
+
+			if ttl := app.Config.IdempotencyKeyTTL; ttl > 0 {
+				deleted, err := app.sweepIdempotencyKeys(ctx, ttl)
+				if err != nil {
+					if ctx.Err() == nil {
+						log.Printf("Error deleting expired idempotency keys: %v", err)
+					}
+					continue
+				}
+				if deleted > 0 {
+					log.Printf("Deleted %d expired idempotency keys", deleted)
+				}
+			}
+		}
+	}
+}
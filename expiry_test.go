@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestAddNumberWithTTL тестирует сохранение момента истечения и проверку ttl_seconds
+func TestAddNumberWithTTL(t *testing.T) {
+	var query atomic.Value
+	fc := &fakeConnector{
+		exec: func(q string, args []driver.NamedValue) (driver.Result, error) {
+			query.Store(q)
+			if len(args) != 2 || args[0].Value != int64(5) || args[1].Value != float64(60) {
+				t.Errorf("Unexpected insert args %v", args)
+			}
+			return driver.RowsAffected(1), nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	req := httptest.NewRequest(http.MethodPost, "/numbers", bytes.NewBufferString(`{"number": 5, "ttl_seconds": 60}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if q, _ := query.Load().(string); !strings.Contains(q, "expires_at") {
+		t.Errorf("Expected insert with expires_at, got %q", q)
+	}
+
+	for _, target := range []string{"/numbers?number=5&ttl_seconds=0", "/numbers?number=5&ttl_seconds=-3", "/numbers?number=5&ttl_seconds=soon"} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", target, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestExpiredValueHiddenAndSwept тестирует, что истекшее значение скрыто из чтений и удаляется очисткой
+func TestExpiredValueHiddenAndSwept(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	app.DB.Exec("INSERT INTO numbers (value) VALUES (1)")
+	app.DB.Exec("INSERT INTO numbers (value, expires_at) VALUES (2, now() - interval '1 second')")
+	if _, err := app.storeExpiringNumber(3, time.Hour); err != nil {
+		t.Fatalf("storeExpiringNumber failed: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers", nil))
+
+	var response NumbersResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Numbers) != 2 || response.Numbers[0] != 1 || response.Numbers[1] != 3 {
+		t.Errorf("Expected [1 3] with the expired value hidden, got %v", response.Numbers)
+	}
+
+	deleted, err := app.sweepExpired(context.Background())
+	if err != nil {
+		t.Fatalf("sweepExpired failed: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("Expected 1 expired row deleted, got %d", deleted)
+	}
+
+	var stored int
+	app.DB.QueryRow("SELECT COUNT(*) FROM numbers").Scan(&stored)
+	if stored != 2 {
+		t.Errorf("Expected 2 rows left in the table, got %d", stored)
+	}
+}
+
+// TestRunExpirySweeper тестирует периодический запуск очистки и остановку по отмене контекста
+func TestRunExpirySweeper(t *testing.T) {
+	var sweeps atomic.Int64
+	fc := &fakeConnector{
+		exec: func(q string, _ []driver.NamedValue) (driver.Result, error) {
+			if strings.HasPrefix(q, "DELETE FROM numbers") {
+				sweeps.Add(1)
+			}
+			return driver.RowsAffected(0), nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		app.runExpirySweeper(ctx, 5*time.Millisecond)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for sweeps.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Sweeper did not stop after context cancellation")
+	}
+	if got := sweeps.Load(); got < 2 {
+		t.Errorf("Expected at least 2 sweeps, got %d", got)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// exportFlushRows - через сколько строк экспорт отправляет накопленные данные клиенту
+const exportFlushRows = 1000
+
+// exportRow представляет одну строку экспорта; ID заполняется, только если он запрошен
+type exportRow struct {
+	ID        int64
+	Value     int64
+	CreatedAt *time.Time
+}
+
+// streamNumbers построчно читает значения (и id записей, если withID) из базы и передает их
+// в emit, вызывая flush каждые exportFlushRows строк. Строки не накапливаются в памяти: драйвер читает их из сокета по мере
+// вызова Next, а запись в медленного клиента блокирует чтение (обратное давление). Отмена ctx
+// (отключение клиента) прерывает запрос в базе. Возвращает количество переданных строк
+func (app *App) streamNumbers(ctx context.Context, withID bool, emit func(exportRow) error, flush func() error) (int, error) {
+	columns := "value, created_at"
+	if withID {
+		columns = "id, " + columns
+	}
+	rows, err := app.DB.QueryContext(ctx, "SELECT "+columns+" FROM live_numbers ORDER BY value ASC, id ASC")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var (
+			row       exportRow
+			createdAt sql.NullTime
+		)
+		dest := []interface{}{&row.Value, &createdAt}
+		if withID {
+			dest = append([]interface{}{&row.ID}, dest...)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return count, err
+		}
+		if createdAt.Valid {
+			row.CreatedAt = &createdAt.Time
+		}
+		if err := emit(row); err != nil {
+			return count, err
+		}
+		count++
+		if count%exportFlushRows == 0 {
+			if err := flush(); err != nil {
+				return count, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+	return count, flush()
+}
+
+// logExportError пишет в журнал причину прерванного экспорта. Статус ответа уже отправлен,
+// поэтому клиент видит только оборванный файл
+func logExportError(r *http.Request, rows int, err error) {
+	if errors.Is(err, context.Canceled) || r.Context().Err() != nil {
+		log.Printf("Export aborted after %d rows: client disconnected", rows)
+		return
+	}
+	log.Printf("Error exporting numbers after %d rows: %v", rows, maskError(err))
+}
+
+// handleExport отдает все значения файлом в формате из параметра format: csv - столбцы id,
+// value, created_at, ndjson - объекты {"id":...,"value":...,"created_at":...} по одному на строку
+func (app *App) handleExport(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Query().Get("format") {
+	case "csv":
+		app.exportCSV(w, r, true)
+	case "ndjson":
+		app.exportJSONLines(w, r, "application/x-ndjson", "numbers.ndjson", true)
+	default:
+		http.Error(w, "format must be csv or ndjson", http.StatusBadRequest)
+	}
+}
+
+// handleExportCSV отдает все значения файлом CSV (value,created_at) в порядке возрастания
+func (app *App) handleExportCSV(w http.ResponseWriter, r *http.Request) {
+	app.exportCSV(w, r, false)
+}
+
+// exportCSV отдает все значения файлом CSV в порядке возрастания; withID добавляет первым
+// столбец id. Ответ передается потоково, память сервера не зависит от размера таблицы. Для
+// пустой таблицы файл содержит только заголовок
+func (app *App) exportCSV(w http.ResponseWriter, r *http.Request, withID bool) {
+	cw := csv.NewWriter(w)
+	rc := http.NewResponseController(w)
+	started := false
+
+	// start отправляет заголовки ответа и строку заголовка CSV перед первой строкой данных
+	start := func() error {
+		if started {
+			return nil
+		}
+		started = true
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="numbers.csv"`)
+		header := []string{"value", "created_at"}
+		if withID {
+			header = append([]string{"id"}, header...)
+		}
+		return cw.Write(header)
+	}
+	emit := func(row exportRow) error {
+		if err := start(); err != nil {
+			return err
+		}
+		createdAt := ""
+		if row.CreatedAt != nil {
+			createdAt = row.CreatedAt.UTC().Format(time.RFC3339Nano)
+		}
+		record := []string{strconv.FormatInt(row.Value, 10), createdAt}
+		if withID {
+			record = append([]string{strconv.FormatInt(row.ID, 10)}, record...)
+		}
+		return cw.Write(record)
+	}
+	flush := func() error {
+		if err := start(); err != nil {
+			return err
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	}
+
+	n, err := app.streamNumbers(r.Context(), withID, emit, flush)
+	if err == nil {
+		return
+	}
+	if !started {
+		log.Printf("Error exporting numbers: %v", maskError(err))
+		http.Error(w, "Failed to export numbers", http.StatusInternalServerError)
+		return
+	}
+	logExportError(r, n, err)
+}
+
+// ExportRecord представляет одну строку файла JSON Lines; id передается только в формате ndjson
+type ExportRecord struct {
+	ID        *int64     `json:"id,omitempty"`
+	Value     int64      `json:"value"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+// handleExportJSONL отдает все значения файлом JSON Lines: по одному объекту
+// {"value":N,"created_at":"..."} на строку в порядке возрастания
+func (app *App) handleExportJSONL(w http.ResponseWriter, r *http.Request) {
+	app.exportJSONLines(w, r, "application/jsonl; charset=utf-8", "numbers.jsonl", false)
+}
+
+// exportJSONLines отдает все значения по одному JSON объекту на строку в порядке возрастания
+// с типом contentType и именем файла filename; withID добавляет в объекты id записи. Каждая
+// строка кодируется сразу после чтения из базы и передается потоково, как CSV, поэтому память
+// не зависит от размера таблицы. Для пустой таблицы файл пустой
+func (app *App) exportJSONLines(w http.ResponseWriter, r *http.Request, contentType, filename string, withID bool) {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	rc := http.NewResponseController(w)
+	started := false
+
+	// start отправляет заголовки ответа перед первой строкой данных
+	start := func() {
+		if started {
+			return
+		}
+		started = true
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	}
+	emit := func(row exportRow) error {
+		start()
+		record := ExportRecord{Value: row.Value}
+		if withID {
+			record.ID = &row.ID
+		}
+		if row.CreatedAt != nil {
+			createdAt := row.CreatedAt.UTC()
+			record.CreatedAt = &createdAt
+		}
+		// Encode завершает каждый объект переводом строки
+		return enc.Encode(record)
+	}
+	flush := func() error {
+		start()
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	}
+
+	n, err := app.streamNumbers(r.Context(), withID, emit, flush)
+	if err == nil {
+		return
+	}
+	if !started {
+		log.Printf("Error exporting numbers: %v", maskError(err))
+		http.Error(w, "Failed to export numbers", http.StatusInternalServerError)
+		return
+	}
+	logExportError(r, n, err)
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// exportRowsConnector возвращает фиктивную базу, отдающую rows на любой запрос
+func exportRowsConnector(rows *fakeRows) *fakeConnector {
+	return &fakeConnector{
+		query: func(string, []driver.NamedValue) (*fakeRows, error) {
+			return rows, nil
+		},
+	}
+}
+
+// sequentialRows создает n строк (value, created_at) со значениями 0..n-1
+func sequentialRows(n int) *fakeRows {
+	values := make([][]driver.Value, n)
+	for i := range values {
+		values[i] = []driver.Value{int64(i), nil}
+	}
+	return &fakeRows{columns: []string{"value", "created_at"}, values: values}
+}
+
+// TestExportCSV тестирует содержимое и заголовки CSV файла, включая пустую таблицу
+func TestExportCSV(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	rows := &fakeRows{
+		columns: []string{"value", "created_at"},
+		values:  [][]driver.Value{{int64(-3), createdAt}, {int64(7), nil}},
+	}
+	app := &App{DB: newFakeDB(t, exportRowsConnector(rows))}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers/export.csv", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="numbers.csv"` {
+		t.Errorf("Unexpected Content-Disposition %q", got)
+	}
+	expected := "value,created_at\n-3,2024-01-01T10:00:00Z\n7,\n"
+	if w.Body.String() != expected {
+		t.Errorf("Expected body %q, got %q", expected, w.Body.String())
+	}
+
+	// Пустая таблица - только строка заголовка
+	app = &App{DB: newFakeDB(t, exportRowsConnector(sequentialRows(0)))}
+	w = serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers/export.csv", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "value,created_at\n" {
+		t.Errorf("Expected a header-only file, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+// TestExportFormatCSV тестирует столбцы id, value, created_at в /numbers/export?format=csv
+func TestExportFormatCSV(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	var query string
+	fc := &fakeConnector{
+		query: func(q string, _ []driver.NamedValue) (*fakeRows, error) {
+			query = q
+			return &fakeRows{
+				columns: []string{"id", "value", "created_at"},
+				values:  [][]driver.Value{{int64(12), int64(-3), createdAt}, {int64(5), int64(7), nil}},
+			}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers/export?format=csv", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if query != "SELECT id, value, created_at FROM live_numbers ORDER BY value ASC, id ASC" {
+		t.Errorf("Unexpected query %q", query)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("Unexpected Content-Type %q", got)
+	}
+	expected := "id,value,created_at\n12,-3,2024-01-01T10:00:00Z\n5,7,\n"
+	if w.Body.String() != expected {
+		t.Errorf("Expected body %q, got %q", expected, w.Body.String())
+	}
+
+	for _, target := range []string{"/numbers/export", "/numbers/export?format=xlsx"} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", target, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestExportFormatNDJSON тестирует объекты с id в /numbers/export?format=ndjson и отправку
+// строк клиенту частями
+func TestExportFormatNDJSON(t *testing.T) {
+	values := make([][]driver.Value, exportFlushRows+1)
+	for i := range values {
+		values[i] = []driver.Value{int64(100 + i), int64(i), nil}
+	}
+	var query string
+	fc := &fakeConnector{
+		query: func(q string, _ []driver.NamedValue) (*fakeRows, error) {
+			query = q
+			return &fakeRows{columns: []string{"id", "value", "created_at"}, values: values}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers/export?format=ndjson", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if !strings.HasPrefix(query, "SELECT id, value, created_at FROM live_numbers") {
+		t.Errorf("Unexpected query %q", query)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/x-ndjson" {
+		t.Errorf("Unexpected Content-Type %q", got)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="numbers.ndjson"` {
+		t.Errorf("Unexpected Content-Disposition %q", got)
+	}
+	if !w.Flushed {
+		t.Error("Expected the export to flush the response")
+	}
+
+	lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+	if len(lines) != len(values) {
+		t.Fatalf("Expected %d lines, got %d", len(values), len(lines))
+	}
+	if lines[1] != `{"id":101,"value":1,"created_at":null}` {
+		t.Errorf("Unexpected line %q", lines[1])
+	}
+}
+
+// TestExportJSONL тестирует разбор скачанного файла JSON Lines, включая пустую таблицу
+func TestExportJSONL(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	rows := &fakeRows{
+		columns: []string{"value", "created_at"},
+		values:  [][]driver.Value{{int64(-3), createdAt}, {int64(7), nil}},
+	}
+	app := &App{DB: newFakeDB(t, exportRowsConnector(rows))}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers/export.jsonl", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="numbers.jsonl"` {
+		t.Errorf("Unexpected Content-Disposition %q", got)
+	}
+
+	var records []ExportRecord
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var record ExportRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Failed to parse line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	if records[0].Value != -3 || records[0].CreatedAt == nil || !records[0].CreatedAt.Equal(createdAt) {
+		t.Errorf("Unexpected first record %+v", records[0])
+	}
+	if records[1].Value != 7 || records[1].CreatedAt != nil {
+		t.Errorf("Unexpected second record %+v", records[1])
+	}
+
+	// Пустая таблица - пустой файл
+	app = &App{DB: newFakeDB(t, exportRowsConnector(sequentialRows(0)))}
+	w = serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers/export.jsonl", nil))
+	if w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Errorf("Expected an empty file, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+// TestExportCSVFlushes тестирует периодическую отправку данных клиенту во время экспорта
+func TestExportCSVFlushes(t *testing.T) {
+	app := &App{DB: newFakeDB(t, exportRowsConnector(sequentialRows(exportFlushRows*2+1)))}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/export.csv", nil))
+
+	if !w.Flushed {
+		t.Error("Expected the export to flush the response")
+	}
+}
+
+// pipeResponseWriter передает тело ответа в канал без буфера, имитируя медленного клиента
+type pipeResponseWriter struct {
+	header http.Header
+	w      *io.PipeWriter
+}
+
+func (p *pipeResponseWriter) Header() http.Header         { return p.header }
+func (p *pipeResponseWriter) WriteHeader(int)             {}
+func (p *pipeResponseWriter) Write(b []byte) (int, error) { return p.w.Write(b) }
+func (p *pipeResponseWriter) Flush()                      {}
+
+// TestExportBackpressureAndAbort тестирует, что экспорт не читает строки из базы быстрее, чем
+// клиент их принимает, и завершается при отключении клиента
+func TestExportBackpressureAndAbort(t *testing.T) {
+	const total = 100000
+	rows := sequentialRows(total)
+	app := &App{DB: newFakeDB(t, exportRowsConnector(rows))}
+
+	pr, pw := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/numbers/export.csv", nil).WithContext(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		app.handleExportCSV(&pipeResponseWriter{header: http.Header{}, w: pw}, req)
+		pw.Close()
+		close(done)
+	}()
+
+	// Медленный клиент прочитал 100 строк и остановился
+	reader := bufio.NewReader(pr)
+	for i := 0; i < 100; i++ {
+		if _, err := reader.ReadString('\n'); err != nil {
+			t.Fatalf("Failed to read line %d: %v", i, err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	// Из базы прочитано не больше, чем помещается в буферы записи и чтения
+	if served := rows.served.Load(); served > 5000 {
+		t.Errorf("Expected export to wait for the slow client, but %d of %d rows were read", served, total)
+	}
+
+	// Клиент отключился
+	cancel()
+	pr.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Export did not stop after the client disconnected")
+	}
+	if served := rows.served.Load(); served >= total {
+		t.Errorf("Expected export to abort before reading all rows, read %d", served)
+	}
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// defaultFailoverCheckInterval задает период проверки основной базы данных по умолчанию
+const defaultFailoverCheckInterval = 5 * time.Second
+
+// defaultFailoverThreshold - количество подряд неудачных (или удачных) проверок основной базы
+// перед переключением на реплику (или возвратом) по умолчанию
+const defaultFailoverThreshold = 3
+
+// openDB открывает пул соединений с базой данных. Если задана реплика, соединения выдаются
+// через failoverConnector. Соединения, получившие ошибку из RECYCLE_ERROR_CODES, не
+// возвращаются в пул
+func openDB(cfg Config) (*sql.DB, error) {
+	primary, err := pq.NewConnector(cfg.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var connector driver.Connector = primary
+	if cfg.ReplicaDatabaseURL != "" {
+		replica, err := pq.NewConnector(cfg.ReplicaDatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		connector = newFailoverConnector(primary, replica, cfg.FailoverCheckInterval, cfg.FailoverThreshold, cfg.FailoverReadsOnly)
+	}
+
+	if len(cfg.RecycleErrorCodes) > 0 {
+		connector = newRecyclingConnector(connector, cfg.RecycleErrorCodes)
+	}
+	return sql.OpenDB(connector), nil
+}
+
+// failoverConnector выдает соединения с основной базой данных, пока она доступна, и с репликой,
+// если threshold проверок основной базы подряд не прошли. Фоновая проверка переключает новые
+// соединения обратно после threshold удачных проверок подряд, поэтому кратковременные сбои не
+// вызывают переключений; соединения к прежней цели не возвращаются в пул. Запись на реплику,
+// доступную только для чтения, отклоняется PostgreSQL и приводит к 503. С readsOnly на реплику
+// переключается только чтение, а запись остается на основной базе (см. routedConn)
+type failoverConnector struct {
+	primary   driver.Connector
+	replica   driver.Connector
+	interval  time.Duration
+	threshold int
+	readsOnly bool
+
+	// Подряд идущие неудачные и удачные проверки основной базы; изменяются только в check
+	failures  int
+	successes int
+
+	onReplica atomic.Bool
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// newFailoverConnector создает переключатель и запускает фоновую проверку основной базы.
+// Проверка останавливается вместе с sql.DB, так как DB.Close вызывает Close коннектора
+func newFailoverConnector(primary, replica driver.Connector, interval time.Duration, threshold int, readsOnly bool) *failoverConnector {
+	if threshold < 1 {
+		threshold = 1
+	}
+	fc := &failoverConnector{
+		primary:   primary,
+		replica:   replica,
+		interval:  interval,
+		threshold: threshold,
+		readsOnly: readsOnly,
+		stop:      make(chan struct{}),
+	}
+	if interval > 0 {
+		go fc.monitor()
+	}
+	return fc
+}
+
+// Connect открывает соединение с текущей целью
+func (fc *failoverConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	onReplica := fc.onReplica.Load()
+	target := fc.primary
+	if onReplica {
+		target = fc.replica
+	}
+
+	conn, err := target.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &routedConn{Conn: conn, onReplica: onReplica, fc: fc}, nil
+}
+
+// Driver возвращает драйвер основной базы данных
+func (fc *failoverConnector) Driver() driver.Driver {
+	return fc.primary.Driver()
+}
+
+// Close останавливает фоновую проверку
+func (fc *failoverConnector) Close() error {
+	fc.stopOnce.Do(func() { close(fc.stop) })
+	return nil
+}
+
+// usingReplica сообщает, направляются ли новые соединения на реплику
+func (fc *failoverConnector) usingReplica() bool {
+	return fc.onReplica.Load()
+}
+
+// monitor периодически проверяет основную базу данных до остановки коннектора
+func (fc *failoverConnector) monitor() {
+	ticker := time.NewTicker(fc.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-fc.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), fc.interval)
+			fc.check(ctx)
+			cancel()
+		}
+	}
+}
+
+// check проверяет основную базу данных и переключает цель соединений, когда threshold
+// проверок подряд дали одинаковый результат. На реплику переключается, только если она сама
+// доступна
+func (fc *failoverConnector) check(ctx context.Context) {
+	primaryErr := ping(ctx, fc.primary)
+	if primaryErr != nil {
+		fc.failures, fc.successes = fc.failures+1, 0
+	} else {
+		fc.failures, fc.successes = 0, fc.successes+1
+	}
+
+	switch {
+	case primaryErr != nil && !fc.usingReplica():
+		if fc.failures < fc.threshold {
+			log.Printf("Primary database check failed (%d of %d before failover): %v", fc.failures, fc.threshold, primaryErr)
+			return
+		}
+		if err := ping(ctx, fc.replica); err != nil {
+			log.Printf("Primary database unreachable (%v), replica unreachable too (%v); staying on primary", primaryErr, err)
+			return
+		}
+		fc.onReplica.Store(true)
+		log.Printf("Primary database unreachable (%v), failing over to replica", primaryErr)
+	case primaryErr == nil && fc.usingReplica():
+		if fc.successes < fc.threshold {
+			return
+		}
+		fc.onReplica.Store(false)
+		log.Printf("Primary database recovered, failing back from replica")
+	}
+}
+
+// ping открывает отдельное соединение и проверяет его, не затрагивая пул sql.DB
+func ping(ctx context.Context, c driver.Connector) error {
+	conn, err := c.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if p, ok := conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// routedConn помнит, к какой цели открыто соединение, чтобы после переключения sql.DB не
+// использовал его повторно. Остальные методы передаются соединению драйвера. При readsOnly
+// соединение с репликой направляет запись на основную базу: Exec, запросы, не начинающиеся с
+// SELECT, и транзакции без ReadOnly выполняются через отдельное соединение с основной базой,
+// открываемое при первой записи. Если основная база недоступна, запись завершается ошибкой
+type routedConn struct {
+	driver.Conn
+	onReplica bool
+	fc        *failoverConnector
+
+	// primary - соединение с основной базой для записи, tx - соединение открытой транзакции
+	primary driver.Conn
+	tx      driver.Conn
+}
+
+// target возвращает соединение для операции: соединение открытой транзакции, основную базу для
+// записи при readsOnly на реплике, иначе соединение драйвера
+func (c *routedConn) target(ctx context.Context, write bool) (driver.Conn, error) {
+	switch {
+	case c.tx != nil:
+		return c.tx, nil
+	case !write || !c.onReplica || !c.fc.readsOnly:
+		return c.Conn, nil
+	case c.primary == nil:
+		conn, err := c.fc.primary.Connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.primary = conn
+	}
+	return c.primary, nil
+}
+
+// isReadQuery сообщает, что запрос начинается с SELECT; остальные запросы, включая WITH и
+// INSERT ... RETURNING, считаются записью
+func isReadQuery(query string) bool {
+	q := strings.TrimSpace(query)
+	return len(q) >= len("SELECT") && strings.EqualFold(q[:len("SELECT")], "SELECT")
+}
+
+// Close закрывает соединение с основной базой для записи, если оно открывалось
+func (c *routedConn) Close() error {
+	if c.primary != nil {
+		c.primary.Close()
+	}
+	return c.Conn.Close()
+}
+
+// stale сообщает, что цель переключилась с момента открытия соединения
+func (c *routedConn) stale() bool {
+	return c.onReplica != c.fc.usingReplica()
+}
+
+// IsValid не дает вернуть в пул соединение к прежней цели
+func (c *routedConn) IsValid() bool {
+	if c.stale() {
+		return false
+	}
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// ResetSession отбрасывает соединение из пула, если цель переключилась, пока оно простаивало
+func (c *routedConn) ResetSession(ctx context.Context) error {
+	if c.stale() {
+		return driver.ErrBadConn
+	}
+	// Сбой соединения для записи не портит соединение с репликой: оно откроется заново
+	if r, ok := c.primary.(driver.SessionResetter); ok && r.ResetSession(ctx) != nil {
+		c.primary.Close()
+		c.primary = nil
+	}
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *routedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *routedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	conn, err := c.target(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := conn.(driver.ExecerContext); ok {
+		return e.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *routedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	conn, err := c.target(ctx, !isReadQuery(query))
+	if err != nil {
+		return nil, err
+	}
+	if q, ok := conn.(driver.QueryerContext); ok {
+		return q.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *routedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	conn, err := c.target(ctx, !isReadQuery(query))
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return conn.Prepare(query)
+}
+
+// BeginTx открывает транзакцию на цели по opts.ReadOnly; все операции транзакции выполняются
+// на той же цели до ее завершения
+func (c *routedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	conn, err := c.target(ctx, !opts.ReadOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx driver.Tx
+	if b, ok := conn.(driver.ConnBeginTx); ok {
+		tx, err = b.BeginTx(ctx, opts)
+	} else {
+		tx, err = conn.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.tx = conn
+	return routedTx{Tx: tx, conn: c}, nil
+}
+
+// routedTx снимает привязку соединения к цели транзакции после ее завершения
+type routedTx struct {
+	driver.Tx
+	conn *routedConn
+}
+
+func (tx routedTx) Commit() error {
+	tx.conn.tx = nil
+	return tx.Tx.Commit()
+}
+
+func (tx routedTx) Rollback() error {
+	tx.conn.tx = nil
+	return tx.Tx.Rollback()
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+// TestFailoverRoutesToReplica тестирует переключение на реплику при отказе основной базы
+// и возврат после ее восстановления
+func TestFailoverRoutesToReplica(t *testing.T) {
+	primary := &fakeConnector{}
+	replica := &fakeConnector{}
+	fc := newFailoverConnector(primary, replica, 0, 1, false)
+	db := sql.OpenDB(fc)
+	defer db.Close()
+
+	app := &App{DB: db}
+	ctx := context.Background()
+
+	if _, err := app.getAllNumbers(); err != nil {
+		t.Fatalf("Query on primary failed: %v", err)
+	}
+	if primary.queries.Load() != 1 || replica.queries.Load() != 0 {
+		t.Fatalf("Expected query on primary, got primary=%d replica=%d", primary.queries.Load(), replica.queries.Load())
+	}
+
+	// Основная база недоступна: после проверки запросы идут на реплику, включая
+	// соединение, которое уже лежит в пуле
+	primary.down.Store(true)
+	fc.check(ctx)
+	if !fc.usingReplica() {
+		t.Fatal("Expected failover to replica")
+	}
+	if _, err := app.getAllNumbers(); err != nil {
+		t.Fatalf("Query after failover failed: %v", err)
+	}
+	if primary.queries.Load() != 1 || replica.queries.Load() != 1 {
+		t.Errorf("Expected query on replica, got primary=%d replica=%d", primary.queries.Load(), replica.queries.Load())
+	}
+
+	// Основная база восстановилась: запросы возвращаются на нее
+	primary.down.Store(false)
+	fc.check(ctx)
+	if fc.usingReplica() {
+		t.Fatal("Expected failback to primary")
+	}
+	if _, err := app.getAllNumbers(); err != nil {
+		t.Fatalf("Query after failback failed: %v", err)
+	}
+	if primary.queries.Load() != 2 || replica.queries.Load() != 1 {
+		t.Errorf("Expected query on primary, got primary=%d replica=%d", primary.queries.Load(), replica.queries.Load())
+	}
+}
+
+// TestFailoverStaysOnPrimaryWithoutReplica тестирует, что недоступная реплика не становится целью
+func TestFailoverStaysOnPrimaryWithoutReplica(t *testing.T) {
+	primary := &fakeConnector{}
+	replica := &fakeConnector{}
+	fc := newFailoverConnector(primary, replica, 0, 1, false)
+
+	primary.down.Store(true)
+	replica.down.Store(true)
+	fc.check(context.Background())
+
+	if fc.usingReplica() {
+		t.Error("Expected to stay on primary when replica is unreachable")
+	}
+}
+
+// TestFailoverThreshold тестирует, что переключение и возврат происходят только после
+// threshold одинаковых проверок подряд, а одиночный сбой счетчик сбрасывает
+func TestFailoverThreshold(t *testing.T) {
+	primary := &fakeConnector{}
+	replica := &fakeConnector{}
+	fc := newFailoverConnector(primary, replica, 0, 3, false)
+	ctx := context.Background()
+
+	// Два сбоя, успешная проверка и снова два сбоя: подряд не набирается трех
+	primary.down.Store(true)
+	fc.check(ctx)
+	fc.check(ctx)
+	primary.down.Store(false)
+	fc.check(ctx)
+	primary.down.Store(true)
+	fc.check(ctx)
+	fc.check(ctx)
+	if fc.usingReplica() {
+		t.Fatal("Expected to stay on primary before 3 consecutive failures")
+	}
+
+	fc.check(ctx)
+	if !fc.usingReplica() {
+		t.Fatal("Expected failover after 3 consecutive failures")
+	}
+
+	primary.down.Store(false)
+	fc.check(ctx)
+	fc.check(ctx)
+	if !fc.usingReplica() {
+		t.Fatal("Expected to stay on replica before 3 consecutive successes")
+	}
+	fc.check(ctx)
+	if fc.usingReplica() {
+		t.Fatal("Expected failback after 3 consecutive successes")
+	}
+}
+
+// TestFailoverReadsOnly тестирует, что с FAILOVER_READS_ONLY на реплику уходит только чтение,
+// а запись и транзакции без ReadOnly выполняются на основной базе
+func TestFailoverReadsOnly(t *testing.T) {
+	primary := &fakeConnector{}
+	replica := &fakeConnector{}
+	fc := newFailoverConnector(primary, replica, 0, 1, true)
+	db := sql.OpenDB(fc)
+	defer db.Close()
+	ctx := context.Background()
+
+	primary.down.Store(true)
+	fc.check(ctx)
+	if !fc.usingReplica() {
+		t.Fatal("Expected failover to replica")
+	}
+
+	// Основная база недоступна: чтение обслуживает реплика, запись завершается ошибкой
+	rows, err := db.Query("SELECT value FROM live_numbers")
+	if err != nil {
+		t.Fatalf("Read on replica failed: %v", err)
+	}
+	rows.Close()
+	if _, err := db.Exec("INSERT INTO numbers (value) VALUES ($1)", 5); err == nil {
+		t.Error("Expected the write to fail while primary is down")
+	}
+	if replica.queries.Load() != 1 || replica.execs.Load() != 0 {
+		t.Errorf("Expected only the read on replica, got queries=%d execs=%d", replica.queries.Load(), replica.execs.Load())
+	}
+
+	// Основная база снова отвечает, но цель еще не переключилась: запись идет на нее
+	primary.down.Store(false)
+	if _, err := db.Exec("INSERT INTO numbers (value) VALUES ($1)", 5); err != nil {
+		t.Fatalf("Write on primary failed: %v", err)
+	}
+	rows, err = db.Query("INSERT INTO numbers (value) VALUES ($1) RETURNING id", 6)
+	if err != nil {
+		t.Fatalf("Write query on primary failed: %v", err)
+	}
+	rows.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	rows, err = tx.Query("SELECT value FROM numbers FOR UPDATE")
+	if err != nil {
+		t.Fatalf("Query in write transaction failed: %v", err)
+	}
+	rows.Close()
+	tx.Commit()
+	if primary.execs.Load() != 1 || primary.queries.Load() != 2 {
+		t.Errorf("Expected writes on primary, got execs=%d queries=%d", primary.execs.Load(), primary.queries.Load())
+	}
+
+	// Транзакция только для чтения остается на реплике
+	tx, err = db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("Failed to begin read-only transaction: %v", err)
+	}
+	if _, err := tx.Exec("DECLARE numbers_cursor NO SCROLL CURSOR FOR SELECT value FROM live_numbers"); err != nil {
+		t.Fatalf("Exec in read-only transaction failed: %v", err)
+	}
+	tx.Commit()
+	if replica.execs.Load() != 1 || primary.execs.Load() != 1 {
+		t.Errorf("Expected the read-only transaction on replica, got replica execs=%d primary execs=%d", replica.execs.Load(), primary.execs.Load())
+	}
+}
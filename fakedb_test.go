@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeRows описывает результат запроса, возвращаемый фиктивным драйвером
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+	pos     int
+
+	// served - количество прочитанных строк, доступное другим горутинам теста
+	served atomic.Int64
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.pos])
+	r.pos++
+	r.served.Add(1)
+	return nil
+}
+
+// fakeConnector реализует driver.Connector, позволяя тестам подменять результаты
+// и ошибки базы данных без запущенного PostgreSQL
+type fakeConnector struct {
+	mu    sync.Mutex
+	exec  func(query string, args []driver.NamedValue) (driver.Result, error)
+	query func(query string, args []driver.NamedValue) (*fakeRows, error)
+
+	opens     atomic.Int64
+	execs     atomic.Int64
+	queries   atomic.Int64
+	commits   atomic.Int64
+	rollbacks atomic.Int64
+
+	// down имитирует недоступную базу данных: новые соединения не открываются
+	down atomic.Bool
+}
+
+// newFakeDB создает *sql.DB поверх фиктивного драйвера и закрывает его по завершении теста
+func newFakeDB(t *testing.T, fc *fakeConnector) *sql.DB {
+	db := sql.OpenDB(fc)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func (fc *fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	if fc.down.Load() {
+		return nil, errors.New("fake database is down")
+	}
+	fc.opens.Add(1)
+	return &fakeConn{fc: fc}, nil
+}
+
+func (fc *fakeConnector) Driver() driver.Driver { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("fake driver supports only OpenDB")
+}
+
+type fakeConn struct {
+	fc *fakeConnector
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fake driver does not support prepared statements")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{fc: c.fc}, nil }
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return fakeTx{fc: c.fc}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.fc.execs.Add(1)
+	c.fc.mu.Lock()
+	exec := c.fc.exec
+	c.fc.mu.Unlock()
+	if exec == nil {
+		return driver.RowsAffected(1), nil
+	}
+	return exec(query, args)
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.fc.queries.Add(1)
+	c.fc.mu.Lock()
+	q := c.fc.query
+	c.fc.mu.Unlock()
+	if q == nil {
+		return &fakeRows{columns: []string{"value"}}, nil
+	}
+	rows, err := q(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+type fakeTx struct {
+	fc *fakeConnector
+}
+
+func (tx fakeTx) Commit() error {
+	tx.fc.commits.Add(1)
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.fc.rollbacks.Add(1)
+	return nil
+}
@@ -0,0 +1,601 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// numberFilter описывает комбинацию фильтров для выборки чисел
+type numberFilter struct {
+	// List - имя именованного списка; пустое имя означает основную таблицу
+	List string
+
+	Parity string
+	Min    *int64
+	Max    *int64
+	Desc   bool
+	Limit  int
+	Offset int
+
+	// Distinct оставляет каждое значение один раз
+	Distinct bool
+
+	// Verbose выбирает записи целиком (recordColumns) вместо одних значений
+	Verbose bool
+
+	// Tags оставляет числа, у которых есть все перечисленные метки
+	Tags []string
+
+	// Keyset-пагинация по (value, id): Keyset включает ее, After - позиция после предыдущей
+	// страницы; nil означает первую страницу
+	Keyset bool
+	After  *pageCursor
+
+	// Границы времени добавления: created_at >= Since и created_at < Until
+	Since *time.Time
+	Until *time.Time
+}
+
+// parseNumberFilter разбирает и проверяет параметры parity, min, max, order и limit
+func parseNumberFilter(q url.Values) (numberFilter, error) {
+	var f numberFilter
+
+	switch parity := q.Get("parity"); parity {
+	case "", "even", "odd":
+		f.Parity = parity
+	default:
+		return f, fmt.Errorf("parity must be \"even\" or \"odd\"")
+	}
+
+	if err := parseValueRange(q, &f); err != nil {
+		return f, err
+	}
+
+	desc, err := parseOrder(q)
+	if err != nil {
+		return f, err
+	}
+	f.Desc = desc
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return f, fmt.Errorf("limit must be a positive integer")
+		}
+		f.Limit = n
+	}
+
+	return f, nil
+}
+
+// parseValueRange разбирает границы значений min и max включительно
+func parseValueRange(q url.Values, f *numberFilter) error {
+	for _, p := range []struct {
+		name string
+		dst  **int64
+	}{{"min", &f.Min}, {"max", &f.Max}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s must be an integer", p.name)
+		}
+		*p.dst = &n
+	}
+	if f.Min != nil && f.Max != nil && *f.Min > *f.Max {
+		return fmt.Errorf("min must not be greater than max")
+	}
+	return nil
+}
+
+// parseOrder разбирает параметр order: asc (по умолчанию) или desc. Возвращает true для desc
+func parseOrder(q url.Values) (bool, error) {
+	switch order := q.Get("order"); order {
+	case "", "asc":
+		return false, nil
+	case "desc":
+		return true, nil
+	default:
+		return false, fmt.Errorf("order must be \"asc\" or \"desc\"")
+	}
+}
+
+const (
+	// defaultPageLimit - размер страницы GET /numbers, если limit не задан
+	defaultPageLimit = 1000
+
+	// maxPageLimit - наибольшая страница GET /numbers
+	maxPageLimit = 10000
+)
+
+// parsePage разбирает параметры limit и offset списка GET /numbers. Без limit возвращается
+// страница из DEFAULT_PAGE_LIMIT чисел, больше maxPageLimit за один запрос не отдается
+func (app *App) parsePage(q url.Values, f *numberFilter) error {
+	f.Limit = app.pageLimit()
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxPageLimit {
+			return fmt.Errorf("limit must be an integer between 1 and %d", maxPageLimit)
+		}
+		f.Limit = n
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("offset must be a non-negative integer")
+		}
+		f.Offset = n
+	}
+
+	// Пустой after начинает keyset-пагинацию с первой страницы
+	if q.Has("after") {
+		if f.Offset > 0 {
+			return fmt.Errorf("after and offset cannot be combined")
+		}
+		// Курсор указывает на строку по (value, id), а у повторов одного значения id разные
+		if f.Distinct {
+			return fmt.Errorf("after and distinct cannot be combined")
+		}
+		f.Keyset = true
+		if v := q.Get("after"); v != "" {
+			c, err := decodePageCursor(v)
+			if err != nil {
+				return fmt.Errorf("after must be a cursor from the X-Next-Cursor header")
+			}
+			f.After = &c
+		}
+	}
+	return nil
+}
+
+// pageCursor - позиция keyset-пагинации: значение и id последней отданной строки
+type pageCursor struct {
+	Value int64
+	ID    int64
+}
+
+// encode возвращает непрозрачное представление курсора для заголовка X-Next-Cursor
+func (c pageCursor) encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", c.Value, c.ID)))
+}
+
+// decodePageCursor разбирает курсор, полученный из encode
+func decodePageCursor(s string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return pageCursor{}, err
+	}
+	value, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return pageCursor{}, fmt.Errorf("malformed cursor")
+	}
+
+	var c pageCursor
+	if c.Value, err = strconv.ParseInt(value, 10, 64); err != nil {
+		return pageCursor{}, err
+	}
+	if c.ID, err = strconv.ParseInt(id, 10, 64); err != nil {
+		return pageCursor{}, err
+	}
+	return c, nil
+}
+
+// pageLimit возвращает размер страницы GET /numbers по умолчанию
+func (app *App) pageLimit() int {
+	if app.Config.DefaultPageLimit <= 0 {
+		return defaultPageLimit
+	}
+	return app.Config.DefaultPageLimit
+}
+
+// defaultMaxClockSkew - допустимое опережение часов клиента для параметра since по умолчанию
+const defaultMaxClockSkew = 30 * time.Second
+
+// parseTimeRange разбирает параметры since и until (RFC 3339) в фильтр; created_after и
+// created_before - их синонимы. Значение since в будущем в пределах MAX_CLOCK_SKEW считается
+// расхождением часов и заменяется текущим временем, иначе выборка была бы молча пустой;
+// большее опережение отклоняется ошибкой
+func (app *App) parseTimeRange(q url.Values, f *numberFilter) error {
+	for _, p := range []struct {
+		name, alias string
+		dst         **time.Time
+	}{{"since", "created_after", &f.Since}, {"until", "created_before", &f.Until}} {
+		name, v := p.name, q.Get(p.name)
+		if a := q.Get(p.alias); a != "" {
+			if v != "" {
+				return fmt.Errorf("%s and %s cannot be combined", p.name, p.alias)
+			}
+			name, v = p.alias, a
+		}
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+		}
+		*p.dst = &t
+	}
+
+	maxSkew := app.Config.MaxClockSkew
+	if maxSkew <= 0 {
+		maxSkew = defaultMaxClockSkew
+	}
+	if now := time.Now(); f.Since != nil && f.Since.After(now) {
+		skew := f.Since.Sub(now)
+		if skew > maxSkew {
+			return fmt.Errorf("since is %s in the future, more than the allowed clock skew of %s", skew.Round(time.Second), maxSkew)
+		}
+		log.Printf("Clamping since %s to now: %s in the future, within clock skew tolerance", f.Since.Format(time.RFC3339), skew)
+		f.Since = &now
+	}
+
+	if f.Since != nil && f.Until != nil && f.Since.After(*f.Until) {
+		return fmt.Errorf("since must not be after until")
+	}
+	return nil
+}
+
+// where объединяет все заданные фильтры в одно условие WHERE и возвращает его с аргументами
+func (f numberFilter) where() (string, []interface{}) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+	addArg := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.List != "" {
+		addArg("list = $%d", f.List)
+	}
+	switch f.Parity {
+	case "even":
+		conds = append(conds, "value % 2 = 0")
+	case "odd":
+		conds = append(conds, "value % 2 <> 0")
+	}
+	for _, tag := range f.Tags {
+		addArg("EXISTS (SELECT 1 FROM number_tags t WHERE t.number_id = live_numbers.id AND t.tag = $%d)", tag)
+	}
+	if f.Min != nil {
+		addArg("value >= $%d", *f.Min)
+	}
+	if f.Max != nil {
+		addArg("value <= $%d", *f.Max)
+	}
+	// created_at хранится без часового пояса в поясе сессии, поэтому границы приводятся к нему
+	if f.Since != nil {
+		addArg("created_at >= $%d::timestamptz::timestamp", *f.Since)
+	}
+	if f.Until != nil {
+		addArg("created_at < $%d::timestamptz::timestamp", *f.Until)
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// source возвращает таблицу выборки: живые числа основной таблицы или значения списков
+func (f numberFilter) source() string {
+	if f.List != "" {
+		return "list_numbers"
+	}
+	return "live_numbers"
+}
+
+// sql строит параметризованный запрос значений с фильтрами, порядком, limit и offset
+func (f numberFilter) sql() (string, []interface{}) {
+	where, args := f.where()
+
+	query := "SELECT value FROM " + f.source() + where
+	switch {
+	case f.Distinct:
+		query = "SELECT DISTINCT value FROM " + f.source() + where
+	case f.Verbose:
+		query = "SELECT " + recordColumns + " FROM " + f.source() + where
+	}
+	// Без DISTINCT одинаковые значения упорядочиваются по id, как в keysetSQL: иначе порядок
+	// строк с равным value не определен и соседние страницы offset могут пересекаться
+	dir := "ASC"
+	if f.Desc {
+		dir = "DESC"
+	}
+	if f.Distinct {
+		query += " ORDER BY value " + dir
+	} else {
+		query += " ORDER BY value " + dir + ", id " + dir
+	}
+	if f.Limit > 0 {
+		args = append(args, f.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if f.Offset > 0 {
+		args = append(args, f.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	return query, args
+}
+
+// keysetSQL строит запрос страницы keyset-пагинации: строки после f.After в порядке (value, id).
+// Условие по паре столбцов позволяет PostgreSQL начать чтение индекса с позиции курсора, а не
+// пропускать offset строк, поэтому стоимость страницы не растет с ее номером
+func (f numberFilter) keysetSQL() (string, []interface{}) {
+	where, args := f.where()
+
+	dir, cmp := "ASC", ">"
+	if f.Desc {
+		dir, cmp = "DESC", "<"
+	}
+	if f.After != nil {
+		args = append(args, f.After.Value, f.After.ID)
+		cond := fmt.Sprintf("(value, id) %s ($%d, $%d)", cmp, len(args)-1, len(args))
+		if where == "" {
+			where = " WHERE " + cond
+		} else {
+			where += " AND " + cond
+		}
+	}
+
+	columns := "value, id"
+	if f.Verbose {
+		columns = recordColumns
+	}
+	args = append(args, f.Limit)
+	query := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY value %s, id %s LIMIT $%d", columns, f.source(), where, dir, dir, len(args))
+	return query, args
+}
+
+// queryKeysetPage возвращает страницу keyset-пагинации и курсор следующей страницы; курсор
+// nil, если страница неполная и дальше строк нет. Одновременные запросы одной страницы
+// выполняются одним обращением к базе через app.Pages
+func (app *App) queryKeysetPage(f numberFilter) ([]int64, *pageCursor, error) {
+	query, args := f.keysetSQL()
+	return app.Pages.do(pageKey(query, args), func() ([]int64, *pageCursor, error) {
+		return app.readKeysetPage(query, args, f.Limit)
+	})
+}
+
+// readKeysetPage выполняет запрос страницы keyset-пагинации
+func (app *App) readKeysetPage(query string, args []interface{}, limit int) ([]int64, *pageCursor, error) {
+	rows, err := app.DB.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	numbers := []int64{}
+	var last pageCursor
+	for rows.Next() {
+		if err := rows.Scan(&last.Value, &last.ID); err != nil {
+			return nil, nil, err
+		}
+		numbers = append(numbers, last.Value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(numbers) < limit {
+		return numbers, nil, nil
+	}
+	return numbers, &last, nil
+}
+
+// countSQL строит запрос количества чисел (различных при Distinct), подходящих под фильтры, без учета limit и offset
+func (f numberFilter) countSQL() (string, []interface{}) {
+	where, args := f.where()
+	if f.Distinct {
+		return "SELECT COUNT(DISTINCT value) FROM " + f.source() + where, args
+	}
+	return "SELECT COUNT(*) FROM " + f.source() + where, args
+}
+
+// handleQuery возвращает числа, отобранные комбинацией фильтров parity, min, max, since, until,
+// order и limit, например четные числа от 10 до 100: /numbers/query?parity=even&min=10&max=100
+func (app *App) handleQuery(w http.ResponseWriter, r *http.Request) {
+	f, err := parseNumberFilter(r.URL.Query())
+	if err == nil {
+		err = app.parseTimeRange(r.URL.Query(), &f)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query, args := f.sql()
+	numbers, err := app.queryNumbers(query, args...)
+	if err != nil {
+		log.Printf("Error querying numbers: %v", err)
+		http.Error(w, "Failed to retrieve numbers", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, NumbersResponse{Numbers: numbers})
+}
+
+// isqrt возвращает целую часть квадратного корня неотрицательного числа. Результат
+// math.Sqrt уточняется целочисленно, так как для больших значений float64 теряет точность;
+// квадраты считаются в uint64, где они не переполняются для любого int64
+func isqrt(n int64) int64 {
+	u := uint64(n)
+	r := uint64(math.Sqrt(float64(n)))
+	for r > 0 && r*r > u {
+		r--
+	}
+	for (r+1)*(r+1) <= u {
+		r++
+	}
+	return int64(r)
+}
+
+// isPerfectSquare сообщает, является ли число полным квадратом; отрицательные числа им не являются
+func isPerfectSquare(n int64) bool {
+	if n < 0 {
+		return false
+	}
+	r := isqrt(n)
+	return r*r == n
+}
+
+// handlePerfectSquares возвращает отсортированные значения, являющиеся полными квадратами
+// (включая 0 и 1). Проверка выполняется в Go целочисленно, без погрешностей SQRT в SQL
+func (app *App) handlePerfectSquares(w http.ResponseWriter, r *http.Request) {
+	app.writeFilteredNumbers(w, "SELECT value FROM live_numbers WHERE value >= 0 ORDER BY value ASC", isPerfectSquare)
+}
+
+// handleWithinSigma возвращает отсортированные значения, отклоняющиеся от среднего не больше чем
+// на n стандартных отклонений (генеральная совокупность). Если все значения равны, отклонение
+// нулевое и возвращаются все значения
+func (app *App) handleWithinSigma(w http.ResponseWriter, r *http.Request) {
+	n, err := strconv.ParseFloat(r.URL.Query().Get("n"), 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) || n <= 0 {
+		http.Error(w, "n must be a positive number", http.StatusBadRequest)
+		return
+	}
+
+	numbers, err := app.queryNumbers(`
+		SELECT value
+		FROM (
+			SELECT value, AVG(value) OVER () AS mean, STDDEV_POP(value) OVER () AS stddev
+			FROM live_numbers
+		) s
+		WHERE ABS(value - mean)::float8 <= $1 * stddev::float8
+		ORDER BY value ASC`, n)
+	if err != nil {
+		log.Printf("Error querying numbers within sigma: %v", err)
+		http.Error(w, "Failed to retrieve numbers", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, NumbersResponse{Numbers: numbers})
+}
+
+// bitmaskConditions сопоставляет режиму match условие на биты маски
+var bitmaskConditions = map[string]string{
+	"all": "(value & $1::bigint) = $1::bigint",
+	"any": "(value & $1::bigint) <> 0",
+}
+
+// handleBitmask возвращает отсортированные значения, в которых установлены все (match=all, по
+// умолчанию) или хотя бы один (match=any) из битов маски. Отрицательные значения сравниваются
+// в дополнительном коде, поэтому у них установлены все старшие биты
+func (app *App) handleBitmask(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	mask, err := strconv.ParseInt(q.Get("mask"), 10, 64)
+	if err != nil || mask < 0 {
+		http.Error(w, "mask must be a non-negative integer", http.StatusBadRequest)
+		return
+	}
+	match := q.Get("match")
+	if match == "" {
+		match = "all"
+	}
+	cond, ok := bitmaskConditions[match]
+	if !ok {
+		http.Error(w, "match must be \"all\" or \"any\"", http.StatusBadRequest)
+		return
+	}
+
+	numbers, err := app.queryNumbers("SELECT value FROM live_numbers WHERE "+cond+" ORDER BY value ASC", mask)
+	if err != nil {
+		log.Printf("Error querying numbers by bitmask: %v", err)
+		http.Error(w, "Failed to retrieve numbers", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, NumbersResponse{Numbers: numbers})
+}
+
+// writeFilteredNumbers выполняет запрос и отправляет значения, удовлетворяющие условию keep
+func (app *App) writeFilteredNumbers(w http.ResponseWriter, query string, keep func(int64) bool) {
+	numbers, err := app.queryNumbers(query)
+	if err != nil {
+		log.Printf("Error querying numbers: %v", err)
+		http.Error(w, "Failed to retrieve numbers", http.StatusInternalServerError)
+		return
+	}
+
+	filtered := []int64{}
+	for _, n := range numbers {
+		if keep(n) {
+			filtered = append(filtered, n)
+		}
+	}
+
+	writeJSON(w, NumbersResponse{Numbers: filtered})
+}
+
+// maxFibonacciSquareTest - наибольшее значение, для которого 5n²+4 помещается в int64
+const maxFibonacciSquareTest = 1 << 30
+
+// isFibonacci сообщает, является ли число числом Фибоначчи: n входит в последовательность
+// тогда и только тогда, когда 5n²+4 или 5n²-4 - полный квадрат. Отрицательные числа не
+// считаются числами Фибоначчи, 0 считается. Для очень больших значений, где 5n² переполняет
+// int64, последовательность перебирается напрямую в uint64, где следующее после наибольшего
+// int64 число Фибоначчи не переполняется
+func isFibonacci(n int64) bool {
+	if n < 0 {
+		return false
+	}
+	if n > maxFibonacciSquareTest {
+		a, b := uint64(0), uint64(1)
+		for b < uint64(n) {
+			a, b = b, a+b
+		}
+		return b == uint64(n)
+	}
+	return isPerfectSquare(5*n*n+4) || isPerfectSquare(5*n*n-4)
+}
+
+// handleFibonacci возвращает отсортированные значения, являющиеся числами Фибоначчи
+func (app *App) handleFibonacci(w http.ResponseWriter, r *http.Request) {
+	app.writeFilteredNumbers(w, "SELECT value FROM live_numbers WHERE value >= 0 ORDER BY value ASC", isFibonacci)
+}
+
+// ValuesRequest представляет запрос со списком значений для сравнения с хранимыми
+type ValuesRequest struct {
+	Values []int64 `json:"values"`
+}
+
+// handleSymmetricDiff возвращает отсортированные значения, которые есть ровно в одном из двух
+// множеств: хранимом или переданном в запросе. Повторы не учитываются. Пустой список
+// значений допустим: тогда результат совпадает с множеством хранимых значений
+func (app *App) handleSymmetricDiff(w http.ResponseWriter, r *http.Request) {
+	var req ValuesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	numbers, err := app.queryNumbers(`
+		WITH stored AS (SELECT DISTINCT value FROM live_numbers),
+		     provided AS (SELECT DISTINCT unnest($1::bigint[]) AS value)
+		(SELECT value FROM stored EXCEPT SELECT value FROM provided)
+		UNION
+		(SELECT value FROM provided EXCEPT SELECT value FROM stored)
+		ORDER BY value ASC`, pq.Array(req.Values))
+	if err != nil {
+		log.Printf("Error computing symmetric difference: %v", err)
+		http.Error(w, "Failed to compute symmetric difference", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, NumbersResponse{Numbers: numbers})
+}
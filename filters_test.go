@@ -0,0 +1,803 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestNumberFilterSQL тестирует объединение нескольких фильтров в один параметризованный запрос
+func TestNumberFilterSQL(t *testing.T) {
+	f, err := parseNumberFilter(url.Values{
+		"parity": {"even"},
+		"min":    {"10"},
+		"max":    {"100"},
+		"order":  {"desc"},
+		"limit":  {"5"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	query, args := f.sql()
+	expectedQuery := "SELECT value FROM live_numbers WHERE value % 2 = 0 AND value >= $1 AND value <= $2 ORDER BY value DESC, id DESC LIMIT $3"
+	if query != expectedQuery {
+		t.Errorf("Expected query %q, got %q", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{int64(10), int64(100), 5}) {
+		t.Errorf("Expected args [10 100 5], got %v", args)
+	}
+
+	// У DISTINCT нет id, порядок задается только значением
+	f.Distinct = true
+	query, _ = f.sql()
+	expectedQuery = "SELECT DISTINCT value FROM live_numbers WHERE value % 2 = 0 AND value >= $1 AND value <= $2 ORDER BY value DESC LIMIT $3"
+	if query != expectedQuery {
+		t.Errorf("Expected distinct query %q, got %q", expectedQuery, query)
+	}
+}
+
+// TestParseNumberFilterInvalid тестирует отклонение некорректных параметров фильтра
+func TestParseNumberFilterInvalid(t *testing.T) {
+	invalid := []url.Values{
+		{"parity": {"prime"}},
+		{"min": {"abc"}},
+		{"min": {"10"}, "max": {"5"}},
+		{"order": {"random"}},
+		{"limit": {"0"}},
+	}
+	for _, q := range invalid {
+		if _, err := parseNumberFilter(q); err == nil {
+			t.Errorf("Expected error for %v", q)
+		}
+	}
+}
+
+// TestQueryCombinedFilters тестирует выборку четных чисел в диапазоне с сортировкой и лимитом
+func TestQueryCombinedFilters(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{4, 10, 11, 12, 50, 51, 100, 102} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/numbers/query?parity=even&min=10&max=100&order=desc&limit=3", nil)
+	w := httptest.NewRecorder()
+
+	app.routes().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response NumbersResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &response)
+
+	expected := []int64{100, 50, 12}
+	if !reflect.DeepEqual(response.Numbers, expected) {
+		t.Errorf("Expected numbers %v, got %v", expected, response.Numbers)
+	}
+}
+
+// TestIsPerfectSquare тестирует проверку полных квадратов, включая граничные случаи
+func TestIsPerfectSquare(t *testing.T) {
+	squares := []int64{0, 1, 4, 9, 1 << 30, 46340 * 46340, 999999999 * 999999999}
+	for _, n := range squares {
+		if !isPerfectSquare(n) {
+			t.Errorf("Expected %d to be a perfect square", n)
+		}
+	}
+
+	nonSquares := []int64{-1, -4, 2, 3, 8, 1<<30 + 1, 46340*46340 - 1, 999999999*999999999 - 1, 999999999*999999999 + 1}
+	for _, n := range nonSquares {
+		if isPerfectSquare(n) {
+			t.Errorf("Expected %d not to be a perfect square", n)
+		}
+	}
+}
+
+// TestPerfectSquares тестирует выборку только полных квадратов из смешанного набора
+func TestPerfectSquares(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{16, -4, 0, 2, 1, 15, 9, 2147395600} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/perfect-squares", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response NumbersResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &response)
+
+	expected := []int64{0, 1, 9, 16, 2147395600}
+	if !reflect.DeepEqual(response.Numbers, expected) {
+		t.Errorf("Expected numbers %v, got %v", expected, response.Numbers)
+	}
+}
+
+// TestIsFibonacci тестирует проверку чисел Фибоначчи, включая ноль, отрицательные и большие значения
+func TestIsFibonacci(t *testing.T) {
+	for _, n := range []int64{0, 1, 2, 3, 5, 8, 13, 21, 832040, 1836311903} {
+		if !isFibonacci(n) {
+			t.Errorf("Expected %d to be a Fibonacci number", n)
+		}
+	}
+	for _, n := range []int64{-1, -8, 4, 6, 7, 22, 832041, 1836311904} {
+		if isFibonacci(n) {
+			t.Errorf("Expected %d not to be a Fibonacci number", n)
+		}
+	}
+}
+
+// TestFibonacci тестирует выборку только чисел Фибоначчи из смешанного набора
+func TestFibonacci(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{13, 4, -8, 0, 21, 7, 1, 100} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/fibonacci", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response NumbersResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &response)
+
+	expected := []int64{0, 1, 13, 21}
+	if !reflect.DeepEqual(response.Numbers, expected) {
+		t.Errorf("Expected numbers %v, got %v", expected, response.Numbers)
+	}
+}
+
+// TestSymmetricDiff тестирует симметрическую разность пересекающихся множеств
+func TestSymmetricDiff(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{2, 3, 3, 4, 10} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		expected []int64
+	}{
+		{name: "Overlapping sets", body: `{"values": [1, 2, 3, 3, 7]}`, expected: []int64{1, 4, 7, 10}},
+		{name: "Empty provided list", body: `{"values": []}`, expected: []int64{2, 3, 4, 10}},
+		{name: "Identical sets", body: `{"values": [2, 3, 4, 10]}`, expected: []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/numbers/symmetric-diff", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			app.routes().ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+			}
+
+			var response NumbersResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			assertNoNullArrays(t, &response)
+
+			if !reflect.DeepEqual(response.Numbers, tt.expected) {
+				t.Errorf("Expected numbers %v, got %v", tt.expected, response.Numbers)
+			}
+		})
+	}
+}
+
+// TestSymmetricDiffInvalidJSON тестирует отклонение невалидного тела запроса
+func TestSymmetricDiffInvalidJSON(t *testing.T) {
+	app := &App{}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers/symmetric-diff", strings.NewReader("not json")))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestSinceClampedWithinClockSkew тестирует замену слегка опережающего since текущим временем
+func TestSinceClampedWithinClockSkew(t *testing.T) {
+	var since time.Time
+	fc := &fakeConnector{
+		query: func(query string, args []driver.NamedValue) (*fakeRows, error) {
+			// Первый аргумент - since, за ним следует limit страницы
+			if len(args) != 2 || !strings.Contains(query, "created_at >=") {
+				return nil, fmt.Errorf("unexpected query %q with %d args", query, len(args))
+			}
+			since = args[0].Value.(time.Time)
+			return &fakeRows{columns: []string{"value"}}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc), Config: Config{MaxClockSkew: time.Minute}}
+
+	before := time.Now()
+	target := "/numbers?since=" + url.QueryEscape(before.Add(10*time.Second).Format(time.RFC3339))
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if since.Before(before) || since.After(time.Now()) {
+		t.Errorf("Expected since to be clamped to now, got %s", since)
+	}
+}
+
+// TestSinceBeyondClockSkewRejected тестирует отказ, если since опережает время больше допустимого
+func TestSinceBeyondClockSkewRejected(t *testing.T) {
+	app := &App{Config: Config{MaxClockSkew: time.Minute}}
+
+	for _, target := range []string{
+		"/numbers?since=" + url.QueryEscape(time.Now().Add(time.Hour).Format(time.RFC3339)),
+		"/numbers/query?since=" + url.QueryEscape(time.Now().Add(time.Hour).Format(time.RFC3339)),
+		"/numbers?since=yesterday",
+		"/numbers?since=2024-02-01T00:00:00Z&until=2024-01-01T00:00:00Z",
+	} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", target, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestWithinSigma тестирует исключение выбросов и случай нулевого отклонения
+func TestWithinSigma(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{10, 11, 12, 10, 11, 12, 10, 11, 12, 100, -70} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/within-sigma?n=1.5", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response NumbersResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &response)
+
+	expected := []int64{10, 10, 10, 11, 11, 11, 12, 12, 12}
+	if !reflect.DeepEqual(response.Numbers, expected) {
+		t.Errorf("Expected outliers to be excluded: %v, got %v", expected, response.Numbers)
+	}
+
+	// Все значения равны: отклонение нулевое, возвращаются все значения
+	app.DB.Exec("DELETE FROM numbers")
+	for i := 0; i < 3; i++ {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES (7)")
+	}
+	w = httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/within-sigma?n=1", nil))
+	response = NumbersResponse{}
+	json.NewDecoder(w.Body).Decode(&response)
+	if !reflect.DeepEqual(response.Numbers, []int64{7, 7, 7}) {
+		t.Errorf("Expected all equal values to be returned, got %v", response.Numbers)
+	}
+}
+
+// TestWithinSigmaInvalidN тестирует проверку параметра n
+func TestWithinSigmaInvalidN(t *testing.T) {
+	app := &App{}
+
+	for _, n := range []string{"", "0", "-1", "abc", "NaN", "Inf"} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/within-sigma?n="+n, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("n=%q: expected status %d, got %d", n, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestBitmask тестирует выборку по маске в режимах all и any
+func TestBitmask(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{8, 1, 4, 5, 7, 2, 0} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	tests := []struct {
+		query    string
+		expected []int64
+	}{
+		{"mask=5&match=all", []int64{5, 7}},
+		{"mask=5", []int64{5, 7}},
+		{"mask=5&match=any", []int64{1, 4, 5, 7}},
+		{"mask=10&match=any", []int64{2, 7, 8}},
+		{"mask=0&match=any", []int64{}},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/bitmask?"+tt.query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d", tt.query, http.StatusOK, w.Code)
+		}
+
+		var response NumbersResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		assertNoNullArrays(t, &response)
+		if !reflect.DeepEqual(response.Numbers, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.query, tt.expected, response.Numbers)
+		}
+	}
+}
+
+// TestBitmaskValidation тестирует проверку mask и match, а также условие SQL для каждого режима
+func TestBitmaskValidation(t *testing.T) {
+	app := &App{}
+	for _, query := range []string{"", "mask=-1", "mask=abc", "mask=1.5", "mask=5&match=none"} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/bitmask?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+
+	for match, cond := range map[string]string{"all": "= $1::bigint", "any": "<> 0"} {
+		var query string
+		fc := &fakeConnector{query: func(q string, args []driver.NamedValue) (*fakeRows, error) {
+			query = q
+			if len(args) != 1 || args[0].Value != int64(5) {
+				t.Errorf("Unexpected args %v", args)
+			}
+			return &fakeRows{columns: []string{"value"}}, nil
+		}}
+		app := &App{DB: newFakeDB(t, fc)}
+
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/bitmask?mask=5&match="+match, nil))
+		if w.Code != http.StatusOK || !strings.Contains(query, cond) {
+			t.Errorf("match=%s: expected condition %q, got status %d query %q", match, cond, w.Code, query)
+		}
+	}
+}
+
+// TestHeadNumbersFiltered тестирует, что HEAD /numbers применяет фильтры GET и возвращает то же
+// значение X-Total-Count
+func TestHeadNumbersFiltered(t *testing.T) {
+	fc := &fakeConnector{
+		query: func(query string, args []driver.NamedValue) (*fakeRows, error) {
+			if strings.HasPrefix(query, "SELECT COUNT(*)") {
+				total := int64(10)
+				if strings.Contains(query, "value >= $1") && args[0].Value == int64(5) {
+					total = 3
+				}
+				return &fakeRows{columns: []string{"count"}, values: [][]driver.Value{{total}}}, nil
+			}
+			return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{int64(5)}, {int64(6)}}}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	get := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers?min=5&limit=2", nil))
+	head := httptest.NewRecorder()
+	app.routes().ServeHTTP(head, httptest.NewRequest(http.MethodHead, "/numbers?min=5&limit=2", nil))
+
+	if get.Code != http.StatusOK || head.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got GET %d and HEAD %d", http.StatusOK, get.Code, head.Code)
+	}
+	if got, want := head.Header().Get("X-Total-Count"), get.Header().Get("X-Total-Count"); got != want || got != "3" {
+		t.Errorf("Expected X-Total-Count 3 for both methods, got HEAD %q and GET %q", got, want)
+	}
+	if head.Body.Len() != 0 {
+		t.Errorf("Expected empty HEAD body, got %q", head.Body.String())
+	}
+
+	head = httptest.NewRecorder()
+	app.routes().ServeHTTP(head, httptest.NewRequest(http.MethodHead, "/numbers?min=abc", nil))
+	if head.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid filter, got %d", http.StatusBadRequest, head.Code)
+	}
+}
+
+// TestGetNumbersPagination тестирует limit, offset и общее количество в X-Total-Count
+func TestGetNumbersPagination(t *testing.T) {
+	var counts atomic.Int64
+	fc := &fakeConnector{
+		query: func(query string, args []driver.NamedValue) (*fakeRows, error) {
+			if strings.HasPrefix(query, "SELECT COUNT(*)") {
+				counts.Add(1)
+				return &fakeRows{columns: []string{"count"}, values: [][]driver.Value{{int64(7)}}}, nil
+			}
+			if !strings.HasSuffix(query, "LIMIT $1 OFFSET $2") || args[0].Value != int64(2) || args[1].Value != int64(4) {
+				return nil, fmt.Errorf("unexpected query %q with args %v", query, args)
+			}
+			return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{int64(5)}, {int64(6)}}}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers?limit=2&offset=4", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response NumbersResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !reflect.DeepEqual(response.Numbers, []int64{5, 6}) {
+		t.Errorf("Expected page [5 6], got %v", response.Numbers)
+	}
+	if got := w.Header().Get("X-Total-Count"); got != "7" {
+		t.Errorf("Expected X-Total-Count 7, got %q", got)
+	}
+	if counts.Load() != 1 {
+		t.Errorf("Expected a COUNT query for a full page, got %d", counts.Load())
+	}
+}
+
+// TestGetNumbersDefaultLimit тестирует размер страницы по умолчанию и подсчет без COUNT для неполной страницы
+func TestGetNumbersDefaultLimit(t *testing.T) {
+	var limit atomic.Value
+	fc := &fakeConnector{
+		query: func(query string, args []driver.NamedValue) (*fakeRows, error) {
+			if strings.HasPrefix(query, "SELECT COUNT(*)") {
+				return nil, fmt.Errorf("unexpected COUNT query for a partial page")
+			}
+			limit.Store(args[len(args)-1].Value)
+			return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{int64(1)}, {int64(2)}}}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc), Config: Config{DefaultPageLimit: 50}}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if got := limit.Load(); got != int64(50) {
+		t.Errorf("Expected default limit 50, got %v", got)
+	}
+	if got := w.Header().Get("X-Total-Count"); got != "2" {
+		t.Errorf("Expected X-Total-Count 2, got %q", got)
+	}
+}
+
+// TestGetNumbersPageValidation тестирует отклонение некорректных limit и offset
+func TestGetNumbersPageValidation(t *testing.T) {
+	app := &App{}
+
+	for _, target := range []string{
+		"/numbers?limit=0",
+		"/numbers?limit=-1",
+		"/numbers?limit=10001",
+		"/numbers?limit=ten",
+		"/numbers?offset=-1",
+		"/numbers?offset=1.5",
+	} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", target, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestKeysetSQL тестирует условие по курсору и порядок (value, id) в обоих направлениях
+func TestKeysetSQL(t *testing.T) {
+	min := int64(10)
+	f := numberFilter{Min: &min, Limit: 3, Keyset: true, After: &pageCursor{Value: 15, ID: 7}}
+
+	query, args := f.keysetSQL()
+	expected := "SELECT value, id FROM live_numbers WHERE value >= $1 AND (value, id) > ($2, $3) ORDER BY value ASC, id ASC LIMIT $4"
+	if query != expected {
+		t.Errorf("Expected query %q, got %q", expected, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{int64(10), int64(15), int64(7), 3}) {
+		t.Errorf("Expected args [10 15 7 3], got %v", args)
+	}
+
+	f = numberFilter{Limit: 3, Keyset: true, Desc: true}
+	query, _ = f.keysetSQL()
+	expected = "SELECT value, id FROM live_numbers ORDER BY value DESC, id DESC LIMIT $1"
+	if query != expected {
+		t.Errorf("Expected query %q, got %q", expected, query)
+	}
+}
+
+// TestPageCursorRoundTrip тестирует кодирование курсора и отклонение поврежденного
+func TestPageCursorRoundTrip(t *testing.T) {
+	for _, c := range []pageCursor{{0, 1}, {-42, 9000000000}, {2147483647, 3}, {math.MinInt64, math.MaxInt64}} {
+		got, err := decodePageCursor(c.encode())
+		if err != nil || got != c {
+			t.Errorf("Round trip of %+v gave %+v, %v", c, got, err)
+		}
+	}
+
+	for _, s := range []string{"%%%", "MTI", "YTpi"} {
+		if _, err := decodePageCursor(s); err == nil {
+			t.Errorf("Expected an error for cursor %q", s)
+		}
+	}
+}
+
+// TestGetNumbersKeysetPages тестирует обход страниц по курсору из X-Next-Cursor
+func TestGetNumbersKeysetPages(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+	for _, num := range []int{5, 1, 3, 3, 2} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	var all []int64
+	target := "/numbers?limit=2&after="
+	for pages := 0; target != ""; pages++ {
+		if pages > 5 {
+			t.Fatal("Pagination did not terminate")
+		}
+		w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var response NumbersResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		all = append(all, response.Numbers...)
+
+		target = ""
+		if next := w.Header().Get("X-Next-Cursor"); next != "" {
+			target = "/numbers?limit=2&after=" + url.QueryEscape(next)
+		}
+	}
+
+	if !reflect.DeepEqual(all, []int64{1, 2, 3, 3, 5}) {
+		t.Errorf("Expected all numbers [1 2 3 3 5] across pages, got %v", all)
+	}
+}
+
+// TestGetNumbersKeysetValidation тестирует отклонение поврежденного курсора и after вместе с offset
+func TestGetNumbersKeysetValidation(t *testing.T) {
+	app := &App{}
+
+	for _, target := range []string{
+		"/numbers?after=not-a-cursor",
+		"/numbers?after=&offset=10",
+	} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", target, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestGetNumbersNextCursor тестирует курсор следующей страницы без обращения к реальной базе
+func TestGetNumbersNextCursor(t *testing.T) {
+	fc := &fakeConnector{
+		query: func(query string, args []driver.NamedValue) (*fakeRows, error) {
+			return &fakeRows{columns: []string{"value", "id"}, values: [][]driver.Value{{int64(1), int64(8)}, {int64(4), int64(2)}}}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers?limit=2&after=", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if got, want := w.Header().Get("X-Next-Cursor"), (pageCursor{Value: 4, ID: 2}).encode(); got != want {
+		t.Errorf("Expected X-Next-Cursor %q, got %q", want, got)
+	}
+	if got := w.Header().Get("X-Total-Count"); got != "" {
+		t.Errorf("Expected no X-Total-Count in keyset mode, got %q", got)
+	}
+
+	// Неполная страница - последняя, курсора нет
+	w = serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers?limit=3&after=", nil))
+	if got := w.Header().Get("X-Next-Cursor"); got != "" {
+		t.Errorf("Expected no cursor after the last page, got %q", got)
+	}
+}
+
+// TestOrderDesc тестирует order=desc в GET /numbers и в списке, возвращаемом POST /numbers
+func TestOrderDesc(t *testing.T) {
+	var inserts atomic.Int64
+	fc := &fakeConnector{
+		exec: func(query string, args []driver.NamedValue) (driver.Result, error) {
+			inserts.Add(1)
+			return driver.RowsAffected(1), nil
+		},
+		query: func(query string, args []driver.NamedValue) (*fakeRows, error) {
+			// База сортирует по запросу: GET просит DESC, POST читает все числа по возрастанию
+			values := [][]driver.Value{{int64(1)}, {int64(2)}, {int64(3)}}
+			if strings.Contains(query, "ORDER BY value DESC") {
+				values = [][]driver.Value{{int64(3)}, {int64(2)}, {int64(1)}}
+			}
+			return &fakeRows{columns: []string{"value"}, values: values}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/numbers?order=desc", nil),
+		httptest.NewRequest(http.MethodPost, "/numbers?number=2&order=desc", nil),
+	} {
+		w := serveChecked(t, app.routes(), req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d: %s", req.Method, http.StatusOK, w.Code, w.Body.String())
+		}
+		var response NumbersResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if !reflect.DeepEqual(response.Numbers, []int64{3, 2, 1}) {
+			t.Errorf("%s: expected [3 2 1], got %v", req.Method, response.Numbers)
+		}
+	}
+
+	// Некорректный порядок отклоняется до вставки
+	before := inserts.Load()
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/numbers?order=down", nil),
+		httptest.NewRequest(http.MethodPost, "/numbers?number=2&order=down", nil),
+	} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", req.Method, http.StatusBadRequest, w.Code)
+		}
+	}
+	if inserts.Load() != before {
+		t.Error("Expected no insert with an invalid order")
+	}
+}
+
+// TestGetNumbersValueRange тестирует фильтр min и max в GET /numbers
+func TestGetNumbersValueRange(t *testing.T) {
+	var query atomic.Value
+	fc := &fakeConnector{
+		query: func(q string, args []driver.NamedValue) (*fakeRows, error) {
+			query.Store(q)
+			if len(args) < 2 || args[0].Value != int64(-5) || args[1].Value != int64(10) {
+				return nil, fmt.Errorf("unexpected args %v", args)
+			}
+			return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{int64(-5)}, {int64(7)}}}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers?min=-5&max=10", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if q, _ := query.Load().(string); !strings.Contains(q, "WHERE value >= $1 AND value <= $2") {
+		t.Errorf("Expected range conditions in the query, got %q", q)
+	}
+
+	for _, target := range []string{"/numbers?min=abc", "/numbers?max=1.5", "/numbers?min=10&max=1"} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", target, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestCreatedAfterBeforeAliases тестирует created_after и created_before как синонимы since и until
+func TestCreatedAfterBeforeAliases(t *testing.T) {
+	app := &App{}
+
+	var f numberFilter
+	err := app.parseTimeRange(url.Values{
+		"created_after":  {"2024-01-01T00:00:00Z"},
+		"created_before": {"2024-02-01T00:00:00Z"},
+	}, &f)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if f.Since == nil || !f.Since.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected since 2024-01-01, got %v", f.Since)
+	}
+	if f.Until == nil || !f.Until.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected until 2024-02-01, got %v", f.Until)
+	}
+
+	for _, target := range []string{
+		"/numbers?created_after=yesterday",
+		"/numbers?created_after=2024-01-01T00:00:00Z&since=2024-01-01T00:00:00Z",
+		"/numbers?created_after=2024-02-01T00:00:00Z&created_before=2024-01-01T00:00:00Z",
+		"/numbers/query?created_before=tomorrow",
+	} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", target, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestGetNumbersDistinct тестирует distinct=true: SELECT DISTINCT и количество различных значений
+func TestGetNumbersDistinct(t *testing.T) {
+	var query atomic.Value
+	fc := &fakeConnector{
+		query: func(q string, args []driver.NamedValue) (*fakeRows, error) {
+			if strings.HasPrefix(q, "SELECT COUNT(DISTINCT value)") {
+				return &fakeRows{columns: []string{"count"}, values: [][]driver.Value{{int64(5)}}}, nil
+			}
+			query.Store(q)
+			return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{int64(1)}, {int64(3)}}}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers?distinct=true&limit=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if q, _ := query.Load().(string); !strings.HasPrefix(q, "SELECT DISTINCT value FROM live_numbers") {
+		t.Errorf("Expected a SELECT DISTINCT query, got %q", q)
+	}
+	if got := w.Header().Get("X-Total-Count"); got != "5" {
+		t.Errorf("Expected X-Total-Count 5, got %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers?distinct=true&after=", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for distinct with after, got %d", http.StatusBadRequest, w.Code)
+	}
+}
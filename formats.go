@@ -0,0 +1,95 @@
+package main
+
+import "strconv"
+
+// listFormats содержит альтернативные представления списка чисел для параметра ?format=.
+// Каждая функция получает значения, отсортированные по возрастанию
+var listFormats = map[string]func(sorted []int64) interface{}{
+	"rle":   func(sorted []int64) interface{} { return runLengthEncode(sorted) },
+	"delta": func(sorted []int64) interface{} { return deltaEncode(sorted) },
+	"zset":  func(sorted []int64) interface{} { return sortedSetMembers(sorted) },
+}
+
+// Run представляет непрерывный диапазон последовательных целых чисел [Start, End]
+type Run struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// runLengthEncode сворачивает отсортированные значения в диапазоны последовательных чисел.
+// Повторяющиеся значения учитываются один раз: [1, 2, 2, 3, 5] превращается в [{1, 3}, {5, 5}]
+func runLengthEncode(sorted []int64) []Run {
+	runs := []Run{}
+	for _, v := range sorted {
+		if n := len(runs); n > 0 {
+			last := &runs[n-1]
+			if v == last.End {
+				continue
+			}
+			if v == last.End+1 {
+				last.End = v
+				continue
+			}
+		}
+		runs = append(runs, Run{Start: v, End: v})
+	}
+	return runs
+}
+
+// DeltaEncoded представляет отсортированные значения как первое значение и разности соседних.
+// Для пустого списка Base равен null. Разности неотрицательны, но между крайними значениями
+// bigint не помещаются в int64, поэтому хранятся как uint64
+type DeltaEncoded struct {
+	Base   *int64   `json:"base"`
+	Deltas []uint64 `json:"deltas"`
+}
+
+// deltaEncode кодирует отсортированные значения разностями: [1, 2, 3, 6] превращается в
+// {base: 1, deltas: [1, 1, 3]}. Повторы сохраняются как нулевые разности
+func deltaEncode(sorted []int64) DeltaEncoded {
+	result := DeltaEncoded{Deltas: []uint64{}}
+	if len(sorted) == 0 {
+		return result
+	}
+
+	base := sorted[0]
+	result.Base = &base
+	for i := 1; i < len(sorted); i++ {
+		result.Deltas = append(result.Deltas, uint64(sorted[i]-sorted[i-1]))
+	}
+	return result
+}
+
+// deltaDecode восстанавливает значения из представления deltaEncode (для клиентов). Сложение
+// с переполнением в дополнительном коде дает верный результат и для разностей больше int64
+func deltaDecode(d DeltaEncoded) []int64 {
+	if d.Base == nil {
+		return []int64{}
+	}
+
+	values := make([]int64, 0, len(d.Deltas)+1)
+	values = append(values, *d.Base)
+	for _, delta := range d.Deltas {
+		values = append(values, values[len(values)-1]+int64(delta))
+	}
+	return values
+}
+
+// ZMember представляет элемент в стиле sorted set: оценка и строковое имя элемента
+type ZMember struct {
+	Score  int64  `json:"score"`
+	Member string `json:"member"`
+}
+
+// sortedSetMembers представляет отсортированные значения как элементы sorted set с оценкой,
+// равной значению. Как и в sorted set, элементы уникальны: повторы учитываются один раз
+func sortedSetMembers(sorted []int64) []ZMember {
+	members := []ZMember{}
+	for i, v := range sorted {
+		if i > 0 && v == sorted[i-1] {
+			continue
+		}
+		members = append(members, ZMember{Score: v, Member: strconv.FormatInt(v, 10)})
+	}
+	return members
+}
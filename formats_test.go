@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// TestRunLengthEncode тестирует свертку серий последовательных чисел и одиночных значений
+func TestRunLengthEncode(t *testing.T) {
+	got := runLengthEncode([]int64{-2, -1, 1, 2, 2, 3, 4, 5, 8, 10, 11})
+	expected := []Run{{-2, -1}, {1, 5}, {8, 8}, {10, 11}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected runs %v, got %v", expected, got)
+	}
+
+	if got := runLengthEncode(nil); got == nil || len(got) != 0 {
+		t.Errorf("Expected empty non-nil runs, got %#v", got)
+	}
+}
+
+// TestGetNumbersRLEFormat тестирует ответ GET /numbers?format=rle
+func TestGetNumbersRLEFormat(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{3, 1, 2, 8, 5, 4, 4} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/numbers?format=rle", nil)
+	w := httptest.NewRecorder()
+
+	app.handleNumbers(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var runs []Run
+	if err := json.NewDecoder(w.Body).Decode(&runs); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &runs)
+
+	expected := []Run{{Start: 1, End: 5}, {Start: 8, End: 8}}
+	if !reflect.DeepEqual(runs, expected) {
+		t.Errorf("Expected runs %v, got %v", expected, runs)
+	}
+}
+
+// TestGetNumbersUnknownFormat тестирует отклонение неизвестного формата
+func TestGetNumbersUnknownFormat(t *testing.T) {
+	app := &App{}
+
+	req := httptest.NewRequest(http.MethodGet, "/numbers?format=bogus", nil)
+	w := httptest.NewRecorder()
+
+	app.handleNumbers(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestGetNumbersFormatDesc тестирует отклонение format вместе с order=desc: представления
+// строятся по значениям в порядке возрастания
+func TestGetNumbersFormatDesc(t *testing.T) {
+	app := &App{}
+
+	for _, format := range []string{"rle", "delta", "zset"} {
+		w := httptest.NewRecorder()
+		app.handleNumbers(w, httptest.NewRequest(http.MethodGet, "/numbers?order=desc&format="+format, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("format=%s: expected status %d, got %d", format, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestDeltaEncodeRoundTrip тестирует кодирование разностями и обратное декодирование
+func TestDeltaEncodeRoundTrip(t *testing.T) {
+	encoded := deltaEncode([]int64{1, 2, 3, 6})
+	if encoded.Base == nil || *encoded.Base != 1 || !reflect.DeepEqual(encoded.Deltas, []uint64{1, 1, 3}) {
+		t.Errorf("Expected base 1 and deltas [1 1 3], got %+v", encoded)
+	}
+
+	for _, sorted := range [][]int64{{}, {7}, {-5, -5, 0, 3, 3, 100}, {1, 2, 3, 6}} {
+		data, err := json.Marshal(deltaEncode(sorted))
+		if err != nil {
+			t.Fatalf("Failed to encode %v: %v", sorted, err)
+		}
+		var decoded DeltaEncoded
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Failed to decode %s: %v", data, err)
+		}
+		assertNoNullArrays(t, &decoded)
+		if got := deltaDecode(decoded); !reflect.DeepEqual(got, sorted) {
+			t.Errorf("Round trip of %v returned %v", sorted, got)
+		}
+	}
+}
+
+// TestSortedSetMembers тестирует структуру score/member, порядок и уникальность элементов
+func TestSortedSetMembers(t *testing.T) {
+	got := sortedSetMembers([]int64{-3, 1, 1, 2, 10})
+	expected := []ZMember{{-3, "-3"}, {1, "1"}, {2, "2"}, {10, "10"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	data, err := json.Marshal(sortedSetMembers([]int64{1, 2}))
+	if err != nil {
+		t.Fatalf("Failed to encode members: %v", err)
+	}
+	if string(data) != `[{"score":1,"member":"1"},{"score":2,"member":"2"}]` {
+		t.Errorf("Unexpected JSON %s", data)
+	}
+
+	if got := sortedSetMembers(nil); got == nil || len(got) != 0 {
+		t.Errorf("Expected empty non-nil members, got %#v", got)
+	}
+}
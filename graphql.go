@@ -0,0 +1,385 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// graphqlRequest - тело POST /graphql по соглашению GraphQL over HTTP
+type graphqlRequest struct {
+	Query         string                     `json:"query"`
+	OperationName string                     `json:"operationName"`
+	Variables     map[string]json.RawMessage `json:"variables"`
+}
+
+// graphqlResponse - ответ /graphql. Data отсутствует, если запрос отклонен до выполнения
+type graphqlResponse struct {
+	Data   gqlObject      `json:"data,omitempty"`
+	Errors []graphqlError `json:"errors,omitempty"`
+}
+
+// graphqlError - элемент errors ответа; Path указывает на поле, при вычислении которого
+// произошла ошибка, а extensions.code - ее класс
+type graphqlError struct {
+	Message    string                  `json:"message"`
+	Path       []string                `json:"path,omitempty"`
+	Extensions *graphqlErrorExtensions `json:"extensions,omitempty"`
+}
+
+type graphqlErrorExtensions struct {
+	Code string `json:"code"`
+}
+
+// gqlError - ошибка вычисления поля с кодом для extensions.code. Текст отправляется клиенту,
+// поэтому внутренние ошибки записываются в лог и заменяются общим сообщением
+type gqlError struct {
+	code    string
+	message string
+}
+
+func (e gqlError) Error() string {
+	return e.message
+}
+
+// handleGraphQL выполняет запрос GraphQL к схеме из README: запросы numbers и stats и мутацию
+// addNumber. Синтаксические ошибки и запросы к несуществующим полям отклоняются с 400 без
+// выполнения; ошибки отдельных полей возвращаются в errors с кодом 200, а поле получает null
+func (app *App) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphqlRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONStatus(w, http.StatusBadRequest, graphqlResponse{Errors: []graphqlError{{Message: "Invalid JSON body"}}})
+		return
+	}
+
+	op, err := parseGraphQL(req.Query, req.OperationName)
+	if err != nil {
+		writeJSONStatus(w, http.StatusBadRequest, graphqlResponse{Errors: []graphqlError{{Message: err.Error()}}})
+		return
+	}
+	if errs := validateGraphQL(op); len(errs) > 0 {
+		writeJSONStatus(w, http.StatusBadRequest, graphqlResponse{Errors: errs})
+		return
+	}
+
+	ex := &gqlExecutor{app: app, op: op, variables: req.Variables}
+	data, errs := ex.execute()
+	writeJSON(w, graphqlResponse{Data: data, Errors: errs})
+}
+
+// gqlObject - объект ответа с полями в порядке запроса; map сериализуется с
+// отсортированными ключами, а GraphQL требует порядок выборки
+type gqlObject []gqlEntry
+
+type gqlEntry struct {
+	key   string
+	value interface{}
+}
+
+// has проверяет, есть ли в объекте поле с ключом key
+func (o gqlObject) has(key string) bool {
+	return slices.ContainsFunc(o, func(e gqlEntry) bool { return e.key == key })
+}
+
+func (o gqlObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, e := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(e.key)
+		value, err := json.Marshal(e.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// gqlType описывает объектный тип схемы /graphql
+type gqlType struct {
+	name   string
+	fields map[string]gqlField
+}
+
+// gqlField описывает поле: тип значения, типы аргументов (! - обязательный аргумент) и
+// объектный тип для полей с выборкой подполей; object равен nil для скаляров и их списков
+type gqlField struct {
+	typ    string
+	args   map[string]string
+	object *gqlType
+}
+
+var (
+	gqlStatsType = &gqlType{name: "Stats", fields: map[string]gqlField{
+		"count":  {typ: "Int64!"},
+		"min":    {typ: "Int64"},
+		"max":    {typ: "Int64"},
+		"sum":    {typ: "BigInt!"},
+		"mean":   {typ: "Float"},
+		"median": {typ: "Float"},
+	}}
+
+	gqlQueryType = &gqlType{name: "Query", fields: map[string]gqlField{
+		"numbers": {typ: "[Int64!]", args: map[string]string{"order": "Order"}},
+		"stats":   {typ: "Stats", object: gqlStatsType},
+	}}
+
+	gqlMutationType = &gqlType{name: "Mutation", fields: map[string]gqlField{
+		"addNumber": {typ: "[Int64!]", args: map[string]string{"number": "Int64!", "order": "Order"}},
+	}}
+)
+
+// validateGraphQL проверяет операцию по схеме до выполнения: поля и аргументы должны
+// существовать, обязательные аргументы - быть заданы, переменные - объявлены, а подполя
+// выбираются только у объектных типов
+func validateGraphQL(op *gqlOperation) []graphqlError {
+	root := gqlQueryType
+	if op.kind == "mutation" {
+		root = gqlMutationType
+	}
+
+	var errs []graphqlError
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, graphqlError{
+			Message:    fmt.Sprintf(format, args...),
+			Extensions: &graphqlErrorExtensions{Code: "GRAPHQL_VALIDATION_FAILED"},
+		})
+	}
+
+	var walk func(selections []gqlSelection, t *gqlType)
+	walk = func(selections []gqlSelection, t *gqlType) {
+		for _, sel := range selections {
+			if sel.name == "__typename" {
+				if len(sel.args) > 0 || sel.selections != nil {
+					fail("Field %q takes no arguments and has no subfields", sel.name)
+				}
+				continue
+			}
+			f, ok := t.fields[sel.name]
+			if !ok {
+				fail("Cannot query field %q on type %q", sel.name, t.name)
+				continue
+			}
+
+			for _, arg := range sel.args {
+				if _, ok := f.args[arg.name]; !ok {
+					fail("Unknown argument %q on field \"%s.%s\"", arg.name, t.name, sel.name)
+				}
+				if arg.value.kind == gqlVariable && op.variable(arg.value.text) == nil {
+					fail("Variable \"$%s\" is not defined", arg.value.text)
+				}
+			}
+			for name, typ := range f.args {
+				if _, ok := sel.arg(name); !ok && strings.HasSuffix(typ, "!") {
+					fail("Field %q argument %q of type %q is required", sel.name, name, typ)
+				}
+			}
+
+			switch {
+			case f.object != nil && sel.selections == nil:
+				fail("Field %q of type %q must have a selection of subfields", sel.name, f.typ)
+			case f.object == nil && sel.selections != nil:
+				fail("Field %q must not have a selection since type %q has no subfields", sel.name, f.typ)
+			case f.object != nil:
+				walk(sel.selections, f.object)
+			}
+		}
+	}
+	walk(op.selections, root)
+	return errs
+}
+
+// gqlExecutor вычисляет поля проверенной операции с переменными запроса
+type gqlExecutor struct {
+	app       *App
+	op        *gqlOperation
+	variables map[string]json.RawMessage
+}
+
+// execute вычисляет корневые поля по порядку; поле с ошибкой получает null, а ошибка
+// попадает в errors с путем к полю
+func (ex *gqlExecutor) execute() (gqlObject, []graphqlError) {
+	data := make(gqlObject, 0, len(ex.op.selections))
+	var errs []graphqlError
+	for _, sel := range ex.op.selections {
+		key := sel.responseKey()
+		if data.has(key) {
+			continue
+		}
+		value, err := ex.resolve(sel)
+		if err != nil {
+			var gerr gqlError
+			if !errors.As(err, &gerr) {
+				gerr = gqlError{code: "INTERNAL_SERVER_ERROR", message: err.Error()}
+			}
+			errs = append(errs, graphqlError{
+				Message:    gerr.message,
+				Path:       []string{key},
+				Extensions: &graphqlErrorExtensions{Code: gerr.code},
+			})
+			value = nil
+		}
+		data = append(data, gqlEntry{key: key, value: value})
+	}
+	return data, errs
+}
+
+// resolve вычисляет корневое поле через ту же логику, что и HTTP обработчики
+func (ex *gqlExecutor) resolve(sel gqlSelection) (interface{}, error) {
+	switch sel.name {
+	case "__typename":
+		if ex.op.kind == "mutation" {
+			return gqlMutationType.name, nil
+		}
+		return gqlQueryType.name, nil
+
+	case "numbers":
+		desc, err := ex.orderArg(sel)
+		if err != nil {
+			return nil, err
+		}
+		return ex.numbers(desc)
+
+	case "stats":
+		stats, err := ex.app.computeStats("live_numbers")
+		if err != nil {
+			log.Printf("Error computing stats: %v", err)
+			return nil, gqlError{code: "INTERNAL_SERVER_ERROR", message: "failed to compute stats"}
+		}
+		return gqlProject(sel.selections, gqlStatsType.name, map[string]interface{}{
+			"count":  stats.Count,
+			"min":    stats.Min,
+			"max":    stats.Max,
+			"sum":    stats.Sum,
+			"mean":   stats.Mean,
+			"median": stats.Median,
+		}), nil
+
+	case "addNumber":
+		n, ok, err := ex.int64Arg(sel, "number")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, gqlError{code: "BAD_USER_INPUT", message: `argument "number" of type "Int64!" must not be null`}
+		}
+		desc, err := ex.orderArg(sel)
+		if err != nil {
+			return nil, err
+		}
+		if err := ex.app.storeNumber(n); err != nil {
+			return nil, gqlStoreError(err)
+		}
+		return ex.numbers(desc)
+	}
+	return nil, fmt.Errorf("field %q has no resolver", sel.name)
+}
+
+// numbers возвращает отсортированный список всех чисел
+func (ex *gqlExecutor) numbers(desc bool) ([]int64, error) {
+	numbers, err := ex.app.getAllNumbers()
+	if err != nil {
+		log.Printf("Error getting numbers: %v", err)
+		return nil, gqlError{code: "INTERNAL_SERVER_ERROR", message: "failed to retrieve numbers"}
+	}
+	if desc {
+		slices.Reverse(numbers)
+	}
+	return numbers, nil
+}
+
+// gqlProject выбирает из полей объекта запрошенные подполя в порядке запроса с учетом псевдонимов
+func gqlProject(selections []gqlSelection, typename string, fields map[string]interface{}) gqlObject {
+	obj := make(gqlObject, 0, len(selections))
+	for _, sel := range selections {
+		key := sel.responseKey()
+		if obj.has(key) {
+			continue
+		}
+		value := fields[sel.name]
+		if sel.name == "__typename" {
+			value = typename
+		}
+		obj = append(obj, gqlEntry{key: key, value: value})
+	}
+	return obj
+}
+
+// argument возвращает значение аргумента в JSON: литерал запроса, значение переменной или
+// значение переменной по умолчанию. Незаданный аргумент дает nil
+func (ex *gqlExecutor) argument(sel gqlSelection, name string) json.RawMessage {
+	v, ok := sel.arg(name)
+	if !ok {
+		return nil
+	}
+	if v.kind != gqlVariable {
+		return v.json()
+	}
+	if raw, ok := ex.variables[v.text]; ok {
+		return raw
+	}
+	if def := ex.op.variable(v.text); def != nil && def.defaultValue != nil {
+		return def.defaultValue.json()
+	}
+	return nil
+}
+
+// int64Arg разбирает аргумент типа Int64: число или строку с десятичной записью, как поле
+// number в JSON теле POST /numbers. Возвращает false для незаданного аргумента и null
+func (ex *gqlExecutor) int64Arg(sel gqlSelection, name string) (int64, bool, error) {
+	raw := ex.argument(sel, name)
+	if raw == nil || string(raw) == "null" {
+		return 0, false, nil
+	}
+	n, err := parseJSONNumber(raw)
+	if err != nil {
+		return 0, false, gqlError{code: "BAD_USER_INPUT", message: fmt.Sprintf("argument %q: %v", name, err)}
+	}
+	return n, true, nil
+}
+
+// orderArg разбирает аргумент order типа Order (ASC по умолчанию или DESC)
+func (ex *gqlExecutor) orderArg(sel gqlSelection) (bool, error) {
+	raw := ex.argument(sel, "order")
+	if raw == nil || string(raw) == "null" {
+		return false, nil
+	}
+	var order string
+	json.Unmarshal(raw, &order)
+	switch order {
+	case "ASC":
+		return false, nil
+	case "DESC":
+		return true, nil
+	}
+	return false, gqlError{code: "BAD_USER_INPUT", message: `argument "order" must be ASC or DESC`}
+}
+
+// gqlStoreError преобразует ошибку сохранения числа в ошибку поля
+func gqlStoreError(err error) error {
+	var verr validationError
+	var merr monotonicError
+	switch {
+	case errors.As(err, &verr):
+		return gqlError{code: "BAD_USER_INPUT", message: err.Error()}
+	case errors.As(err, &merr):
+		return gqlError{code: "CONFLICT", message: err.Error()}
+	case isReadOnlyError(err):
+		log.Printf("Error inserting number: database is read-only: %v", maskError(err))
+		return gqlError{code: "UNAVAILABLE", message: "database is read-only, try again later"}
+	default:
+		log.Printf("Error inserting number: %v", maskError(err))
+		return gqlError{code: "INTERNAL_SERVER_ERROR", message: "failed to save number"}
+	}
+}
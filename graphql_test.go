@@ -0,0 +1,169 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// graphqlFakeDB возвращает базу, где числа - [-2 5], а статистика - для этих чисел
+func graphqlFakeDB(t *testing.T, inserted *atomic.Value) *App {
+	fc := &fakeConnector{
+		exec: func(q string, args []driver.NamedValue) (driver.Result, error) {
+			if strings.HasPrefix(q, "INSERT INTO numbers") {
+				inserted.Store(args[0].Value)
+			}
+			return driver.RowsAffected(1), nil
+		},
+		query: func(q string, args []driver.NamedValue) (*fakeRows, error) {
+			if strings.Contains(q, "PERCENTILE_CONT") {
+				return &fakeRows{
+					columns: []string{"count", "min", "max", "sum", "avg", "median"},
+					values:  [][]driver.Value{{int64(2), int64(-2), int64(5), "3", 1.5, 1.5}},
+				}, nil
+			}
+			return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{int64(-2)}, {int64(5)}}}, nil
+		},
+	}
+	return &App{DB: newFakeDB(t, fc)}
+}
+
+// postGraphQL отправляет запрос на /graphql и возвращает ответ
+func postGraphQL(t *testing.T, app *App, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("Failed to marshal request: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(data)))
+	req.Header.Set("Content-Type", "application/json")
+	return serveChecked(t, app.routes(), req)
+}
+
+// TestGraphQLQuery тестирует запрос нескольких полей с псевдонимами в одном обращении
+func TestGraphQLQuery(t *testing.T) {
+	app := graphqlFakeDB(t, &atomic.Value{})
+
+	w := postGraphQL(t, app, graphqlRequest{Query: `
+		# Числа по убыванию и часть статистики
+		query Dashboard {
+			desc: numbers(order: DESC)
+			stats { __typename sum, count mean }
+		}`})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	expected := `{"data":{"desc":[5,-2],"stats":{"__typename":"Stats","sum":3,"count":2,"mean":1.5}}}`
+	if strings.TrimSpace(w.Body.String()) != expected {
+		t.Errorf("Expected %s, got %s", expected, w.Body.String())
+	}
+}
+
+// TestGraphQLAddNumber тестирует мутацию с переменными и ошибку поля при отказе валидатора
+func TestGraphQLAddNumber(t *testing.T) {
+	var inserted atomic.Value
+	app := graphqlFakeDB(t, &inserted)
+
+	w := postGraphQL(t, app, graphqlRequest{
+		Query:     `mutation Add($n: Int64!, $order: Order = ASC) { addNumber(number: $n, order: $order) }`,
+		Variables: map[string]json.RawMessage{"n": json.RawMessage(`"9223372036854775807"`)},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if expected := `{"data":{"addNumber":[-2,5]}}`; strings.TrimSpace(w.Body.String()) != expected {
+		t.Errorf("Expected %s, got %s", expected, w.Body.String())
+	}
+	if got := inserted.Load(); got != int64(9223372036854775807) {
+		t.Errorf("Expected the maximum int64 to be inserted, got %v", got)
+	}
+
+	app.Validators = []Validator{NonNegativeValidator{}}
+	w = postGraphQL(t, app, graphqlRequest{Query: `mutation { addNumber(number: -1) }`})
+	var resp struct {
+		Data   map[string]interface{} `json:"data"`
+		Errors []graphqlError         `json:"errors"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Expected a GraphQL response, got %d (%v)", w.Code, err)
+	}
+	if _, ok := resp.Data["addNumber"]; !ok || resp.Data["addNumber"] != nil {
+		t.Errorf("Expected addNumber to be null, got %v", resp.Data)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Extensions == nil || resp.Errors[0].Extensions.Code != "BAD_USER_INPUT" ||
+		!reflect.DeepEqual(resp.Errors[0].Path, []string{"addNumber"}) {
+		t.Errorf("Expected a BAD_USER_INPUT error at addNumber, got %+v", resp.Errors)
+	}
+}
+
+// TestGraphQLInvalid тестирует отклонение неверных запросов до выполнения
+func TestGraphQLInvalid(t *testing.T) {
+	app := &App{DB: newFakeDB(t, &fakeConnector{})}
+
+	tests := []struct {
+		name, query, operation, message string
+	}{
+		{"syntax", `{ numbers(order: ) }`, "", `Syntax Error: unexpected ")" at line 1, column 18`},
+		{"unterminated", "{\n  numbers }\n{", "", "Syntax Error: unexpected end of input at line 3, column 2"},
+		{"unknown field", `{ values }`, "", `Cannot query field "values" on type "Query"`},
+		{"mutation on query", `{ addNumber(number: 1) }`, "", `Cannot query field "addNumber" on type "Query"`},
+		{"missing argument", `mutation { addNumber }`, "", `Field "addNumber" argument "number" of type "Int64!" is required`},
+		{"unknown argument", `{ numbers(limit: 5) }`, "", `Unknown argument "limit" on field "Query.numbers"`},
+		{"undefined variable", `mutation { addNumber(number: $n) }`, "", `Variable "$n" is not defined`},
+		{"subfields required", `{ stats }`, "", `Field "stats" of type "Stats" must have a selection of subfields`},
+		{"no subfields", `{ numbers { value } }`, "", `Field "numbers" must not have a selection since type "[Int64!]" has no subfields`},
+		{"fragments", `{ ...F } fragment F on Query { numbers }`, "", "fragments are not supported"},
+		{"several operations", `query A { numbers } query B { numbers }`, "", "operationName is required when the document contains several operations"},
+		{"unknown operation", `query A { numbers }`, "B", `Unknown operation "B"`},
+		{"empty", `  # nothing`, "", "Syntax Error: the document contains no operations"},
+	}
+	for _, tt := range tests {
+		w := postGraphQL(t, app, graphqlRequest{Query: tt.query, OperationName: tt.operation})
+		var resp graphqlResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if w.Code != http.StatusBadRequest || strings.Contains(w.Body.String(), `"data"`) ||
+			len(resp.Errors) != 1 || resp.Errors[0].Message != tt.message {
+			t.Errorf("%s: expected status %d with %q, got %d %s", tt.name, http.StatusBadRequest, tt.message, w.Code, w.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/graphql", nil)
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d for GET, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+// TestGraphQLArguments тестирует разбор аргументов: строку с числом, неверный тип и порядок
+func TestGraphQLArguments(t *testing.T) {
+	var inserted atomic.Value
+	app := graphqlFakeDB(t, &inserted)
+
+	for _, tt := range []struct {
+		query, message string
+	}{
+		{`mutation { addNumber(number: "42") }`, ""},
+		{`mutation { addNumber(number: 1.5) }`, `argument "number": ` + errInvalidNumber.Error()},
+		{`mutation { addNumber(number: null) }`, `argument "number" of type "Int64!" must not be null`},
+		{`{ numbers(order: UP) }`, `argument "order" must be ASC or DESC`},
+	} {
+		w := postGraphQL(t, app, graphqlRequest{Query: tt.query})
+		var resp graphqlResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		switch {
+		case tt.message == "" && len(resp.Errors) != 0:
+			t.Errorf("%s: unexpected errors %+v", tt.query, resp.Errors)
+		case tt.message != "" && (len(resp.Errors) != 1 || resp.Errors[0].Message != tt.message):
+			t.Errorf("%s: expected error %q, got %s", tt.query, tt.message, w.Body.String())
+		}
+	}
+	if got := inserted.Load(); got != int64(42) {
+		t.Errorf("Expected 42 to be inserted, got %v", got)
+	}
+}
@@ -0,0 +1,521 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// gqlKind - вид лексемы или значения запроса GraphQL
+type gqlKind int
+
+const (
+	gqlEOF gqlKind = iota
+	gqlPunct
+	gqlName
+	gqlInt
+	gqlFloat
+	gqlString
+	gqlVariable
+	gqlBool
+	gqlNull
+	gqlEnum
+)
+
+type gqlToken struct {
+	kind  gqlKind
+	value string
+	pos   int
+}
+
+// gqlValue - значение аргумента: литерал или ссылка на переменную ($name в text)
+type gqlValue struct {
+	kind gqlKind
+	text string
+}
+
+// json возвращает литерал в JSON, чтобы литералы и переменные разбирались одинаково.
+// Значения перечислений передаются строками, как в переменных
+func (v gqlValue) json() json.RawMessage {
+	switch v.kind {
+	case gqlString, gqlEnum:
+		raw, _ := json.Marshal(v.text)
+		return raw
+	case gqlNull:
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(v.text)
+}
+
+type gqlArgument struct {
+	name  string
+	value gqlValue
+}
+
+// gqlSelection - поле выборки; selections равен nil, если у поля нет набора подполей
+type gqlSelection struct {
+	alias      string
+	name       string
+	args       []gqlArgument
+	selections []gqlSelection
+}
+
+// responseKey возвращает ключ поля в ответе: псевдоним или имя поля
+func (s gqlSelection) responseKey() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.name
+}
+
+// arg возвращает аргумент поля по имени
+func (s gqlSelection) arg(name string) (gqlValue, bool) {
+	for _, a := range s.args {
+		if a.name == name {
+			return a.value, true
+		}
+	}
+	return gqlValue{}, false
+}
+
+type gqlVariableDef struct {
+	name         string
+	typ          string
+	defaultValue *gqlValue
+}
+
+// gqlOperation - операция query или mutation документа GraphQL
+type gqlOperation struct {
+	kind       string
+	name       string
+	variables  []gqlVariableDef
+	selections []gqlSelection
+}
+
+// variable возвращает объявление переменной операции или nil
+func (op *gqlOperation) variable(name string) *gqlVariableDef {
+	for i := range op.variables {
+		if op.variables[i].name == name {
+			return &op.variables[i]
+		}
+	}
+	return nil
+}
+
+// parseGraphQL разбирает документ GraphQL и выбирает операцию operationName; без имени
+// документ должен содержать одну операцию. Поддерживается подмножество языка, достаточное для
+// схемы /graphql: операции с переменными, псевдонимы и аргументы-скаляры. Фрагменты, директивы,
+// подписки, списки и объекты во входных значениях отклоняются
+func parseGraphQL(src, operationName string) (*gqlOperation, error) {
+	p := &gqlParser{src: src}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	var ops []*gqlOperation
+	for p.tok.kind != gqlEOF {
+		op, err := p.parseOperation()
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	if len(ops) == 0 {
+		return nil, errors.New("Syntax Error: the document contains no operations")
+	}
+
+	if operationName == "" {
+		if len(ops) > 1 {
+			return nil, errors.New("operationName is required when the document contains several operations")
+		}
+		return ops[0], nil
+	}
+	for _, op := range ops {
+		if op.name == operationName {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("Unknown operation %q", operationName)
+}
+
+// gqlParser - разбор документа рекурсивным спуском; tok - текущая лексема
+type gqlParser struct {
+	src string
+	pos int
+	tok gqlToken
+}
+
+func (p *gqlParser) parseOperation() (*gqlOperation, error) {
+	op := &gqlOperation{kind: "query"}
+	if !p.peek("{") {
+		if p.tok.kind != gqlName {
+			return nil, p.unexpected()
+		}
+		switch p.tok.value {
+		case "query", "mutation":
+			op.kind = p.tok.value
+		case "subscription":
+			return nil, errors.New("subscriptions are not supported")
+		case "fragment":
+			return nil, errors.New("fragments are not supported")
+		default:
+			return nil, p.unexpected()
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		if p.tok.kind == gqlName {
+			op.name = p.tok.value
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+		if p.peek("(") {
+			if err := p.parseVariableDefs(op); err != nil {
+				return nil, err
+			}
+		}
+		if p.peek("@") {
+			return nil, errors.New("directives are not supported")
+		}
+	}
+
+	var err error
+	op.selections, err = p.parseSelectionSet()
+	return op, err
+}
+
+func (p *gqlParser) parseVariableDefs(op *gqlOperation) error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for !p.peek(")") {
+		if err := p.expect("$"); err != nil {
+			return err
+		}
+		name, err := p.expectName()
+		if err != nil {
+			return err
+		}
+		if op.variable(name) != nil {
+			return fmt.Errorf("Variable \"$%s\" is declared twice", name)
+		}
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		def := gqlVariableDef{name: name}
+		if def.typ, err = p.parseType(); err != nil {
+			return err
+		}
+		if p.peek("=") {
+			if err := p.advance(); err != nil {
+				return err
+			}
+			v, err := p.parseValue(true)
+			if err != nil {
+				return err
+			}
+			def.defaultValue = &v
+		}
+		op.variables = append(op.variables, def)
+	}
+	return p.advance()
+}
+
+// parseType разбирает ссылку на тип (Int64, Int64!, [Int64!]) в ее текстовую запись
+func (p *gqlParser) parseType() (string, error) {
+	var typ string
+	if p.peek("[") {
+		if err := p.advance(); err != nil {
+			return "", err
+		}
+		inner, err := p.parseType()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect("]"); err != nil {
+			return "", err
+		}
+		typ = "[" + inner + "]"
+	} else {
+		name, err := p.expectName()
+		if err != nil {
+			return "", err
+		}
+		typ = name
+	}
+	if p.peek("!") {
+		typ += "!"
+		return typ, p.advance()
+	}
+	return typ, nil
+}
+
+func (p *gqlParser) parseSelectionSet() ([]gqlSelection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	if p.peek("}") {
+		return nil, p.unexpected()
+	}
+	var selections []gqlSelection
+	for !p.peek("}") {
+		if p.peek("...") {
+			return nil, errors.New("fragments are not supported")
+		}
+		sel, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+	return selections, p.advance()
+}
+
+func (p *gqlParser) parseField() (gqlSelection, error) {
+	var sel gqlSelection
+	name, err := p.expectName()
+	if err != nil {
+		return sel, err
+	}
+	if p.peek(":") {
+		if err := p.advance(); err != nil {
+			return sel, err
+		}
+		sel.alias = name
+		if name, err = p.expectName(); err != nil {
+			return sel, err
+		}
+	}
+	sel.name = name
+
+	if p.peek("(") {
+		if err := p.advance(); err != nil {
+			return sel, err
+		}
+		for !p.peek(")") {
+			arg, err := p.expectName()
+			if err != nil {
+				return sel, err
+			}
+			if _, dup := sel.arg(arg); dup {
+				return sel, fmt.Errorf("Argument %q is given twice", arg)
+			}
+			if err := p.expect(":"); err != nil {
+				return sel, err
+			}
+			v, err := p.parseValue(false)
+			if err != nil {
+				return sel, err
+			}
+			sel.args = append(sel.args, gqlArgument{name: arg, value: v})
+		}
+		if err := p.advance(); err != nil {
+			return sel, err
+		}
+	}
+	if p.peek("@") {
+		return sel, errors.New("directives are not supported")
+	}
+	if p.peek("{") {
+		sel.selections, err = p.parseSelectionSet()
+	}
+	return sel, err
+}
+
+// parseValue разбирает значение аргумента; в значениях по умолчанию (constant) переменные
+// не допускаются
+func (p *gqlParser) parseValue(constant bool) (gqlValue, error) {
+	tok := p.tok
+	var v gqlValue
+	switch {
+	case tok.kind == gqlPunct && tok.value == "$" && !constant:
+		if err := p.advance(); err != nil {
+			return v, err
+		}
+		name, err := p.expectName()
+		return gqlValue{kind: gqlVariable, text: name}, err
+	case tok.kind == gqlInt || tok.kind == gqlFloat || tok.kind == gqlString:
+		v = gqlValue{kind: tok.kind, text: tok.value}
+	case tok.kind == gqlName:
+		v = gqlValue{kind: gqlEnum, text: tok.value}
+		switch tok.value {
+		case "true", "false":
+			v.kind = gqlBool
+		case "null":
+			v.kind = gqlNull
+		}
+	case tok.kind == gqlPunct && (tok.value == "[" || tok.value == "{"):
+		return v, errors.New("list and object values are not supported")
+	default:
+		return v, p.unexpected()
+	}
+	return v, p.advance()
+}
+
+// peek проверяет, что текущая лексема - знак punct
+func (p *gqlParser) peek(punct string) bool {
+	return p.tok.kind == gqlPunct && p.tok.value == punct
+}
+
+// expect пропускает знак punct или возвращает синтаксическую ошибку
+func (p *gqlParser) expect(punct string) error {
+	if !p.peek(punct) {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+// expectName возвращает имя из текущей лексемы и переходит к следующей
+func (p *gqlParser) expectName() (string, error) {
+	if p.tok.kind != gqlName {
+		return "", p.unexpected()
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+// unexpected возвращает ошибку о текущей лексеме с ее строкой и столбцом
+func (p *gqlParser) unexpected() error {
+	what := "end of input"
+	if p.tok.kind != gqlEOF {
+		what = fmt.Sprintf("%q", p.tok.value)
+	}
+	line, column := p.location(p.tok.pos)
+	return fmt.Errorf("Syntax Error: unexpected %s at line %d, column %d", what, line, column)
+}
+
+// location переводит смещение в тексте в номер строки и столбца, начиная с 1
+func (p *gqlParser) location(pos int) (int, int) {
+	before := p.src[:pos]
+	line := strings.Count(before, "\n") + 1
+	return line, pos - strings.LastIndexByte(before, '\n')
+}
+
+// advance читает следующую лексему, пропуская пробелы, запятые и комментарии #
+func (p *gqlParser) advance() error {
+	p.skipIgnored()
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.tok = gqlToken{kind: gqlEOF, pos: start}
+		return nil
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		p.tok = gqlToken{kind: gqlPunct, value: "...", pos: start}
+	case strings.IndexByte("!$()[]{}:=@|&", c) >= 0:
+		p.pos++
+		p.tok = gqlToken{kind: gqlPunct, value: string(c), pos: start}
+	case isGQLNameStart(c):
+		for p.pos < len(p.src) && (isGQLNameStart(p.src[p.pos]) || isGQLDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		p.tok = gqlToken{kind: gqlName, value: p.src[start:p.pos], pos: start}
+	case c == '-' || isGQLDigit(c):
+		return p.scanNumber()
+	case c == '"':
+		return p.scanString()
+	default:
+		line, column := p.location(start)
+		return fmt.Errorf("Syntax Error: unexpected character %q at line %d, column %d", c, line, column)
+	}
+	return nil
+}
+
+func (p *gqlParser) skipIgnored() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' && p.src[p.pos] != '\r' {
+				p.pos++
+			}
+		case strings.HasPrefix(p.src[p.pos:], "\ufeff"):
+			p.pos += len("\ufeff")
+		default:
+			return
+		}
+	}
+}
+
+// scanNumber читает целое или дробное число: -?цифры, затем необязательные .цифры и экспонента
+func (p *gqlParser) scanNumber() error {
+	start, i := p.pos, p.pos
+	digits := func() bool {
+		from := i
+		for i < len(p.src) && isGQLDigit(p.src[i]) {
+			i++
+		}
+		return i > from
+	}
+
+	kind := gqlInt
+	if p.src[i] == '-' {
+		i++
+	}
+	ok := digits()
+	if ok && i < len(p.src) && p.src[i] == '.' {
+		i++
+		kind, ok = gqlFloat, digits()
+	}
+	if ok && i < len(p.src) && (p.src[i] == 'e' || p.src[i] == 'E') {
+		i++
+		if i < len(p.src) && (p.src[i] == '+' || p.src[i] == '-') {
+			i++
+		}
+		kind, ok = gqlFloat, digits()
+	}
+	if !ok || i < len(p.src) && (p.src[i] == '.' || isGQLNameStart(p.src[i])) {
+		line, column := p.location(start)
+		return fmt.Errorf("Syntax Error: invalid number at line %d, column %d", line, column)
+	}
+
+	p.pos = i
+	p.tok = gqlToken{kind: kind, value: p.src[start:i], pos: start}
+	return nil
+}
+
+// scanString читает строку в кавычках. Экранирование в GraphQL совпадает с JSON, поэтому
+// строка декодируется encoding/json; блочные строки """ не поддерживаются
+func (p *gqlParser) scanString() error {
+	start := p.pos
+	line, column := p.location(start)
+	if strings.HasPrefix(p.src[start:], `"""`) {
+		return errors.New("block strings are not supported")
+	}
+
+	i := start + 1
+	for ; i < len(p.src) && p.src[i] != '"'; i++ {
+		switch p.src[i] {
+		case '\\':
+			i++
+		case '\n', '\r':
+			i = len(p.src)
+		}
+	}
+	if i >= len(p.src) {
+		return fmt.Errorf("Syntax Error: unterminated string at line %d, column %d", line, column)
+	}
+
+	var s string
+	if err := json.Unmarshal([]byte(p.src[start:i+1]), &s); err != nil {
+		return fmt.Errorf("Syntax Error: invalid string at line %d, column %d", line, column)
+	}
+	p.pos = i + 1
+	p.tok = gqlToken{kind: gqlString, value: s, pos: start}
+	return nil
+}
+
+func isGQLNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isGQLDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"numbers-service/numberspb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcServer реализует gRPC сервис Numbers поверх той же логики, что и HTTP обработчики
+type grpcServer struct {
+	numberspb.UnimplementedNumbersServer
+	app *App
+}
+
+// newGRPCServer создает gRPC сервер с зарегистрированным сервисом Numbers
+func newGRPCServer(app *App) *grpc.Server {
+	s := grpc.NewServer(grpc.UnaryInterceptor(app.grpcAPIKey))
+	numberspb.RegisterNumbersServer(s, &grpcServer{app: app})
+	return s
+}
+
+// grpcAPIKey проверяет ключ из метаданных x-api-key так же, как requireAPIKey для HTTP,
+// и увеличивает счетчик запросов ключа. Если API_KEYS_ENABLED не задан, вызовы пропускаются
+func (app *App) grpcAPIKey(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !app.Config.APIKeysEnabled {
+		return handler(ctx, req)
+	}
+
+	var key string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-api-key"); len(values) > 0 {
+			key = values[0]
+		}
+	}
+	err := app.useAPIKey(key)
+	switch {
+	case errors.Is(err, errAPIKeyRequired), errors.Is(err, errInvalidAPIKey):
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	case err != nil:
+		log.Printf("Error checking API key: %v", err)
+		return nil, status.Error(codes.Internal, "failed to check API key")
+	}
+	return handler(ctx, req)
+}
+
+// AddNumber сохраняет число и возвращает отсортированный список всех чисел
+func (s *grpcServer) AddNumber(ctx context.Context, req *numberspb.NumberRequest) (*numberspb.NumbersResponse, error) {
+	if err := s.app.storeNumber(req.GetNumber()); err != nil {
+		return nil, grpcStoreError(err)
+	}
+	return s.listNumbers()
+}
+
+// ListNumbers возвращает отсортированный список всех чисел
+func (s *grpcServer) ListNumbers(ctx context.Context, req *numberspb.ListNumbersRequest) (*numberspb.NumbersResponse, error) {
+	return s.listNumbers()
+}
+
+// Stats возвращает статистику значений, как GET /numbers/stats; сумма передается строкой
+func (s *grpcServer) Stats(ctx context.Context, req *numberspb.StatsRequest) (*numberspb.StatsResponse, error) {
+	stats, err := s.app.computeStats("live_numbers")
+	if err != nil {
+		log.Printf("Error computing stats: %v", err)
+		return nil, status.Error(codes.Internal, "failed to compute stats")
+	}
+
+	return &numberspb.StatsResponse{
+		Count:  int64(stats.Count),
+		Min:    stats.Min,
+		Max:    stats.Max,
+		Sum:    stats.Sum.String(),
+		Mean:   stats.Mean,
+		Median: stats.Median,
+	}, nil
+}
+
+// listNumbers получает числа из базы данных и преобразует их в protobuf ответ
+func (s *grpcServer) listNumbers() (*numberspb.NumbersResponse, error) {
+	numbers, err := s.app.getAllNumbers()
+	if err != nil {
+		log.Printf("Error getting numbers: %v", err)
+		return nil, status.Error(codes.Internal, "failed to retrieve numbers")
+	}
+
+	return &numberspb.NumbersResponse{Numbers: numbers}, nil
+}
+
+// grpcStoreError преобразует ошибку сохранения числа в gRPC статус
+func grpcStoreError(err error) error {
+	var verr validationError
+	var merr monotonicError
+	switch {
+	case errors.As(err, &verr):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.As(err, &merr):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case isReadOnlyError(err):
+		log.Printf("Error inserting number: database is read-only: %v", maskError(err))
+		return status.Error(codes.Unavailable, "database is read-only, try again later")
+	default:
+		log.Printf("Error inserting number: %v", maskError(err))
+		return status.Error(codes.Internal, "failed to save number")
+	}
+}
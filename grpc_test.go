@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"math"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"numbers-service/numberspb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// startTestGRPC запускает gRPC сервер в памяти процесса и возвращает подключенного клиента
+func startTestGRPC(t *testing.T, app *App) numberspb.NumbersClient {
+	lis := bufconn.Listen(1 << 20)
+	srv := newGRPCServer(app)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial gRPC server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return numberspb.NewNumbersClient(conn)
+}
+
+// TestGRPCAddAndListNumbers тестирует gRPC методы AddNumber и ListNumbers
+func TestGRPCAddAndListNumbers(t *testing.T) {
+	var inserted []int64
+	fc := &fakeConnector{
+		exec: func(_ string, args []driver.NamedValue) (driver.Result, error) {
+			inserted = append(inserted, args[0].Value.(int64))
+			return driver.RowsAffected(1), nil
+		},
+		query: func(string, []driver.NamedValue) (*fakeRows, error) {
+			return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{int64(1)}, {int64(2)}, {int64(3)}}}, nil
+		},
+	}
+	client := startTestGRPC(t, &App{DB: newFakeDB(t, fc)})
+	ctx := context.Background()
+
+	resp, err := client.AddNumber(ctx, &numberspb.NumberRequest{Number: 2})
+	if err != nil {
+		t.Fatalf("AddNumber failed: %v", err)
+	}
+	if !reflect.DeepEqual(inserted, []int64{2}) {
+		t.Errorf("Expected 2 to be inserted, got %v", inserted)
+	}
+	if !reflect.DeepEqual(resp.GetNumbers(), []int64{1, 2, 3}) {
+		t.Errorf("Expected numbers [1 2 3], got %v", resp.GetNumbers())
+	}
+
+	resp, err = client.ListNumbers(ctx, &numberspb.ListNumbersRequest{})
+	if err != nil {
+		t.Fatalf("ListNumbers failed: %v", err)
+	}
+	if !reflect.DeepEqual(resp.GetNumbers(), []int64{1, 2, 3}) {
+		t.Errorf("Expected numbers [1 2 3], got %v", resp.GetNumbers())
+	}
+}
+
+// TestGRPCAddNumberInvalid тестирует, что ошибка валидации возвращается как InvalidArgument
+func TestGRPCAddNumberInvalid(t *testing.T) {
+	client := startTestGRPC(t, &App{Validators: []Validator{NonNegativeValidator{}}})
+
+	_, err := client.AddNumber(context.Background(), &numberspb.NumberRequest{Number: -5})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+}
+
+// TestGRPCStats тестирует gRPC метод Stats: сумму больше int64 и незаданные поля пустой таблицы
+func TestGRPCStats(t *testing.T) {
+	row := []driver.Value{int64(2), int64(math.MaxInt64), int64(math.MaxInt64), "18446744073709551614", float64(math.MaxInt64), float64(math.MaxInt64)}
+	fc := &fakeConnector{
+		query: func(string, []driver.NamedValue) (*fakeRows, error) {
+			return &fakeRows{columns: []string{"count", "min", "max", "sum", "avg", "median"}, values: [][]driver.Value{row}}, nil
+		},
+	}
+	client := startTestGRPC(t, &App{DB: newFakeDB(t, fc)})
+	ctx := context.Background()
+
+	resp, err := client.Stats(ctx, &numberspb.StatsRequest{})
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if resp.GetCount() != 2 || resp.GetSum() != "18446744073709551614" || resp.GetMin() != math.MaxInt64 || resp.GetMedian() != float64(math.MaxInt64) {
+		t.Errorf("Unexpected stats %v", resp)
+	}
+
+	row = []driver.Value{int64(0), nil, nil, int64(0), nil, nil}
+	resp, err = client.Stats(ctx, &numberspb.StatsRequest{})
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if resp.GetCount() != 0 || resp.GetSum() != "0" || resp.Min != nil || resp.Max != nil || resp.Mean != nil || resp.Median != nil {
+		t.Errorf("Expected only count and sum for an empty table, got %v", resp)
+	}
+}
+
+// TestGRPCAPIKey тестирует проверку ключа x-api-key и счетчик запросов на gRPC сервере
+func TestGRPCAPIKey(t *testing.T) {
+	uses := 0
+	fc := &fakeConnector{
+		query: func(query string, args []driver.NamedValue) (*fakeRows, error) {
+			if strings.Contains(query, "UPDATE api_keys") {
+				if args[0].Value != hashAPIKey("good-key") {
+					return &fakeRows{columns: []string{"name"}}, nil
+				}
+				uses++
+				return &fakeRows{columns: []string{"name"}, values: [][]driver.Value{{"client-a"}}}, nil
+			}
+			return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{int64(7)}}}, nil
+		},
+	}
+	client := startTestGRPC(t, &App{DB: newFakeDB(t, fc), Config: Config{APIKeysEnabled: true}})
+
+	for name, ctx := range map[string]context.Context{
+		"missing": context.Background(),
+		"invalid": metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "bad-key"),
+	} {
+		_, err := client.ListNumbers(ctx, &numberspb.ListNumbersRequest{})
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("%s key: expected Unauthenticated, got %v", name, err)
+		}
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "good-key")
+	resp, err := client.ListNumbers(ctx, &numberspb.ListNumbersRequest{})
+	if err != nil {
+		t.Fatalf("ListNumbers with a valid key failed: %v", err)
+	}
+	if !reflect.DeepEqual(resp.GetNumbers(), []int64{7}) {
+		t.Errorf("Expected numbers [7], got %v", resp.GetNumbers())
+	}
+	if uses != 1 {
+		t.Errorf("Expected the key usage to be counted once, got %d", uses)
+	}
+}
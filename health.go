@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+)
+
+// healthCheckTimeout ограничивает время проверки базы данных в /health
+const healthCheckTimeout = 2 * time.Second
+
+// HealthResponse представляет состояние сервиса и его зависимостей
+type HealthResponse struct {
+	Status      string            `json:"status"`
+	Database    string            `json:"database"`
+	RetryBudget *RetryBudgetState `json:"retry_budget,omitempty"`
+}
+
+// handleHealth проверяет доступность базы данных и возвращает состояние бюджета повторов.
+// Если база недоступна, возвращает 503
+func (app *App) handleHealth(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	result := HealthResponse{Status: "ok", Database: "ok"}
+	if app.RetryBudget != nil {
+		state := app.RetryBudget.state()
+		result.RetryBudget = &state
+	}
+
+	status := http.StatusOK
+	if err := app.DB.PingContext(ctx); err != nil {
+		log.Printf("Health check failed: %v", err)
+		result.Status = "unavailable"
+		result.Database = "unreachable"
+		status = http.StatusServiceUnavailable
+	}
+
+	writeJSONStatus(w, status, result)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestHealth тестирует ответ /health с состоянием бюджета повторов
+func TestHealth(t *testing.T) {
+	app := &App{DB: newFakeDB(t, &fakeConnector{}), RetryBudget: newRetryBudget(5, 0.1)}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result HealthResponse
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Status != "ok" || result.RetryBudget == nil || result.RetryBudget.Tokens != 5 {
+		t.Errorf("Unexpected health response %+v", result)
+	}
+}
+
+// TestHealthDatabaseDown тестирует 503, если база данных недоступна
+func TestHealthDatabaseDown(t *testing.T) {
+	fc := &fakeConnector{}
+	fc.down.Store(true)
+	app := &App{DB: newFakeDB(t, fc)}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
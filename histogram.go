@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Ограничения параметров изображения гистограммы
+const (
+	maxHistogramBuckets   = 200
+	minHistogramDimension = 50
+	maxHistogramDimension = 4000
+)
+
+var (
+	histogramBackground = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	histogramBar        = color.RGBA{R: 70, G: 110, B: 180, A: 255}
+)
+
+// histogramCounts делит отсортированные значения на buckets интервалов равной ширины от
+// минимума до максимума включительно и возвращает количество значений в каждом
+func histogramCounts(sorted []int64, buckets int) []int {
+	return weightedHistogramCounts(sorted, nil, buckets)
+}
+
+// weightedHistogramCounts работает как histogramCounts, но значение sorted[i] учитывается
+// weights[i] раз; nil означает вес 1 у каждого значения. Ширина считается в float64, так как
+// разность крайних значений bigint не помещается в int64
+func weightedHistogramCounts(sorted []int64, weights []int, buckets int) []int {
+	counts := make([]int, buckets)
+	if len(sorted) == 0 {
+		return counts
+	}
+
+	lo, hi := float64(sorted[0]), float64(sorted[len(sorted)-1])
+	width := (hi - lo + 1) / float64(buckets)
+	for j, v := range sorted {
+		i := int((float64(v) - lo) / width)
+		if i >= buckets {
+			i = buckets - 1
+		}
+		if weights == nil {
+			counts[i]++
+		} else {
+			counts[i] += weights[j]
+		}
+	}
+	return counts
+}
+
+// renderHistogram рисует столбчатую диаграмму: столбцы одинаковой ширины, высота
+// пропорциональна количеству значений относительно наибольшего
+func renderHistogram(counts []int, width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: histogramBackground}, image.Point{}, draw.Src)
+
+	peak := 0
+	for _, c := range counts {
+		if c > peak {
+			peak = c
+		}
+	}
+	if peak == 0 {
+		return img
+	}
+
+	for i, c := range counts {
+		x0 := i * width / len(counts)
+		x1 := (i + 1) * width / len(counts)
+		// Отступ в один пиксель между столбцами, если ширина это позволяет
+		if x1-x0 > 2 {
+			x1--
+		}
+		barHeight := c * height / peak
+		bar := image.Rect(x0, height-barHeight, x1, height)
+		draw.Draw(img, bar, &image.Uniform{C: histogramBar}, image.Point{}, draw.Src)
+	}
+	return img
+}
+
+// handleHistogramPNG возвращает гистограмму значений в формате PNG. Параметры buckets
+// (по умолчанию 20), width (800) и height (400) проверяются на допустимые пределы
+func (app *App) handleHistogramPNG(w http.ResponseWriter, r *http.Request) {
+	buckets, width, height := 20, 800, 400
+	for _, p := range []struct {
+		name     string
+		min, max int
+		dst      *int
+	}{
+		{"buckets", 1, maxHistogramBuckets, &buckets},
+		{"width", minHistogramDimension, maxHistogramDimension, &width},
+		{"height", minHistogramDimension, maxHistogramDimension, &height},
+	} {
+		v := r.URL.Query().Get(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < p.min || n > p.max {
+			http.Error(w, fmt.Sprintf("%s must be an integer between %d and %d", p.name, p.min, p.max), http.StatusBadRequest)
+			return
+		}
+		*p.dst = n
+	}
+	if buckets > width {
+		http.Error(w, "buckets must not exceed width", http.StatusBadRequest)
+		return
+	}
+
+	// При USE_MATVIEW повторы значений читаются из материализованного представления
+	var (
+		numbers []int64
+		weights []int
+		err     error
+	)
+	if app.Config.UseMatview {
+		numbers, weights, err = app.valueCounts()
+	} else {
+		numbers, err = app.getAllNumbers()
+	}
+	if err != nil {
+		log.Printf("Error getting numbers for histogram: %v", err)
+		http.Error(w, "Failed to render histogram", http.StatusInternalServerError)
+		return
+	}
+
+	// Изображение кодируется в буфер, чтобы ошибку кодирования можно было вернуть как 500
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, renderHistogram(weightedHistogramCounts(numbers, weights, buckets), width, height)); err != nil {
+		log.Printf("Error encoding histogram: %v", err)
+		http.Error(w, "Failed to render histogram", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(buf.Bytes())
+}
+
+// defaultHistogramBuckets - количество интервалов /numbers/histogram по умолчанию
+const defaultHistogramBuckets = 10
+
+// HistogramBucket представляет интервал гистограммы [from, to) и количество значений в нем.
+// null в from или to означает, что интервал не ограничен с этой стороны
+type HistogramBucket struct {
+	From  *float64 `json:"from"`
+	To    *float64 `json:"to"`
+	Count int      `json:"count"`
+}
+
+// handleHistogram возвращает гистограмму значений, подсчитанную в PostgreSQL через width_bucket.
+// По умолчанию диапазон от минимума до максимума делится на buckets интервалов равной ширины;
+// параметр bounds задает явные границы, например bounds=0,10,100. Пустые интервалы включаются
+func (app *App) handleHistogram(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("buckets") != "" && q.Get("bounds") != "" {
+		http.Error(w, "buckets and bounds cannot be combined", http.StatusBadRequest)
+		return
+	}
+
+	var (
+		result []HistogramBucket
+		err    error
+	)
+	if v := q.Get("bounds"); v != "" {
+		bounds, perr := parseHistogramBounds(v)
+		if perr != nil {
+			http.Error(w, perr.Error(), http.StatusBadRequest)
+			return
+		}
+		result, err = app.histogramByBounds(bounds)
+	} else {
+		buckets := defaultHistogramBuckets
+		if v := q.Get("buckets"); v != "" {
+			n, perr := strconv.Atoi(v)
+			if perr != nil || n < 1 || n > maxHistogramBuckets {
+				http.Error(w, fmt.Sprintf("buckets must be an integer between 1 and %d", maxHistogramBuckets), http.StatusBadRequest)
+				return
+			}
+			buckets = n
+		}
+		result, err = app.histogramByWidth(buckets)
+	}
+	if err != nil {
+		log.Printf("Error computing histogram: %v", err)
+		http.Error(w, "Failed to compute histogram", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+// parseHistogramBounds разбирает строго возрастающие целые границы через запятую
+func parseHistogramBounds(v string) ([]int64, error) {
+	parts := strings.Split(v, ",")
+	if len(parts) > maxHistogramBuckets {
+		return nil, fmt.Errorf("bounds must contain at most %d values", maxHistogramBuckets)
+	}
+
+	bounds := make([]int64, len(parts))
+	for i, p := range parts {
+		n, err := strconv.ParseInt(strings.TrimSpace(p), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bounds must be comma-separated integers")
+		}
+		if i > 0 && n <= bounds[i-1] {
+			return nil, fmt.Errorf("bounds must be strictly increasing")
+		}
+		bounds[i] = n
+	}
+	return bounds, nil
+}
+
+// histogramByWidth делит диапазон [MIN(value), MAX(value) + 1) на buckets интервалов равной
+// ширины, как /numbers/histogram.png. Для пустой таблицы возвращает пустой срез
+func (app *App) histogramByWidth(buckets int) ([]HistogramBucket, error) {
+	rows, err := app.DB.Query(`
+		WITH bounds AS (
+			SELECT MIN(value)::float8 AS lo, MAX(value)::float8 + 1 AS hi FROM live_numbers
+		)
+		SELECT bounds.lo, bounds.hi, width_bucket(n.value, bounds.lo, bounds.hi, $1) AS bucket, COUNT(*)
+		FROM live_numbers n CROSS JOIN bounds
+		GROUP BY bounds.lo, bounds.hi, bucket
+		ORDER BY bucket`, buckets)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		lo, hi float64
+		counts = make([]int, buckets)
+		seen   bool
+	)
+	for rows.Next() {
+		var bucket, count int
+		if err := rows.Scan(&lo, &hi, &bucket, &count); err != nil {
+			return nil, err
+		}
+		// width_bucket нумерует интервалы с 1; значения вне [lo, hi) невозможны
+		if bucket >= 1 && bucket <= buckets {
+			counts[bucket-1] += count
+		}
+		seen = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if !seen {
+		return []HistogramBucket{}, nil
+	}
+
+	width := (hi - lo) / float64(buckets)
+	result := make([]HistogramBucket, buckets)
+	for i := range result {
+		from, to := lo+float64(i)*width, lo+float64(i+1)*width
+		result[i] = HistogramBucket{From: &from, To: &to, Count: counts[i]}
+	}
+	return result, nil
+}
+
+// histogramByBounds считает значения в интервалах между явными границами. Значения меньше первой
+// границы попадают в интервал без from, не меньше последней - в интервал без to
+func (app *App) histogramByBounds(bounds []int64) ([]HistogramBucket, error) {
+	rows, err := app.DB.Query(`
+		SELECT width_bucket(value, $1::bigint[]) AS bucket, COUNT(*)
+		FROM live_numbers
+		GROUP BY bucket
+		ORDER BY bucket`, pq.Array(bounds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]int, len(bounds)+1)
+	for rows.Next() {
+		var bucket, count int
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return nil, err
+		}
+		if bucket >= 0 && bucket < len(counts) {
+			counts[bucket] = count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]HistogramBucket, len(counts))
+	for i := range result {
+		result[i].Count = counts[i]
+		if i > 0 {
+			from := float64(bounds[i-1])
+			result[i].From = &from
+		}
+		if i < len(bounds) {
+			to := float64(bounds[i])
+			result[i].To = &to
+		}
+	}
+	return result, nil
+}
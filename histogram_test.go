@@ -0,0 +1,174 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestHistogramCounts тестирует распределение значений по интервалам равной ширины
+func TestHistogramCounts(t *testing.T) {
+	// Диапазон 0..9 на 5 интервалов: [0,1], [2,3], [4,5], [6,7], [8,9]
+	got := histogramCounts([]int64{0, 1, 1, 2, 5, 9, 9}, 5)
+	if expected := []int{3, 1, 1, 0, 2}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected counts %v, got %v", expected, got)
+	}
+
+	if got := histogramCounts([]int64{7, 7}, 3); !reflect.DeepEqual(got, []int{2, 0, 0}) {
+		t.Errorf("Expected all equal values in the first bucket, got %v", got)
+	}
+	if got := histogramCounts(nil, 4); !reflect.DeepEqual(got, []int{0, 0, 0, 0}) {
+		t.Errorf("Expected empty counts, got %v", got)
+	}
+}
+
+// TestHistogramPNG тестирует, что ответ является PNG изображением запрошенного размера
+func TestHistogramPNG(t *testing.T) {
+	fc := &fakeConnector{
+		query: func(query string, args []driver.NamedValue) (*fakeRows, error) {
+			return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{int64(1)}, {int64(3)}, {int64(3)}, {int64(10)}}}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/histogram.png?buckets=5&width=320&height=120", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("Expected Content-Type image/png, got %q", ct)
+	}
+
+	img, err := png.Decode(w.Body)
+	if err != nil {
+		t.Fatalf("Failed to decode PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 320 || b.Dy() != 120 {
+		t.Errorf("Expected 320x120 image, got %dx%d", b.Dx(), b.Dy())
+	}
+	// Самый высокий столбец (значения 3, 3) занимает всю высоту второго интервала
+	if img.At(100, 0) != histogramBar {
+		t.Errorf("Expected the tallest bar to reach the top of the image")
+	}
+}
+
+// TestHistogramPNGInvalidParams тестирует проверку параметров изображения
+func TestHistogramPNGInvalidParams(t *testing.T) {
+	app := &App{}
+
+	for _, query := range []string{"buckets=0", "buckets=201", "width=10", "height=5000", "width=abc", "buckets=100&width=60"} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/histogram.png?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestHistogramByWidth тестирует интервалы равной ширины, включая пустые, в JSON гистограмме
+func TestHistogramByWidth(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+	for _, num := range []int{0, 1, 1, 2, 5, 9, 9} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers/histogram?buckets=5", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result []HistogramBucket
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	counts := make([]int, len(result))
+	for i, b := range result {
+		counts[i] = b.Count
+	}
+	// Те же интервалы, что и у PNG гистограммы
+	if expected := histogramCounts([]int64{0, 1, 1, 2, 5, 9, 9}, 5); !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Expected counts %v, got %v", expected, counts)
+	}
+	if *result[0].From != 0 || *result[4].To != 10 {
+		t.Errorf("Expected range [0, 10), got [%v, %v)", *result[0].From, *result[4].To)
+	}
+}
+
+// TestHistogramByWidthFake тестирует построение интервалов по строкам width_bucket
+func TestHistogramByWidthFake(t *testing.T) {
+	fc := &fakeConnector{
+		query: func(string, []driver.NamedValue) (*fakeRows, error) {
+			return &fakeRows{
+				columns: []string{"lo", "hi", "bucket", "count"},
+				values:  [][]driver.Value{{float64(0), float64(10), int64(1), int64(3)}, {float64(0), float64(10), int64(2), int64(1)}},
+			}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers/histogram?buckets=4", nil))
+	expected := `[{"from":0,"to":2.5,"count":3},{"from":2.5,"to":5,"count":1},{"from":5,"to":7.5,"count":0},{"from":7.5,"to":10,"count":0}]`
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != expected {
+		t.Errorf("Expected %s, got %d %s", expected, w.Code, w.Body.String())
+	}
+
+	// Пустая таблица - пустой список
+	app = &App{DB: newFakeDB(t, &fakeConnector{})}
+	w = serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers/histogram", nil))
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("Expected [] for an empty table, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+// TestHistogramByBounds тестирует явные границы с открытыми крайними интервалами
+func TestHistogramByBounds(t *testing.T) {
+	fc := &fakeConnector{
+		query: func(q string, args []driver.NamedValue) (*fakeRows, error) {
+			if args[0].Value != "{0,10,100}" {
+				t.Errorf("Unexpected bounds argument %v", args[0].Value)
+			}
+			return &fakeRows{
+				columns: []string{"bucket", "count"},
+				values:  [][]driver.Value{{int64(0), int64(2)}, {int64(1), int64(5)}, {int64(3), int64(1)}},
+			}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers/histogram?bounds=0,10,100", nil))
+	expected := `[{"from":null,"to":0,"count":2},{"from":0,"to":10,"count":5},{"from":10,"to":100,"count":0},{"from":100,"to":null,"count":1}]`
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != expected {
+		t.Errorf("Expected %s, got %d %s", expected, w.Code, w.Body.String())
+	}
+}
+
+// TestHistogramInvalidParams тестирует проверку buckets и bounds
+func TestHistogramInvalidParams(t *testing.T) {
+	app := &App{}
+
+	for _, target := range []string{
+		"/numbers/histogram?buckets=0",
+		"/numbers/histogram?buckets=201",
+		"/numbers/histogram?buckets=ten",
+		"/numbers/histogram?bounds=10,5",
+		"/numbers/histogram?bounds=1,1",
+		"/numbers/histogram?bounds=a,b",
+		"/numbers/histogram?bounds=1,2&buckets=3",
+	} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", target, http.StatusBadRequest, w.Code)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	// maxIdempotencyKeyLength ограничивает длину заголовка Idempotency-Key
+	maxIdempotencyKeyLength = 255
+
+	// defaultIdempotencyKeyTTL задает время хранения ключей идемпотентности по умолчанию
+	defaultIdempotencyKeyTTL = 24 * time.Hour
+
+	// defaultIdempotencyLease - время, после которого резервирование ключа без сохраненного
+	// ответа считается брошенным, по умолчанию
+	defaultIdempotencyLease = 30 * time.Second
+
+	// maxIdempotentBodyBytes ограничивает тело запроса с Idempotency-Key, которое читается в память целиком
+	maxIdempotentBodyBytes = 1 << 20
+)
+
+// idempotent оборачивает обработчик записи поддержкой заголовка Idempotency-Key. Первый запрос
+// с ключом резервирует его в таблице idempotency_keys, выполняется и сохраняет ответ; повторы
+// получают сохраненный ответ с заголовком Idempotent-Replayed, не выполняя запрос заново.
+// Повтор во время выполнения первого запроса получает 409, тот же ключ с другим запросом - 422.
+// Ответы 5xx не сохраняются, а ключ освобождается, чтобы клиент мог повторить запрос.
+// Резервирование без ответа старше IDEMPOTENCY_LEASE (например, после падения экземпляра)
+// перехватывается следующим запросом с этим ключом. Тело больше 1 MiB отклоняется с 413
+func (app *App) idempotent(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			h(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+			return
+		}
+
+		// Тело читается заранее, чтобы сравнить повтор с исходным запросом
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		hash := idempotencyHash(r, body)
+
+		reserved, err := app.reserveIdempotencyKey(key, hash, app.idempotencyLease())
+		if err != nil {
+			log.Printf("Error reserving idempotency key: %v", err)
+			http.Error(w, "Failed to save number", http.StatusInternalServerError)
+			return
+		}
+		if !reserved {
+			app.replayIdempotent(w, key, hash)
+			return
+		}
+
+		rec := &cacheRecorder{ResponseWriter: w, status: http.StatusOK}
+		h(rec, r)
+
+		// Условие status IS NULL не дает запросу, чье резервирование перехватили после
+		// IDEMPOTENCY_LEASE, заменить уже сохраненный ответ перехватившего
+		if rec.status >= http.StatusInternalServerError {
+			if _, err := app.DB.Exec("DELETE FROM idempotency_keys WHERE key = $1 AND status IS NULL", key); err != nil {
+				log.Printf("Error releasing idempotency key: %v", err)
+			}
+			return
+		}
+		_, err = app.DB.Exec("UPDATE idempotency_keys SET status = $2, content_type = $3, body = $4 WHERE key = $1 AND status IS NULL",
+			key, rec.status, w.Header().Get("Content-Type"), rec.body.Bytes())
+		if err != nil {
+			log.Printf("Error saving idempotent response: %v", err)
+		}
+	}
+}
+
+// idempotencyHash возвращает отпечаток запроса: метод, путь с параметрами, тип и тело
+func idempotencyHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n"+r.Header.Get("Content-Type")+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotencyLease возвращает время, после которого резервирование без ответа перехватывается
+func (app *App) idempotencyLease() time.Duration {
+	if app.Config.IdempotencyLease <= 0 {
+		return defaultIdempotencyLease
+	}
+	return app.Config.IdempotencyLease
+}
+
+// reserveIdempotencyKey резервирует ключ и сообщает, свободен ли он был. Резервирование без
+// сохраненного ответа старше lease перехватывается: время и отпечаток запроса обновляются
+func (app *App) reserveIdempotencyKey(key, hash string, lease time.Duration) (bool, error) {
+	res, err := app.DB.Exec(`INSERT INTO idempotency_keys (key, request_hash) VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET request_hash = EXCLUDED.request_hash, created_at = now()
+		WHERE idempotency_keys.status IS NULL AND idempotency_keys.created_at < now() - make_interval(secs => $3)`,
+		key, hash, lease.Seconds())
+	if err != nil {
+		return false, err
+	}
+	reserved, err := res.RowsAffected()
+	return reserved > 0, err
+}
+
+// replayIdempotent отправляет сохраненный ответ на запрос с уже использованным ключом
+func (app *App) replayIdempotent(w http.ResponseWriter, key, hash string) {
+	var (
+		storedHash  string
+		status      sql.NullInt64
+		contentType sql.NullString
+		body        []byte
+	)
+	err := app.DB.QueryRow("SELECT request_hash, status, content_type, body FROM idempotency_keys WHERE key = $1", key).
+		Scan(&storedHash, &status, &contentType, &body)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// Ключ освобожден после ошибки первого запроса между резервированием и чтением
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "A request with this Idempotency-Key is in progress", http.StatusConflict)
+		return
+	case err != nil:
+		log.Printf("Error reading idempotent response: %v", err)
+		http.Error(w, "Failed to save number", http.StatusInternalServerError)
+		return
+	}
+
+	if storedHash != hash {
+		http.Error(w, "Idempotency-Key was already used with a different request", http.StatusUnprocessableEntity)
+		return
+	}
+	if !status.Valid {
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "A request with this Idempotency-Key is in progress", http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType.String)
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(int(status.Int64))
+	w.Write(body)
+}
+
+// sweepIdempotencyKeys удаляет ключи идемпотентности старше ttl и возвращает их количество
+func (app *App) sweepIdempotencyKeys(ctx context.Context, ttl time.Duration) (int64, error) {
+	res, err := app.DB.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE created_at < now() - make_interval(secs => $1)", ttl.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
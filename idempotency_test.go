@@ -0,0 +1,227 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// idempotencyStore имитирует таблицу idempotency_keys поверх фиктивной базы и считает вставки чисел
+type idempotencyStore struct {
+	mu      sync.Mutex
+	rows    map[string][]driver.Value
+	inserts atomic.Int64
+
+	// reserved - время резервирования ключей; ключ без времени считается только что зарезервированным
+	reserved map[string]time.Time
+}
+
+func newIdempotencyStore() *idempotencyStore {
+	return &idempotencyStore{rows: make(map[string][]driver.Value), reserved: make(map[string]time.Time)}
+}
+
+func (s *idempotencyStore) connector() *fakeConnector {
+	return &fakeConnector{
+		exec: func(q string, args []driver.NamedValue) (driver.Result, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			switch {
+			case strings.HasPrefix(q, "INSERT INTO idempotency_keys"):
+				key := args[0].Value.(string)
+				if row, ok := s.rows[key]; ok {
+					lease := time.Duration(args[2].Value.(float64) * float64(time.Second))
+					at, ok := s.reserved[key]
+					if row[1] != nil || !ok || time.Since(at) <= lease {
+						return driver.RowsAffected(0), nil
+					}
+				}
+				s.rows[key] = []driver.Value{args[1].Value, nil, nil, nil}
+				s.reserved[key] = time.Now()
+			case strings.HasPrefix(q, "UPDATE idempotency_keys"):
+				key := args[0].Value.(string)
+				if row, ok := s.rows[key]; !ok || row[1] != nil {
+					return driver.RowsAffected(0), nil
+				}
+				s.rows[key] = []driver.Value{s.rows[key][0], args[1].Value, args[2].Value, args[3].Value}
+			case strings.HasPrefix(q, "DELETE FROM idempotency_keys"):
+				key := args[0].Value.(string)
+				if row, ok := s.rows[key]; !ok || row[1] != nil {
+					return driver.RowsAffected(0), nil
+				}
+				delete(s.rows, key)
+			case strings.HasPrefix(q, "INSERT INTO numbers"):
+				s.inserts.Add(1)
+			}
+			return driver.RowsAffected(1), nil
+		},
+		query: func(q string, args []driver.NamedValue) (*fakeRows, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			if strings.Contains(q, "FROM idempotency_keys") {
+				rows := &fakeRows{columns: []string{"request_hash", "status", "content_type", "body"}}
+				if row, ok := s.rows[args[0].Value.(string)]; ok {
+					rows.values = [][]driver.Value{row}
+				}
+				return rows, nil
+			}
+			return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{int64(s.inserts.Load())}}}, nil
+		},
+	}
+}
+
+// postWithKey отправляет POST /numbers с заголовком Idempotency-Key
+func postWithKey(app *App, target, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, nil)
+	req.Header.Set("Idempotency-Key", key)
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, req)
+	return w
+}
+
+// TestIdempotencyKeyReplay тестирует, что повтор с тем же ключом получает исходный ответ без новой вставки
+func TestIdempotencyKeyReplay(t *testing.T) {
+	store := newIdempotencyStore()
+	app := &App{DB: newFakeDB(t, store.connector())}
+
+	first := postWithKey(app, "/numbers?number=5", "abc")
+	if first.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, first.Code, first.Body.String())
+	}
+
+	second := postWithKey(app, "/numbers?number=5", "abc")
+	if second.Code != http.StatusOK {
+		t.Fatalf("Expected status %d on replay, got %d: %s", http.StatusOK, second.Code, second.Body.String())
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Errorf("Expected replayed body %q, got %q", first.Body.String(), second.Body.String())
+	}
+	if got := second.Header().Get("Idempotent-Replayed"); got != "true" {
+		t.Errorf("Expected Idempotent-Replayed true, got %q", got)
+	}
+	if got := second.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %q", got)
+	}
+	if got := store.inserts.Load(); got != 1 {
+		t.Errorf("Expected 1 insert, got %d", got)
+	}
+
+	// Другой ключ выполняет запрос заново
+	if w := postWithKey(app, "/numbers?number=5", "def"); w.Code != http.StatusOK || store.inserts.Load() != 2 {
+		t.Errorf("Expected a new insert for another key, got status %d and %d inserts", w.Code, store.inserts.Load())
+	}
+}
+
+// TestIdempotencyKeyConflicts тестирует 422 для другого запроса с тем же ключом, 409 во время
+// выполнения и отклонение слишком длинного ключа
+func TestIdempotencyKeyConflicts(t *testing.T) {
+	store := newIdempotencyStore()
+	app := &App{DB: newFakeDB(t, store.connector())}
+
+	if w := postWithKey(app, "/numbers?number=5", "abc"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if w := postWithKey(app, "/numbers?number=6", "abc"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status %d for a different request, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+
+	// Ключ зарезервирован, но ответ еще не сохранен
+	req := httptest.NewRequest(http.MethodPost, "/numbers?number=7", nil)
+	store.rows["pending"] = []driver.Value{idempotencyHash(req, nil), nil, nil, nil}
+	w := postWithKey(app, "/numbers?number=7", "pending")
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a request in progress, got %d", http.StatusConflict, w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After for a request in progress")
+	}
+
+	if w := postWithKey(app, "/numbers?number=8", strings.Repeat("k", maxIdempotencyKeyLength+1)); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a long key, got %d", http.StatusBadRequest, w.Code)
+	}
+	if got := store.inserts.Load(); got != 1 {
+		t.Errorf("Expected 1 insert, got %d", got)
+	}
+}
+
+// TestIdempotencyKeyReleasedOnServerError тестирует, что ответ 5xx не сохраняется и ключ освобождается
+func TestIdempotencyKeyReleasedOnServerError(t *testing.T) {
+	store := newIdempotencyStore()
+	fc := store.connector()
+	exec := fc.exec
+	var fail atomic.Bool
+	fail.Store(true)
+	fc.exec = func(q string, args []driver.NamedValue) (driver.Result, error) {
+		if strings.HasPrefix(q, "INSERT INTO numbers") && fail.Load() {
+			return nil, errors.New("insert failed")
+		}
+		return exec(q, args)
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	if w := postWithKey(app, "/numbers?number=5", "abc"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	if _, ok := store.rows["abc"]; ok {
+		t.Fatal("Expected the key to be released after a server error")
+	}
+
+	fail.Store(false)
+	w := postWithKey(app, "/numbers?number=5", "abc")
+	if w.Code != http.StatusOK || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("Expected the retry to run, got status %d replayed %q", w.Code, w.Header().Get("Idempotent-Replayed"))
+	}
+}
+
+// TestIdempotencyKeyLease тестирует, что брошенное резервирование старше IDEMPOTENCY_LEASE
+// перехватывается, а свежее по-прежнему отклоняется с 409
+func TestIdempotencyKeyLease(t *testing.T) {
+	store := newIdempotencyStore()
+	app := &App{DB: newFakeDB(t, store.connector()), Config: Config{IdempotencyLease: time.Minute}}
+
+	req := httptest.NewRequest(http.MethodPost, "/numbers?number=5", nil)
+	store.rows["fresh"] = []driver.Value{idempotencyHash(req, nil), nil, nil, nil}
+	store.reserved["fresh"] = time.Now().Add(-time.Second)
+	store.rows["stale"] = []driver.Value{idempotencyHash(req, nil), nil, nil, nil}
+	store.reserved["stale"] = time.Now().Add(-2 * time.Minute)
+
+	if w := postWithKey(app, "/numbers?number=5", "fresh"); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a fresh reservation, got %d", http.StatusConflict, w.Code)
+	}
+
+	w := postWithKey(app, "/numbers?number=5", "stale")
+	if w.Code != http.StatusOK || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("Expected the stale reservation to be taken over, got status %d replayed %q", w.Code, w.Header().Get("Idempotent-Replayed"))
+	}
+	if store.rows["stale"][1] == nil {
+		t.Error("Expected the response to be saved under the taken over key")
+	}
+	if got := store.inserts.Load(); got != 1 {
+		t.Errorf("Expected 1 insert, got %d", got)
+	}
+}
+
+// TestIdempotencyKeyBodyLimit тестирует, что слишком большое тело отклоняется с 413 без резервирования ключа
+func TestIdempotencyKeyBodyLimit(t *testing.T) {
+	store := newIdempotencyStore()
+	app := &App{DB: newFakeDB(t, store.connector())}
+
+	req := httptest.NewRequest(http.MethodPost, "/numbers", strings.NewReader(strings.Repeat(" ", maxIdempotentBodyBytes+1)))
+	req.Header.Set("Idempotency-Key", "abc")
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status %d, got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+	if _, ok := store.rows["abc"]; ok || store.inserts.Load() != 0 {
+		t.Errorf("Expected no reservation and no insert, got %d inserts", store.inserts.Load())
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxImportBodyBytes ограничивает размер загружаемого файла импорта по умолчанию (1 GiB)
+const defaultMaxImportBodyBytes = 1 << 30
+
+// maxImportErrors ограничивает количество ошибок строк в отчете импорта; остальные ошибки
+// только учитываются в failed
+const maxImportErrors = 100
+
+// ImportLineError описывает строку файла, которая не была импортирована
+type ImportLineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// ImportResult описывает итог импорта CSV. Failed - общее количество пропущенных строк,
+// Errors - первые maxImportErrors из них с причиной. Skipped - значения, уже сохраненные в
+// режиме UNIQUE_NUMBERS. Error заполняется, если импорт прерван
+type ImportResult struct {
+	Inserted int               `json:"inserted"`
+	Skipped  int               `json:"skipped,omitempty"`
+	Failed   int               `json:"failed"`
+	Errors   []ImportLineError `json:"errors"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// handleImport загружает значения из файла CSV в поле file запроса multipart/form-data. Файл
+// читается потоково, по строке, и сохраняется частями по BATCH_CHUNK_SIZE значений, как в
+// POST /numbers/batch, поэтому память не зависит от размера файла. Значение берется из столбца
+// value, если первая строка - заголовок с таким столбцом (например, файл /numbers/export.csv),
+// иначе из первого столбца. Строки с неверным числом или не прошедшие валидаторы пропускаются
+// и попадают в отчет с номером строки. Ошибка вставки части прерывает импорт: сохранены
+// только первые inserted значений. В режиме MONOTONIC часть с невозрастающим значением
+// прерывает импорт с 409. Тело больше MAX_IMPORT_BODY_BYTES отклоняется с 413
+func (app *App) handleImport(w http.ResponseWriter, r *http.Request) {
+	if limit := app.Config.MaxImportBodyBytes; limit > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+	}
+
+	file, err := importFile(r)
+	if err != nil {
+		if batchDecodeStatus(err) == http.StatusRequestEntityTooLarge {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cr := csv.NewReader(file)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	chunkSize := app.batchChunkSize()
+	chunk := make([]int64, 0, chunkSize)
+	result := ImportResult{Errors: []ImportLineError{}}
+	status := http.StatusOK
+	column, line := 0, 0
+
+	reject := func(line int, reason string) {
+		result.Failed++
+		if len(result.Errors) < maxImportErrors {
+			result.Errors = append(result.Errors, ImportLineError{Line: line, Error: reason})
+		}
+	}
+
+	// flush сохраняет накопленную часть; line - номер последней строки части
+	flush := func() {
+		var inserted []int64
+		err := app.withRetry(func() (err error) {
+			inserted, err = app.insertChunk(chunk)
+			return err
+		})
+		var merr monotonicError
+		if errors.As(err, &merr) {
+			result.Error = fmt.Sprintf("Values up to line %d: %v", line, err)
+			result.Failed += len(chunk)
+			status = http.StatusConflict
+		} else if err != nil {
+			log.Printf("Error inserting import chunk ending at line %d: %v", line, maskError(err))
+			result.Error = "Failed to save values up to line " + strconv.Itoa(line)
+			result.Failed += len(chunk)
+
+			status = http.StatusInternalServerError
+			if isReadOnlyError(err) {
+				w.Header().Set("Retry-After", strconv.Itoa(app.readOnlyRetryAfter()))
+				status = http.StatusServiceUnavailable
+			}
+		} else {
+			result.Inserted += len(inserted)
+			result.Skipped += len(chunk) - len(inserted)
+			app.Stream.publish(inserted...)
+		}
+		chunk = chunk[:0]
+	}
+
+	for first := true; status == http.StatusOK; first = false {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			line = perr.StartLine
+			reject(line, perr.Err.Error())
+			continue
+		}
+		if err != nil {
+			// Файл не дочитать: накопленная часть не сохраняется
+			status, result.Error = batchDecodeStatus(err), fmt.Sprintf("Failed to read file after line %d", line)
+			result.Failed += len(chunk)
+			break
+		}
+		line, _ = cr.FieldPos(0)
+
+		if first {
+			// Таблицы, сохраненные в UTF-8 с BOM, начинают первое поле с U+FEFF
+			record[0] = strings.TrimPrefix(record[0], "\ufeff")
+			if i := importValueColumn(record); i >= 0 {
+				column = i
+				continue
+			}
+		}
+		if column >= len(record) {
+			reject(line, "value column is missing")
+			continue
+		}
+		n, err := parseNumber(strings.TrimSpace(record[column]))
+		if err != nil {
+			reject(line, err.Error())
+			continue
+		}
+		if err := app.validateNumber(n); err != nil {
+			reject(line, err.Error())
+			continue
+		}
+		chunk = append(chunk, n)
+		if len(chunk) == chunkSize {
+			flush()
+		}
+	}
+	if status == http.StatusOK && len(chunk) > 0 {
+		flush()
+	}
+
+	if result.Inserted > 0 {
+		app.dataChanged()
+	}
+	if status != http.StatusOK {
+		writeJSONStatus(w, status, result)
+		return
+	}
+	writeJSON(w, result)
+}
+
+// importFile возвращает содержимое поля file запроса multipart/form-data. Части тела читаются
+// по мере обращения, файл не сохраняется ни в памяти, ни на диске
+func importFile(r *http.Request) (io.Reader, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, errors.New("Expected a multipart/form-data body with a file field")
+	}
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("file field is required")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid multipart body: %w", err)
+		}
+		if part.FormName() == "file" {
+			return part, nil
+		}
+	}
+}
+
+// importValueColumn возвращает номер столбца value, если запись - строка заголовка, и -1,
+// если в записи нет такого столбца
+func importValueColumn(record []string) int {
+	for i, field := range record {
+		if strings.EqualFold(strings.TrimSpace(field), "value") {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// importRequest возвращает запрос POST /numbers/import с файлом content в поле field
+func importRequest(t *testing.T, field, content string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if err := mw.WriteField("comment", "ignored"); err != nil {
+		t.Fatalf("Failed to write field: %v", err)
+	}
+	fw, err := mw.CreateFormFile(field, "numbers.csv")
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	fw.Write([]byte(content))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/numbers/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+// decodeImportResult разбирает отчет импорта
+func decodeImportResult(t *testing.T, w *httptest.ResponseRecorder) ImportResult {
+	t.Helper()
+	var result ImportResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &result)
+	return result
+}
+
+// TestImportCSV тестирует импорт частями, столбец value из заголовка и отчет об ошибках строк
+func TestImportCSV(t *testing.T) {
+	fc, chunks := recordingConnector(0)
+	app := &App{DB: newFakeDB(t, fc), Config: Config{BatchChunkSize: 2}}
+
+	// Незакрытая кавычка в строке 8 поглощает файл до конца одной ошибочной записью
+	content := "\ufeffid,value,created_at\n" +
+		"1,5,2024-01-01T00:00:00Z\n" +
+		"2,abc,\n" +
+		"3, -3 ,\n" +
+		"4\n" +
+		"5,99999999999999999999,\n" +
+		"6,1,\n" +
+		"7,\"8\n" +
+		"8,2,\n"
+	w := serveChecked(t, app.routes(), importRequest(t, "file", content))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	expected := ImportResult{
+		Inserted: 3,
+		Failed:   4,
+		Errors: []ImportLineError{
+			{Line: 3, Error: errInvalidNumber.Error()},
+			{Line: 5, Error: "value column is missing"},
+			{Line: 6, Error: errNumberRange.Error()},
+			{Line: 8, Error: "extraneous or missing \" in quoted-field"},
+		},
+	}
+	if result := decodeImportResult(t, w); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected report %+v, got %+v", expected, result)
+	}
+	if !reflect.DeepEqual(*chunks, [][]int{{5, -3}, {1}}) {
+		t.Errorf("Expected chunks [[5 -3] [1]], got %v", *chunks)
+	}
+}
+
+// TestImportCSVWithoutHeader тестирует импорт первого столбца файла без заголовка и валидаторы
+func TestImportCSVWithoutHeader(t *testing.T) {
+	fc, chunks := recordingConnector(0)
+	app := &App{DB: newFakeDB(t, fc), Config: Config{BatchChunkSize: 2, Parity: "odd"}}
+	app.Validators = buildValidators(app.Config)
+
+	w := serveChecked(t, app.routes(), importRequest(t, "file", "1\n2\n3\n\n5\n7\n"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	result := decodeImportResult(t, w)
+	if result.Inserted != 4 || result.Failed != 1 || len(result.Errors) != 1 || result.Errors[0].Line != 2 {
+		t.Errorf("Expected 4 inserted and line 2 rejected, got %+v", result)
+	}
+	if !reflect.DeepEqual(*chunks, [][]int{{1, 3}, {5, 7}}) {
+		t.Errorf("Expected chunks [[1 3] [5 7]], got %v", *chunks)
+	}
+}
+
+// TestImportCSVErrors тестирует прерывание импорта при ошибке вставки и отказ в неверных запросах
+func TestImportCSVErrors(t *testing.T) {
+	fc, chunks := recordingConnector(2)
+	app := &App{DB: newFakeDB(t, fc), Config: Config{BatchChunkSize: 2}}
+
+	w := serveChecked(t, app.routes(), importRequest(t, "file", "1\n2\n3\n4\n5\n6\n"))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusInternalServerError, w.Code, w.Body.String())
+	}
+	result := decodeImportResult(t, w)
+	if result.Inserted != 2 || result.Failed != 2 || result.Error != "Failed to save values up to line 4" {
+		t.Errorf("Expected 2 inserted and the chunk ending at line 4 failed, got %+v", result)
+	}
+	if len(*chunks) != 2 {
+		t.Errorf("Expected the import to stop after the failed chunk, got %v", *chunks)
+	}
+
+	notMultipart := httptest.NewRequest(http.MethodPost, "/numbers/import", strings.NewReader("1\n2\n"))
+	notMultipart.Header.Set("Content-Type", "text/csv")
+
+	app.Config.MaxImportBodyBytes = 512
+	for _, tt := range []struct {
+		name   string
+		req    *http.Request
+		status int
+	}{
+		{"not multipart", notMultipart, http.StatusBadRequest},
+		{"no file field", importRequest(t, "data", "1\n"), http.StatusBadRequest},
+		{"too large", importRequest(t, "file", strings.Repeat("1\n", 1000)), http.StatusRequestEntityTooLarge},
+		{"wrong method", httptest.NewRequest(http.MethodGet, "/numbers/import", nil), http.StatusMethodNotAllowed},
+	} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, tt.req)
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.status, w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestImportCSVRoundTrip тестирует повторную загрузку файла /numbers/export.csv в реальную базу
+func TestImportCSVRoundTrip(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.Exec("DELETE FROM numbers")
+	app := &App{DB: db}
+	for _, num := range []int{4, 2, 9} {
+		db.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers/export.csv", nil))
+	exported := w.Body.String()
+
+	w = serveChecked(t, app.routes(), importRequest(t, "file", exported))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if result := decodeImportResult(t, w); result.Inserted != 3 || result.Failed != 0 {
+		t.Errorf("Expected 3 inserted, got %+v", result)
+	}
+
+	numbers, err := app.getAllNumbers()
+	if err != nil || !reflect.DeepEqual(numbers, []int64{2, 2, 4, 4, 9, 9}) {
+		t.Errorf("Expected every value twice, got %v (%v)", numbers, err)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// modTracker хранит время последнего изменения данных этим экземпляром сервиса
+type modTracker struct {
+	unixNano atomic.Int64
+}
+
+// touch запоминает время изменения, если оно позже уже известного. Безопасно вызывать для nil
+func (m *modTracker) touch(t time.Time) {
+	if m == nil {
+		return
+	}
+	for {
+		prev := m.unixNano.Load()
+		if t.UnixNano() <= prev || m.unixNano.CompareAndSwap(prev, t.UnixNano()) {
+			return
+		}
+	}
+}
+
+// get возвращает время последнего изменения; false, если изменений еще не было
+func (m *modTracker) get() (time.Time, bool) {
+	if m == nil {
+		return time.Time{}, false
+	}
+	n := m.unixNano.Load()
+	if n == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, n).UTC(), true
+}
+
+// newModTracker создает трекер, начальное значение которого - время добавления последнего
+// значения в базе. Удаления до запуска сервиса по created_at не восстановить
+func newModTracker(db *sql.DB) (*modTracker, error) {
+	m := &modTracker{}
+	var last sql.NullTime
+	if err := db.QueryRow("SELECT MAX(created_at)::timestamptz FROM numbers").Scan(&last); err != nil {
+		return nil, err
+	}
+	if last.Valid {
+		m.touch(last.Time)
+	}
+	return m, nil
+}
+
+// dataChanged вызывается после каждого изменения данных: сбрасывает кэш ответов, обновляет
+// время последнего изменения и запрашивает обновление материализованного представления
+func (app *App) dataChanged() {
+	app.Cache.invalidate()
+	app.LastModified.touch(time.Now())
+	app.Matview.request()
+}
+
+// LastModifiedResponse представляет время последнего изменения данных; null, если оно неизвестно
+type LastModifiedResponse struct {
+	LastModified *time.Time `json:"last_modified"`
+}
+
+// handleLastModified возвращает время последней вставки или удаления значений
+func (app *App) handleLastModified(w http.ResponseWriter, r *http.Request) {
+	var result LastModifiedResponse
+	if t, ok := app.LastModified.get(); ok {
+		result.LastModified = &t
+	}
+	writeJSON(w, result)
+}
+
+// withLastModified добавляет заголовок Last-Modified к GET и HEAD ответам эндпоинтов /numbers
+func (app *App) withLastModified(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			if r.URL.Path == "/numbers" || strings.HasPrefix(r.URL.Path, "/numbers/") {
+				if t, ok := app.LastModified.get(); ok {
+					w.Header().Set("Last-Modified", t.Format(http.TimeFormat))
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// getLastModified запрашивает /numbers/last-modified
+func getLastModified(t *testing.T, app *App) *time.Time {
+	t.Helper()
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/last-modified", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var response LastModifiedResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return response.LastModified
+}
+
+// TestLastModifiedUpdatesAfterWrite тестирует обновление времени изменения после вставки
+// и заголовок Last-Modified в GET ответах
+func TestLastModifiedUpdatesAfterWrite(t *testing.T) {
+	app := &App{DB: newFakeDB(t, &fakeConnector{}), LastModified: &modTracker{}}
+
+	if got := getLastModified(t, app); got != nil {
+		t.Fatalf("Expected null before any write, got %v", got)
+	}
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers", nil))
+	if got := w.Header().Get("Last-Modified"); got != "" {
+		t.Errorf("Expected no Last-Modified before any write, got %q", got)
+	}
+
+	before := time.Now()
+	w = httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers?number=5", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	first := getLastModified(t, app)
+	if first == nil || first.Before(before) {
+		t.Fatalf("Expected last modified after %v, got %v", before, first)
+	}
+
+	w = httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers", nil))
+	header, err := http.ParseTime(w.Header().Get("Last-Modified"))
+	if err != nil || !header.Equal(first.Truncate(time.Second)) {
+		t.Errorf("Expected Last-Modified %v, got %q", first.Truncate(time.Second), w.Header().Get("Last-Modified"))
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	app.storeNumber(6)
+	if second := getLastModified(t, app); second == nil || !second.After(*first) {
+		t.Errorf("Expected last modified to advance after another write, got %v then %v", first, second)
+	}
+}
+
+// TestNewModTracker тестирует начальное значение из времени добавления последнего значения
+func TestNewModTracker(t *testing.T) {
+	last := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, v := range []driver.Value{last, nil} {
+		fc := &fakeConnector{query: func(string, []driver.NamedValue) (*fakeRows, error) {
+			return &fakeRows{columns: []string{"max"}, values: [][]driver.Value{{v}}}, nil
+		}}
+		m, err := newModTracker(newFakeDB(t, fc))
+		if err != nil {
+			t.Fatalf("newModTracker failed: %v", err)
+		}
+		got, ok := m.get()
+		if v == nil && ok {
+			t.Errorf("Expected unknown time for an empty table, got %v", got)
+		}
+		if v != nil && !got.Equal(last) {
+			t.Errorf("Expected %v, got %v", last, got)
+		}
+	}
+
+	// Более раннее время не заменяет уже известное
+	m := &modTracker{}
+	m.touch(last)
+	m.touch(last.Add(-time.Hour))
+	if got, _ := m.get(); !got.Equal(last) {
+		t.Errorf("Expected %v to be kept, got %v", last, got)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// listNamePattern задает допустимые имена списков: строчные латинские буквы, цифры, '_' и '-'
+var listNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// handleLists обрабатывает запросы к именованным спискам чисел:
+//   - /lists/{name}/numbers - GET отсортированных значений списка с теми же параметрами, что и
+//     GET /numbers, POST для добавления числа;
+//   - /lists/{name}/numbers/count - количество значений списка;
+//   - /lists/{name}/numbers/stats - статистика значений списка.
+//
+// Список существует, пока в нем есть значения; несуществующий список пуст. Неверное имя
+// списка или неизвестный путь получают 404
+func (app *App) handleLists(w http.ResponseWriter, r *http.Request) {
+	name, resource, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/lists/"), "/")
+	if !listNamePattern.MatchString(name) {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch resource {
+	case "numbers":
+		switch r.Method {
+		case http.MethodGet:
+			app.serveNumbers(w, r, name)
+		case http.MethodPost:
+			app.addListNumber(w, r, name)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case "numbers/count":
+		onlyMethod(http.MethodGet, func(w http.ResponseWriter, r *http.Request) { app.countList(w, name) })(w, r)
+	case "numbers/stats":
+		onlyMethod(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+			app.writeStats(w, "list_numbers WHERE list = $1", name)
+		})(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// addListNumber проверяет число валидаторами, добавляет его в список и возвращает
+// отсортированные значения списка; параметр order=desc - по убыванию. Время жизни и метки
+// значений и несколько чисел в одном запросе списки не поддерживают
+func (app *App) addListNumber(w http.ResponseWriter, r *http.Request, name string) {
+	desc, err := parseOrder(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req, err := parseNumberRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.TTLSeconds != nil || len(req.Tags) > 0 {
+		http.Error(w, "ttl_seconds and tags are not supported for lists", http.StatusBadRequest)
+		return
+	}
+	if len(req.Numbers) > 1 {
+		http.Error(w, "Only one number can be added to a list at a time", http.StatusBadRequest)
+		return
+	}
+	if err := app.validateNumber(req.Number); err != nil {
+		app.writeStoreError(w, validationError{err})
+		return
+	}
+
+	err = app.withRetry(func() error {
+		_, err := app.DB.Exec("INSERT INTO list_numbers (list, value) VALUES ($1, $2)", name, req.Number)
+		return err
+	})
+	if err != nil {
+		app.writeStoreError(w, err)
+		return
+	}
+
+	query, args := numberFilter{List: name, Desc: desc}.sql()
+	numbers, err := app.queryNumbers(query, args...)
+	if err != nil {
+		log.Printf("Error getting list numbers: %v", err)
+		http.Error(w, "Failed to retrieve numbers", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, NumbersResponse{Numbers: numbers})
+}
+
+// countList отправляет количество значений списка в теле и в заголовке X-Total-Count
+func (app *App) countList(w http.ResponseWriter, name string) {
+	query, args := numberFilter{List: name}.countSQL()
+
+	var count int
+	if err := app.DB.QueryRow(query, args...).Scan(&count); err != nil {
+		log.Printf("Error counting list numbers: %v", err)
+		http.Error(w, "Failed to count numbers", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(count))
+	writeJSON(w, CountResponse{Count: count})
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestListsIndependent тестирует, что именованные списки и основная таблица не видят значения друг друга
+func TestListsIndependent(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if _, err := db.Exec("DELETE FROM list_numbers"); err != nil {
+		t.Fatalf("Failed to clean lists: %v", err)
+	}
+	app := &App{DB: db}
+
+	for _, target := range []string{"/lists/a/numbers?number=3", "/lists/a/numbers?number=1", "/lists/b/numbers?number=2", "/numbers?number=9"} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d: %s", target, http.StatusOK, w.Code, w.Body.String())
+		}
+	}
+
+	for target, expected := range map[string][]int64{
+		"/lists/a/numbers": {1, 3},
+		"/lists/b/numbers": {2},
+		"/lists/c/numbers": {},
+		"/numbers":         {9},
+	} {
+		w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, target, nil))
+		var response NumbersResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("%s: failed to decode response: %v", target, err)
+		}
+		if !reflect.DeepEqual(response.Numbers, expected) {
+			t.Errorf("%s: expected %v, got %v", target, expected, response.Numbers)
+		}
+	}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/lists/a/numbers/stats", nil))
+	var stats Stats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	if stats.Count != 2 || stats.Sum == nil || stats.Sum.Int64() != 4 {
+		t.Errorf("Expected count 2 and sum 4 for list a, got %+v", stats)
+	}
+}
+
+// TestListsFake тестирует маршрутизацию и условие по имени списка без обращения к реальной базе
+func TestListsFake(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		queries []string
+		inserts [][]driver.NamedValue
+	)
+	fc := &fakeConnector{
+		exec: func(q string, args []driver.NamedValue) (driver.Result, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			inserts = append(inserts, args)
+			return driver.RowsAffected(1), nil
+		},
+		query: func(q string, args []driver.NamedValue) (*fakeRows, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			queries = append(queries, q)
+			if len(args) == 0 || args[0].Value != "sensors" {
+				t.Errorf("Expected list name as the first argument of %q, got %v", q, args)
+			}
+			switch {
+			case strings.Contains(q, "PERCENTILE_CONT"):
+				return &fakeRows{
+					columns: []string{"count", "min", "max", "sum", "avg", "median"},
+					values:  [][]driver.Value{{int64(2), int64(1), int64(3), int64(4), 2.0, 2.0}},
+				}, nil
+			case strings.HasPrefix(q, "SELECT COUNT(*)"):
+				return &fakeRows{columns: []string{"count"}, values: [][]driver.Value{{int64(2)}}}, nil
+			}
+			return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{int64(1)}, {int64(3)}}}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodPost, "/lists/sensors/numbers?number=3", nil))
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `{"numbers":[1,3]}` {
+		t.Errorf("Expected the sorted list, got %d %s", w.Code, w.Body.String())
+	}
+	if len(inserts) != 1 || inserts[0][0].Value != "sensors" || inserts[0][1].Value != int64(3) {
+		t.Errorf("Expected one insert into list sensors, got %v", inserts)
+	}
+
+	w = serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/lists/sensors/numbers?min=1", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	w = serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/lists/sensors/numbers/count", nil))
+	if strings.TrimSpace(w.Body.String()) != `{"count":2}` || w.Header().Get("X-Total-Count") != "2" {
+		t.Errorf("Expected count 2, got %s", w.Body.String())
+	}
+
+	w = serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/lists/sensors/numbers/stats", nil))
+	var stats Stats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil || stats.Count != 2 {
+		t.Errorf("Expected stats with count 2, got %s (%v)", w.Body.String(), err)
+	}
+
+	for _, q := range queries {
+		if !strings.Contains(q, "FROM list_numbers WHERE list = $1") {
+			t.Errorf("Expected a query restricted to the list, got %q", q)
+		}
+	}
+
+	for _, tt := range []struct {
+		method, target string
+		want           int
+	}{
+		{http.MethodGet, "/lists/Bad_Name/numbers", http.StatusNotFound},
+		{http.MethodGet, "/lists/sensors", http.StatusNotFound},
+		{http.MethodGet, "/lists/sensors/other", http.StatusNotFound},
+		{http.MethodPut, "/lists/sensors/numbers", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/lists/sensors/numbers/count", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/lists/sensors/numbers?number=1&ttl_seconds=5", http.StatusBadRequest},
+		{http.MethodPost, "/lists/sensors/numbers?number=1,2", http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.target, tt.want, w.Code)
+		}
+	}
+}
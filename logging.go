@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// logSampler решает, записывать ли успешный запрос в лог. Пока нагрузка не превышает
+// threshold запросов в секунду, пишется каждый запрос; выше порога - только каждый rate-й.
+// Ответы с ошибкой (4xx и 5xx) пишутся всегда
+type logSampler struct {
+	rate      int
+	threshold int
+	now       func() time.Time
+
+	mu          sync.Mutex
+	windowStart time.Time
+	inWindow    int
+	skipped     int
+}
+
+// newLogSampler создает сэмплер, пишущий 1 из rate запросов при нагрузке выше threshold в секунду
+func newLogSampler(rate, threshold int) *logSampler {
+	return &logSampler{rate: rate, threshold: threshold, now: time.Now}
+}
+
+// allow сообщает, нужно ли записать запрос с указанным статусом. Nil-сэмплер пишет все запросы
+func (s *logSampler) allow(status int) bool {
+	if s == nil || s.rate <= 1 {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.windowStart) >= time.Second {
+		s.windowStart = now
+		s.inWindow = 0
+	}
+	s.inWindow++
+
+	if status >= http.StatusBadRequest || s.inWindow <= s.threshold {
+		return true
+	}
+	s.skipped++
+	if s.skipped < s.rate {
+		return false
+	}
+	s.skipped = 0
+	return true
+}
+
+// statusRecorder запоминает код ответа для журнала запросов
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap открывает исходный ResponseWriter для http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// logRequests пишет в лог метод, путь, статус и длительность каждого запроса с учетом сэмплирования
+func (app *App) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		if app.LogSampler.allow(rec.status) {
+			log.Printf("%s %s %d %s", r.Method, r.URL.Path, rec.status, time.Since(start))
+		}
+	})
+}
+
+// maskValues включает маскирование сохраняемых чисел в логах (MASK_VALUES)
+var maskValues bool
+
+// maskedNumberPattern находит числа в тексте ошибки PostgreSQL
+var maskedNumberPattern = regexp.MustCompile(`-?\d+`)
+
+// maskError скрывает числа в сообщениях PostgreSQL, которые могут содержать сохраняемые
+// значения (например, `value "123" is out of range`), оставляя код ошибки для диагностики.
+// Прочие ошибки (сетевые и т.п.) значений не содержат и возвращаются без изменений
+func maskError(err error) error {
+	var pqErr *pq.Error
+	if !maskValues || !errors.As(err, &pqErr) {
+		return err
+	}
+	return errors.New("pq: " + maskedNumberPattern.ReplaceAllString(pqErr.Message, "***") + " (SQLSTATE " + string(pqErr.Code) + ")")
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// TestLogSamplerAllow тестирует, что ошибки пишутся всегда, а успешные запросы - 1 из rate
+func TestLogSamplerAllow(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newLogSampler(3, 2)
+	s.now = func() time.Time { return now }
+
+	// Первые threshold запросов в секунду пишутся без сэмплирования
+	for i := 0; i < 2; i++ {
+		if !s.allow(http.StatusOK) {
+			t.Fatalf("Request %d below threshold should be logged", i)
+		}
+	}
+
+	logged := 0
+	for i := 0; i < 9; i++ {
+		if s.allow(http.StatusOK) {
+			logged++
+		}
+	}
+	if logged != 3 {
+		t.Errorf("Expected 3 of 9 successful requests logged, got %d", logged)
+	}
+
+	for _, status := range []int{http.StatusBadRequest, http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		if !s.allow(status) {
+			t.Errorf("Expected status %d to always be logged", status)
+		}
+	}
+
+	// В новой секунде счетчик нагрузки сбрасывается
+	now = now.Add(time.Second)
+	if !s.allow(http.StatusOK) {
+		t.Error("Expected request in a new window to be logged")
+	}
+}
+
+// TestLogRequestsSampling тестирует сэмплирование в middleware журнала запросов
+func TestLogRequestsSampling(t *testing.T) {
+	var buf bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(prev)
+
+	app := &App{LogSampler: newLogSampler(4, 0)}
+	handler := app.logRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}
+	}))
+
+	for i := 0; i < 8; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	}
+	for i := 0; i < 3; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	}
+
+	out := buf.String()
+	if n := strings.Count(out, "GET /ok 200"); n != 2 {
+		t.Errorf("Expected 2 of 8 successful requests logged, got %d:\n%s", n, out)
+	}
+	if n := strings.Count(out, "GET /fail 500"); n != 3 {
+		t.Errorf("Expected all 3 failed requests logged, got %d:\n%s", n, out)
+	}
+}
+
+// TestMaskValuesInLoggedErrors тестирует, что при MASK_VALUES ошибка записи не раскрывает значение
+func TestMaskValuesInLoggedErrors(t *testing.T) {
+	var buf bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(prev)
+
+	maskValues = true
+	defer func() { maskValues = false }()
+
+	fc := &fakeConnector{
+		exec: func(query string, args []driver.NamedValue) (driver.Result, error) {
+			return nil, &pq.Error{Code: pgNumericValueOutOfRange, Message: `value "98765432109" is out of range for type integer`}
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers?number=98765432109", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	out := buf.String()
+	if strings.Contains(out, "98765432109") {
+		t.Errorf("Expected value to be masked in logs, got:\n%s", out)
+	}
+	if !strings.Contains(out, "is out of range") || !strings.Contains(out, pgNumericValueOutOfRange) {
+		t.Errorf("Expected error metadata to be kept in logs, got:\n%s", out)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MaintenanceResponse описывает плановое окно обслуживания, во время которого сервис отвечает 503
+type MaintenanceResponse struct {
+	Status     string    `json:"status"`
+	Until      time.Time `json:"until"`
+	RetryAfter int       `json:"retry_after"`
+}
+
+// inMaintenance до момента MAINTENANCE_UNTIL отвечает на все запросы, кроме /health, статусом 503
+// с Retry-After и описанием окна обслуживания. После окончания окна запросы обрабатываются как обычно
+func (app *App) inMaintenance(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		until := app.Config.MaintenanceUntil
+		if until == nil || r.URL.Path == "/health" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		remaining := time.Until(*until)
+		if remaining <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		retryAfter := retryAfterSeconds(remaining)
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		writeJSONStatus(w, http.StatusServiceUnavailable, MaintenanceResponse{
+			Status:     "maintenance",
+			Until:      until.UTC(),
+			RetryAfter: retryAfter,
+		})
+	})
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestMaintenanceWindow тестирует ответ 503 во время окна обслуживания и обычную работу после него
+func TestMaintenanceWindow(t *testing.T) {
+	until := time.Now().Add(90 * time.Second)
+	app := &App{DB: newFakeDB(t, &fakeConnector{}), Config: Config{MaintenanceUntil: &until}}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status %d during maintenance, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	if err != nil || retryAfter < 89 || retryAfter > 90 {
+		t.Errorf("Expected Retry-After of about 90 seconds, got %q", w.Header().Get("Retry-After"))
+	}
+
+	var response MaintenanceResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Status != "maintenance" || !response.Until.Equal(until) || response.RetryAfter != retryAfter {
+		t.Errorf("Unexpected maintenance response %+v", response)
+	}
+
+	// /health доступен и во время обслуживания
+	w = httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected /health status %d during maintenance, got %d", http.StatusOK, w.Code)
+	}
+
+	// После окончания окна запросы обрабатываются как обычно
+	past := time.Now().Add(-time.Second)
+	app.Config.MaintenanceUntil = &past
+
+	w = httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d after maintenance, got %d", http.StatusOK, w.Code)
+	}
+	if w.Header().Get("Retry-After") != "" {
+		t.Errorf("Expected no Retry-After after maintenance, got %q", w.Header().Get("Retry-After"))
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// valueCountsView - материализованное представление с количеством повторов каждого значения.
+// Из него при USE_MATVIEW читают /numbers/decades и /numbers/histogram.png: представление
+// содержит по строке на различное значение, а не на каждую вставку
+const valueCountsView = "numbers_value_counts"
+
+// createValueCountsView создает материализованное представление и уникальный индекс, без
+// которого невозможно REFRESH ... CONCURRENTLY. Повторный вызов ничего не меняет
+func createValueCountsView(db *sql.DB) error {
+	statements := []string{
+		`CREATE MATERIALIZED VIEW IF NOT EXISTS ` + valueCountsView + ` AS
+			SELECT value, COUNT(*)::int AS count FROM live_numbers GROUP BY value`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_numbers_value_counts_value ON ` + valueCountsView + ` (value)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("materialized view %s: %w", valueCountsView, err)
+		}
+	}
+	return nil
+}
+
+// valueCounts возвращает различные значения по возрастанию и количество повторов каждого
+// из материализованного представления
+func (app *App) valueCounts() ([]int64, []int, error) {
+	rows, err := app.DB.Query("SELECT value, count FROM " + valueCountsView + " ORDER BY value ASC")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	values, counts := []int64{}, []int{}
+	for rows.Next() {
+		var (
+			value int64
+			count int
+		)
+		if err := rows.Scan(&value, &count); err != nil {
+			return nil, nil, err
+		}
+		values = append(values, value)
+		counts = append(counts, count)
+	}
+	return values, counts, rows.Err()
+}
+
+// matviewRefresher обновляет материализованное представление по расписанию или, если период
+// не задан, после записей. Записи, пришедшие во время обновления, объединяются в одно следующее
+type matviewRefresher struct {
+	db       *sql.DB
+	interval time.Duration
+	pending  chan struct{}
+
+	// onRefresh вызывается после успешного обновления; сбрасывает кэш ответов, который мог
+	// сохранить данные из устаревшего представления
+	onRefresh func()
+}
+
+// newMatviewRefresher создает обновляющий; interval 0 означает обновление после каждой записи
+func newMatviewRefresher(db *sql.DB, interval time.Duration, onRefresh func()) *matviewRefresher {
+	return &matviewRefresher{db: db, interval: interval, pending: make(chan struct{}, 1), onRefresh: onRefresh}
+}
+
+// request запрашивает обновление после записи, не дожидаясь его. При обновлении по расписанию
+// ничего не делает. Безопасно вызывать для nil
+func (m *matviewRefresher) request() {
+	if m == nil || m.interval > 0 {
+		return
+	}
+	select {
+	case m.pending <- struct{}{}:
+	default:
+		// Обновление уже запрошено и учтет и эту запись
+	}
+}
+
+// refresh обновляет представление, не блокируя чтение из него
+func (m *matviewRefresher) refresh(ctx context.Context) error {
+	if _, err := m.db.ExecContext(ctx, "REFRESH MATERIALIZED VIEW CONCURRENTLY "+valueCountsView); err != nil {
+		return err
+	}
+	if m.onRefresh != nil {
+		m.onRefresh()
+	}
+	return nil
+}
+
+// run обновляет представление по расписанию или по запросам до отмены контекста
+func (m *matviewRefresher) run(ctx context.Context) {
+	var tick <-chan time.Time
+	if m.interval > 0 {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		case <-m.pending:
+		}
+		if err := m.refresh(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error refreshing materialized view: %v", err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMatviewEndpoints тестирует, что при USE_MATVIEW агрегаты читаются из представления
+func TestMatviewEndpoints(t *testing.T) {
+	fc := &fakeConnector{
+		query: func(q string, args []driver.NamedValue) (*fakeRows, error) {
+			if !strings.Contains(q, valueCountsView) {
+				t.Errorf("Expected a query against %s, got %q", valueCountsView, q)
+			}
+			if strings.Contains(q, "decade") {
+				return &fakeRows{columns: []string{"decade", "count"}, values: [][]driver.Value{{int64(-1), int64(2)}, {int64(1), int64(5)}}}, nil
+			}
+			return &fakeRows{columns: []string{"value", "count"}, values: [][]driver.Value{{int64(1), int64(3)}, {int64(9), int64(1)}}}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc), Config: Config{UseMatview: true}}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers/decades", nil))
+	var decades []DecadeBucket
+	if err := json.NewDecoder(w.Body).Decode(&decades); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	expected := []DecadeBucket{{Decade: -1, Range: "-10..-1", Count: 2}, {Decade: 1, Range: "10..19", Count: 5}}
+	if !reflect.DeepEqual(decades, expected) {
+		t.Errorf("Expected %v, got %v", expected, decades)
+	}
+
+	w = httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/histogram.png?buckets=2", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("Expected a PNG histogram, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	values, counts, err := app.valueCounts()
+	if err != nil {
+		t.Fatalf("valueCounts failed: %v", err)
+	}
+	if got := weightedHistogramCounts(values, counts, 2); !reflect.DeepEqual(got, []int{3, 1}) {
+		t.Errorf("Expected histogram [3 1] from the view, got %v", got)
+	}
+}
+
+// TestMatviewRefreshAfterWrite тестирует обновление представления после записи и сброс кэша
+func TestMatviewRefreshAfterWrite(t *testing.T) {
+	refreshed := make(chan struct{}, 10)
+	fc := &fakeConnector{
+		exec: func(q string, args []driver.NamedValue) (driver.Result, error) {
+			if strings.HasPrefix(q, "REFRESH MATERIALIZED VIEW") {
+				refreshed <- struct{}{}
+			}
+			return driver.RowsAffected(1), nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc), Config: Config{UseMatview: true}}
+	invalidated := make(chan struct{}, 10)
+	app.Matview = newMatviewRefresher(app.DB, 0, func() { invalidated <- struct{}{} })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go app.Matview.run(ctx)
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers?number=4", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	for _, ch := range []chan struct{}{refreshed, invalidated} {
+		select {
+		case <-ch:
+		case <-time.After(time.Second):
+			t.Fatal("Expected the view to be refreshed after the write")
+		}
+	}
+}
+
+// TestMatviewRefreshInterval тестирует, что при заданном периоде записи не вызывают обновление
+func TestMatviewRefreshInterval(t *testing.T) {
+	m := newMatviewRefresher(nil, time.Hour, nil)
+	m.request()
+	if len(m.pending) != 0 {
+		t.Error("Expected writes to be ignored when a refresh interval is set")
+	}
+
+	// Запросы, пришедшие до обновления, объединяются в один
+	m = newMatviewRefresher(nil, 0, nil)
+	m.request()
+	m.request()
+	if len(m.pending) != 1 {
+		t.Errorf("Expected one pending refresh, got %d", len(m.pending))
+	}
+
+	var nilRefresher *matviewRefresher
+	nilRefresher.request()
+}
+
+// TestMatviewMatchesLiveQuery тестирует, что агрегаты из представления совпадают с расчетом по таблице
+func TestMatviewMatchesLiveQuery(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := createValueCountsView(db); err != nil {
+		t.Fatalf("createValueCountsView failed: %v", err)
+	}
+	defer db.Exec("DROP MATERIALIZED VIEW IF EXISTS " + valueCountsView)
+	for _, num := range []int{-1, 3, 3, 15, 27} {
+		db.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	live := &App{DB: db}
+	matview := &App{DB: db, Config: Config{UseMatview: true}}
+	matview.Matview = newMatviewRefresher(db, 0, nil)
+	if err := matview.Matview.refresh(context.Background()); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+
+	decades := func(app *App) string {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/decades", nil))
+		return w.Body.String()
+	}
+	if got, want := decades(matview), decades(live); got != want {
+		t.Errorf("Expected decades from the view %s, got %s", want, got)
+	}
+
+	numbers, _ := live.getAllNumbers()
+	values, counts, err := matview.valueCounts()
+	if err != nil {
+		t.Fatalf("valueCounts failed: %v", err)
+	}
+	if got, want := weightedHistogramCounts(values, counts, 4), histogramCounts(numbers, 4); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected histogram %v from the view, got %v", want, got)
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// migration описывает одно версионированное изменение схемы базы данных
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// migrations содержит изменения схемы в порядке применения.
+// Новые миграции добавляются только в конец списка со следующим номером версии
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create numbers table",
+		sql: `
+		CREATE TABLE IF NOT EXISTS numbers (
+			id SERIAL PRIMARY KEY,
+			value INTEGER NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+	},
+	{
+		version: 2,
+		name:    "create api_keys table",
+		sql: `
+		CREATE TABLE IF NOT EXISTS api_keys (
+			key_hash TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			request_count BIGINT NOT NULL DEFAULT 0,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			last_used_at TIMESTAMP
+		);`,
+	},
+	{
+		// Индекс по значению покрывает COUNT(*) и проверки существования (WHERE value = $1):
+		// они выполняются index-only scan по узкому индексу вместо чтения всей таблицы
+		version: 3,
+		name:    "create value index",
+		sql:     `CREATE INDEX IF NOT EXISTS idx_numbers_value ON numbers (value);`,
+	},
+	{
+		// Значения с TTL хранят момент истечения; чтения идут через представление live_numbers,
+		// которое скрывает истекшие строки до их удаления фоновой очисткой
+		version: 4,
+		name:    "add value expiration",
+		sql: `
+		ALTER TABLE numbers ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+		CREATE INDEX IF NOT EXISTS idx_numbers_expires_at ON numbers (expires_at) WHERE expires_at IS NOT NULL;
+		CREATE OR REPLACE VIEW live_numbers AS
+			SELECT id, value, created_at, expires_at
+			FROM numbers
+			WHERE expires_at IS NULL OR expires_at > now();`,
+	},
+	{
+		// Keyset-пагинация GET /numbers?after= читает индекс с позиции курсора (value, id)
+		version: 5,
+		name:    "create value and id index",
+		sql:     `CREATE INDEX IF NOT EXISTS idx_numbers_value_id ON numbers (value, id);`,
+	},
+	{
+		// Ключи Idempotency-Key POST /numbers с сохраненными ответами; status NULL означает,
+		// что запрос с этим ключом еще выполняется
+		version: 6,
+		name:    "create idempotency keys table",
+		sql: `
+		CREATE TABLE IF NOT EXISTS idempotency_keys (
+			key TEXT PRIMARY KEY,
+			request_hash TEXT NOT NULL,
+			status INTEGER,
+			content_type TEXT,
+			body BYTEA,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);
+		CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys (created_at);`,
+	},
+	{
+		// Версия записи для оптимистичной блокировки PATCH /numbers/{id}: каждое изменение
+		// значения увеличивает ее, а клиент передает ожидаемую версию в If-Match
+		version: 7,
+		name:    "add record version",
+		sql: `
+		ALTER TABLE numbers ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+		CREATE OR REPLACE VIEW live_numbers AS
+			SELECT id, value, created_at, expires_at, version
+			FROM numbers
+			WHERE expires_at IS NULL OR expires_at > now();`,
+	},
+	{
+		// Именованные списки /lists/{name}/numbers хранятся отдельно от основной таблицы,
+		// поэтому эндпоинты /numbers их не видят
+		version: 8,
+		name:    "create list numbers table",
+		sql: `
+		CREATE TABLE IF NOT EXISTS list_numbers (
+			id BIGSERIAL PRIMARY KEY,
+			list TEXT NOT NULL,
+			value INTEGER NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_list_numbers_list_value ON list_numbers (list, value, id);`,
+	},
+	{
+		// Метки чисел для фильтра GET /numbers?tag=; удаляются вместе с числом
+		version: 9,
+		name:    "create number tags table",
+		sql: `
+		CREATE TABLE IF NOT EXISTS number_tags (
+			number_id INTEGER NOT NULL REFERENCES numbers (id) ON DELETE CASCADE,
+			tag TEXT NOT NULL,
+			PRIMARY KEY (number_id, tag)
+		);
+		CREATE INDEX IF NOT EXISTS idx_number_tags_tag ON number_tags (tag, number_id);`,
+	},
+	{
+		// Дробные значения /decimals хранятся отдельно от целых: NUMERIC без ограничения
+		// точности, округление до DECIMAL_SCALE знаков выполняется при вставке
+		version: 10,
+		name:    "create decimal numbers table",
+		sql: `
+		CREATE TABLE IF NOT EXISTS decimal_numbers (
+			id BIGSERIAL PRIMARY KEY,
+			value NUMERIC NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_decimal_numbers_value ON decimal_numbers (value, id);`,
+	},
+	{
+		// Значения расширяются до BIGINT. Представления, зависящие от столбца, не дают изменить
+		// его тип, поэтому удаляются и создаются заново; numbers_value_counts пересоздается
+		// при запуске с USE_MATVIEW=true
+		version: 11,
+		name:    "widen values to bigint",
+		sql: `
+		DROP MATERIALIZED VIEW IF EXISTS numbers_value_counts;
+		DROP VIEW IF EXISTS live_numbers;
+		ALTER TABLE numbers ALTER COLUMN value TYPE BIGINT;
+		ALTER TABLE list_numbers ALTER COLUMN value TYPE BIGINT;
+		CREATE VIEW live_numbers AS
+			SELECT id, value, created_at, expires_at, version
+			FROM numbers
+			WHERE expires_at IS NULL OR expires_at > now();`,
+	},
+}
+
+// latestSchemaVersion возвращает версию последней известной приложению миграции
+func latestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// migrate применяет к базе данных все миграции, которые еще не были применены.
+// Каждая миграция выполняется в отдельной транзакции вместе с записью в schema_migrations
+func migrate(db *sql.DB) error {
+	createMigrationsTable := `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+	if _, err := db.Exec(createMigrationsTable); err != nil {
+		return err
+	}
+
+	current, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(db, m); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		log.Printf("Applied migration %d: %s", m.version, m.name)
+	}
+
+	return nil
+}
+
+// applyMigration выполняет одну миграцию и фиксирует ее версию в одной транзакции
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.sql); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES ($1)", m.version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// schemaVersion возвращает номер последней примененной миграции или 0, если миграций еще не было
+func schemaVersion(db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	return version, err
+}
+
+// SchemaVersion представляет последнюю примененную миграцию; applied_at равен null,
+// если миграций еще не было
+type SchemaVersion struct {
+	Version   int        `json:"version"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+// handleSchemaVersion возвращает номер и время применения последней миграции, чтобы
+// инструменты развертывания могли проверить схему перед переключением трафика
+func (app *App) handleSchemaVersion(w http.ResponseWriter, r *http.Request) {
+	var (
+		result    SchemaVersion
+		appliedAt time.Time
+	)
+	err := app.DB.QueryRow(`
+		SELECT version, applied_at
+		FROM schema_migrations
+		ORDER BY version DESC
+		LIMIT 1`).Scan(&result.Version, &appliedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// Миграции еще не применялись: версия 0
+	case err != nil:
+		log.Printf("Error getting schema version: %v", err)
+		http.Error(w, "Failed to retrieve schema version", http.StatusInternalServerError)
+		return
+	default:
+		result.AppliedAt = &appliedAt
+	}
+
+	writeJSON(w, result)
+}
+
+// auxIndex описывает необязательный индекс для частых запросов
+type auxIndex struct {
+	name string
+	sql  string
+}
+
+// auxIndexes создаются только при CREATE_AUX_INDEXES=true, так как ускоряют чтение ценой
+// замедления вставок. Все определения идемпотентны
+var auxIndexes = []auxIndex{
+	{
+		// Порядок добавления: /numbers/rolling-stddev
+		name: "idx_numbers_created_at",
+		sql:  "CREATE INDEX IF NOT EXISTS idx_numbers_created_at ON numbers (created_at, id)",
+	},
+	{
+		// Фильтр четности: /numbers/query?parity=even
+		name: "idx_numbers_even_value",
+		sql:  "CREATE INDEX IF NOT EXISTS idx_numbers_even_value ON numbers (value) WHERE value % 2 = 0",
+	},
+	{
+		// Неотрицательные значения: /numbers/perfect-squares, /numbers/fibonacci
+		name: "idx_numbers_nonnegative_value",
+		sql:  "CREATE INDEX IF NOT EXISTS idx_numbers_nonnegative_value ON numbers (value) WHERE value >= 0",
+	},
+}
+
+// createAuxIndexes создает необязательные индексы; повторный вызов ничего не меняет
+func createAuxIndexes(db *sql.DB) error {
+	for _, idx := range auxIndexes {
+		if _, err := db.Exec(idx.sql); err != nil {
+			return fmt.Errorf("aux index %s: %w", idx.name, err)
+		}
+	}
+	return nil
+}
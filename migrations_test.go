@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestCreateAuxIndexesIdempotent тестирует повторное создание дополнительных индексов
+func TestCreateAuxIndexesIdempotent(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for i := 0; i < 2; i++ {
+		if err := createAuxIndexes(db); err != nil {
+			t.Fatalf("createAuxIndexes run %d failed: %v", i+1, err)
+		}
+	}
+
+	for _, idx := range auxIndexes {
+		var exists bool
+		err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_indexes WHERE tablename = 'numbers' AND indexname = $1)", idx.name).Scan(&exists)
+		if err != nil {
+			t.Fatalf("Failed to check index %s: %v", idx.name, err)
+		}
+		if !exists {
+			t.Errorf("Expected index %s to exist", idx.name)
+		}
+	}
+}
+
+// TestMigrateIdempotent тестирует, что повторный запуск миграций не меняет версию схемы
+func TestMigrateIdempotent(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := migrate(db); err != nil {
+		t.Fatalf("Second migrate failed: %v", err)
+	}
+
+	version, err := schemaVersion(db)
+	if err != nil {
+		t.Fatalf("Failed to get schema version: %v", err)
+	}
+	if version != latestSchemaVersion() {
+		t.Errorf("Expected schema version %d, got %d", latestSchemaVersion(), version)
+	}
+}
+
+// TestSchemaVersionEndpoint тестирует, что /schema/version возвращает последнюю миграцию
+func TestSchemaVersionEndpoint(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/schema/version", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result SchemaVersion
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Version != latestSchemaVersion() {
+		t.Errorf("Expected version %d, got %d", latestSchemaVersion(), result.Version)
+	}
+	if result.AppliedAt == nil {
+		t.Error("Expected applied_at to be set")
+	}
+}
+
+// TestSchemaVersionNoMigrations тестирует версию 0, пока миграции не применялись
+func TestSchemaVersionNoMigrations(t *testing.T) {
+	// Фиктивная база возвращает пустой результат
+	app := &App{DB: newFakeDB(t, &fakeConnector{})}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/schema/version", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if body := w.Body.String(); body != `{"version":0,"applied_at":null}`+"\n" {
+		t.Errorf("Unexpected response %q", body)
+	}
+}
+
+// benchmarkRows - размер таблицы для сравнения запросов с индексом по значению и без него
+const benchmarkRows = 200000
+
+// BenchmarkCountQueries сравнивает COUNT(*) и проверку существования с индексом idx_numbers_value
+// и без него. Для честного сравнения дополнительные индексы на время замера без индекса удаляются,
+// так как index-only scan для COUNT(*) может использовать любой индекс
+func BenchmarkCountQueries(b *testing.B) {
+	db, cleanup := setupTestDB(b)
+	defer cleanup()
+
+	if _, err := db.Exec("INSERT INTO numbers (value) SELECT g % 10000 FROM generate_series(1, $1) g", benchmarkRows); err != nil {
+		b.Fatalf("Failed to seed numbers: %v", err)
+	}
+	// VACUUM обновляет карту видимости, без которой index-only scan обращается к таблице
+	if _, err := db.Exec("VACUUM ANALYZE numbers"); err != nil {
+		b.Fatalf("Failed to vacuum numbers: %v", err)
+	}
+
+	queries := map[string]string{
+		"count":  "SELECT COUNT(*) FROM numbers",
+		"exists": "SELECT EXISTS (SELECT 1 FROM numbers WHERE value = 4242)",
+	}
+	run := func(b *testing.B) {
+		for name, query := range queries {
+			b.Run(name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					var result interface{}
+					if err := db.QueryRow(query).Scan(&result); err != nil {
+						b.Fatalf("Query failed: %v", err)
+					}
+				}
+			})
+		}
+	}
+
+	b.Run("with_index", run)
+
+	dropped := []string{"idx_numbers_value"}
+	for _, idx := range auxIndexes {
+		dropped = append(dropped, idx.name)
+	}
+	for _, name := range dropped {
+		if _, err := db.Exec(fmt.Sprintf("DROP INDEX IF EXISTS %s", name)); err != nil {
+			b.Fatalf("Failed to drop index %s: %v", name, err)
+		}
+	}
+	// Индекс по значению входит в схему, поэтому восстанавливается после замера
+	defer db.Exec("CREATE INDEX IF NOT EXISTS idx_numbers_value ON numbers (value)")
+
+	b.Run("without_index", run)
+}
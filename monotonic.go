@@ -0,0 +1,58 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// monotonicError сообщает, что в режиме MONOTONIC число не больше текущего максимума
+type monotonicError struct {
+	value int64
+	max   int64
+}
+
+func (e monotonicError) Error() string {
+	return fmt.Sprintf("value %d must be greater than the current maximum %d", e.value, e.max)
+}
+
+// insertMonotonic сохраняет числа с метками, только если каждое больше MAX(value) и всех
+// предыдущих чисел ns. Проверка и вставка выполняются в одной транзакции под блокировкой
+// таблицы, поэтому одновременные вставки не могут обойти друг друга. В пустую таблицу
+// принимается любое первое число
+func (app *App) insertMonotonic(ns []int64, ttl time.Duration, tags []string) error {
+	tx, err := app.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := app.insertMonotonicTx(tx, ns, ttl, tags); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertMonotonicTx выполняет проверку и вставку insertMonotonic в транзакции вызывающего.
+// Повторная блокировка в той же транзакции не ждет, а MAX(value) учитывает уже вставленные ею числа
+func (app *App) insertMonotonicTx(tx *sql.Tx, ns []int64, ttl time.Duration, tags []string) error {
+	// SHARE ROW EXCLUSIVE конфликтует сам с собой: второй писатель ждет фиксации первого
+	if _, err := tx.Exec("LOCK TABLE numbers IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return err
+	}
+
+	var max sql.NullInt64
+	if err := tx.QueryRow("SELECT MAX(value) FROM live_numbers").Scan(&max); err != nil {
+		return err
+	}
+	for _, n := range ns {
+		if max.Valid && n <= max.Int64 {
+			return monotonicError{value: n, max: max.Int64}
+		}
+		if _, err := app.insertNumberTx(tx, n, ttl, tags); err != nil {
+			return err
+		}
+		max = sql.NullInt64{Int64: n, Valid: true}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// monotonicConnector возвращает фиктивную базу с заданным MAX(value) и считает вставки;
+// nil означает пустую таблицу
+func monotonicConnector(max driver.Value, inserts *atomic.Int64) *fakeConnector {
+	return &fakeConnector{
+		exec: func(q string, args []driver.NamedValue) (driver.Result, error) {
+			return driver.RowsAffected(1), nil
+		},
+		query: func(q string, args []driver.NamedValue) (*fakeRows, error) {
+			if strings.HasPrefix(q, "INSERT") {
+				inserts.Add(1)
+				return &fakeRows{columns: []string{"id"}, values: [][]driver.Value{{int64(1)}}}, nil
+			}
+			if strings.Contains(q, "MAX(value)") {
+				return &fakeRows{columns: []string{"max"}, values: [][]driver.Value{{max}}}, nil
+			}
+			return &fakeRows{columns: []string{"value"}}, nil
+		},
+	}
+}
+
+// TestMonotonicInsert тестирует, что в режиме MONOTONIC принимаются только числа больше максимума
+func TestMonotonicInsert(t *testing.T) {
+	tests := []struct {
+		name   string
+		max    driver.Value
+		number string
+		want   int
+	}{
+		{"increasing", int64(10), "11", http.StatusOK},
+		{"equal", int64(10), "10", http.StatusConflict},
+		{"smaller", int64(10), "-5", http.StatusConflict},
+		{"empty table", nil, "-100", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inserts atomic.Int64
+			app := &App{DB: newFakeDB(t, monotonicConnector(tt.max, &inserts)), Config: Config{Monotonic: true}}
+
+			w := httptest.NewRecorder()
+			app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers?number="+tt.number, nil))
+
+			if w.Code != tt.want {
+				t.Fatalf("Expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+			wantInserts := int64(0)
+			if tt.want == http.StatusOK {
+				wantInserts = 1
+			}
+			if got := inserts.Load(); got != wantInserts {
+				t.Errorf("Expected %d inserts, got %d", wantInserts, got)
+			}
+		})
+	}
+}
+
+// TestMonotonicInsertSeveral тестирует, что несколько чисел в режиме MONOTONIC должны возрастать
+// и откатываются вместе, если одно из них не больше предыдущего
+func TestMonotonicInsertSeveral(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.Exec("DELETE FROM numbers")
+	app := &App{DB: db, Config: Config{Monotonic: true}}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers?number=1,5,7", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers?number=8,10,9", nil))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+	}
+
+	numbers, err := app.getAllNumbers()
+	if err != nil || len(numbers) != 3 || numbers[2] != 7 {
+		t.Errorf("Expected [1 5 7] after the rolled back insert, got %v (%v)", numbers, err)
+	}
+}
+
+// TestMonotonicBatch тестирует, что пакетная вставка в режиме MONOTONIC отклоняет часть со
+// значением не больше максимума или предыдущего значения с 409
+func TestMonotonicBatch(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want BatchResult
+	}{
+		{"below maximum", "[11, 12, 5, 13]", BatchResult{Inserted: 2, Failed: 2, Chunks: 1,
+			Error: "Chunk starting at index 2: value 5 must be greater than the current maximum 10"}},
+		{"repeated", "[11, 12, 13, 13]", BatchResult{Inserted: 2, Failed: 2, Chunks: 1,
+			Error: "Chunk starting at index 2: value 13 must be greater than the current maximum 13"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inserts atomic.Int64
+			app := &App{DB: newFakeDB(t, monotonicConnector(int64(10), &inserts)), Config: Config{Monotonic: true, BatchChunkSize: 2}}
+
+			w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodPost, "/numbers/batch", strings.NewReader(tt.body)))
+			if w.Code != http.StatusConflict {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+			}
+			var result BatchResult
+			if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if result != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, result)
+			}
+		})
+	}
+}
+
+// TestMonotonicImport тестирует, что импорт CSV в режиме MONOTONIC прерывается с 409 на части
+// с невозрастающим значением
+func TestMonotonicImport(t *testing.T) {
+	var inserts atomic.Int64
+	app := &App{DB: newFakeDB(t, monotonicConnector(int64(10), &inserts)), Config: Config{Monotonic: true, BatchChunkSize: 2}}
+
+	w := serveChecked(t, app.routes(), importRequest(t, "file", "11\n12\n13\n7\n14\n"))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+	}
+	result := decodeImportResult(t, w)
+	expected := "Values up to line 4: value 7 must be greater than the current maximum 13"
+	if result.Inserted != 2 || result.Failed != 2 || result.Error != expected {
+		t.Errorf("Expected 2 inserted and the chunk ending at line 4 rejected, got %+v", result)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// mediaEncoder сериализует ответ в одном из поддерживаемых форматов. Форматы с фиксированной
+// схемой (listsOnly) умеют сериализовать только списки NumbersResponse и RecordsResponse
+type mediaEncoder struct {
+	contentType string
+	encode      func(w io.Writer, v interface{}) error
+	listsOnly   bool
+}
+
+// mediaEncoders - реестр форматов ответа для заголовка Accept. Первый элемент используется
+// по умолчанию, когда Accept не задан или допускает любой формат
+var mediaEncoders = []mediaEncoder{
+	{contentType: "application/json", encode: func(w io.Writer, v interface{}) error {
+		return json.NewEncoder(w).Encode(v)
+	}},
+	{contentType: "application/msgpack", encode: encodeMsgpack},
+	{contentType: "text/csv", encode: encodeCSV, listsOnly: true},
+	{contentType: "application/xml", encode: encodeXML, listsOnly: true},
+	{contentType: protobufContentType, encode: encodeProtobuf, listsOnly: true},
+}
+
+// encodeMsgpack кодирует значение в MessagePack с теми же именами полей, что и в JSON
+func encodeMsgpack(w io.Writer, v interface{}) error {
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	return enc.Encode(v)
+}
+
+// encodeCSV кодирует список в CSV со строкой заголовка: столбец value для чисел и столбцы
+// id, value, created_at для записей, как в /numbers/export?format=csv
+func encodeCSV(w io.Writer, v interface{}) error {
+	cw := csv.NewWriter(w)
+	switch v := v.(type) {
+	case NumbersResponse:
+		cw.Write([]string{"value"})
+		for _, n := range v.Numbers {
+			cw.Write([]string{strconv.FormatInt(n, 10)})
+		}
+	case RecordsResponse:
+		cw.Write([]string{"id", "value", "created_at"})
+		for _, rec := range v.Numbers {
+			createdAt := ""
+			if rec.CreatedAt != nil {
+				createdAt = rec.CreatedAt.UTC().Format(time.RFC3339Nano)
+			}
+			cw.Write([]string{strconv.FormatInt(rec.ID, 10), strconv.FormatInt(rec.Value, 10), createdAt})
+		}
+	default:
+		return fmt.Errorf("unsupported CSV value %T", v)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// xmlNumbers представляет список чисел в XML: <numbers><number>1</number>...</numbers>
+type xmlNumbers struct {
+	XMLName xml.Name `xml:"numbers"`
+	Numbers []int64  `xml:"number"`
+}
+
+// xmlRecords представляет список записей в XML: id и время добавления передаются атрибутами
+// элемента number, значение - его текстом
+type xmlRecords struct {
+	XMLName xml.Name    `xml:"numbers"`
+	Records []xmlRecord `xml:"number"`
+}
+
+type xmlRecord struct {
+	ID        int64      `xml:"id,attr"`
+	CreatedAt *time.Time `xml:"created_at,attr,omitempty"`
+	Value     int64      `xml:",chardata"`
+}
+
+// encodeXML кодирует список чисел или записей в XML документ
+func encodeXML(w io.Writer, v interface{}) error {
+	var doc interface{}
+	switch v := v.(type) {
+	case NumbersResponse:
+		doc = xmlNumbers{Numbers: v.Numbers}
+	case RecordsResponse:
+		records := make([]xmlRecord, len(v.Numbers))
+		for i, rec := range v.Numbers {
+			records[i] = xmlRecord{ID: rec.ID, CreatedAt: rec.CreatedAt, Value: rec.Value}
+		}
+		doc = xmlRecords{Records: records}
+	default:
+		return fmt.Errorf("unsupported XML value %T", v)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(doc)
+}
+
+// negotiate выбирает формат ответа по заголовку Accept с учетом весов q.
+// Возвращает false, если ни один из поддерживаемых форматов не допустим
+func negotiate(r *http.Request) (mediaEncoder, bool) {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return mediaEncoders[0], true
+	}
+
+	var (
+		best   mediaEncoder
+		bestQ  float64
+		chosen bool
+	)
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		for _, enc := range mediaEncoders {
+			if mediaMatches(mediaType, enc.contentType) && q > bestQ {
+				best, bestQ, chosen = enc, q, true
+				break
+			}
+		}
+	}
+	return best, chosen
+}
+
+// mediaMatches проверяет, подходит ли тип из Accept (в том числе */* и type/*) под формат
+func mediaMatches(pattern, contentType string) bool {
+	if pattern == "*/*" || pattern == contentType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(pattern, "/*")
+	return ok && strings.HasPrefix(contentType, prefix+"/")
+}
+
+// writeNegotiated отправляет значение в формате, выбранном negotiate
+func writeNegotiated(w http.ResponseWriter, enc mediaEncoder, v interface{}) {
+	checkResponse(v)
+	w.Header().Set("Content-Type", enc.contentType)
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(http.StatusOK)
+	if err := enc.encode(w, v); err != nil {
+		log.Printf("Error encoding %s response: %v", enc.contentType, err)
+	}
+}
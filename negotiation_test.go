@@ -0,0 +1,124 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// TestGetNumbersMsgpack тестирует ответ в MessagePack по заголовку Accept
+func TestGetNumbersMsgpack(t *testing.T) {
+	fc := &fakeConnector{
+		query: func(query string, args []driver.NamedValue) (*fakeRows, error) {
+			return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{int64(1)}, {int64(2)}, {int64(3)}}}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	req := httptest.NewRequest(http.MethodGet, "/numbers", nil)
+	req.Header.Set("Accept", "application/msgpack")
+	w := httptest.NewRecorder()
+
+	app.routes().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/msgpack" {
+		t.Errorf("Expected Content-Type application/msgpack, got %q", ct)
+	}
+
+	var decoded map[string][]int64
+	if err := msgpack.Unmarshal(w.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("Failed to decode MessagePack: %v", err)
+	}
+	if !reflect.DeepEqual(decoded["numbers"], []int64{1, 2, 3}) {
+		t.Errorf("Expected numbers [1 2 3], got %v", decoded)
+	}
+}
+
+// TestNegotiate тестирует выбор формата по заголовку Accept
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"application/msgpack", "application/msgpack"},
+		{"application/json;q=0.5, application/msgpack", "application/msgpack"},
+		{"application/msgpack;q=0.1, application/*;q=0.9", "application/json"},
+		{"text/csv", "text/csv"},
+		{"text/*", "text/csv"},
+		{"application/xml;q=0.9, application/json;q=0.8", "application/xml"},
+		{"text/html", ""},
+		{"application/msgpack;q=0", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/numbers", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		enc, ok := negotiate(req)
+		if got := enc.contentType; ok != (tt.want != "") || got != tt.want {
+			t.Errorf("Accept %q: expected %q, got %q (ok=%v)", tt.accept, tt.want, got, ok)
+		}
+	}
+}
+
+// TestGetNumbersTabular тестирует ответы GET /numbers в CSV и XML для чисел и записей
+func TestGetNumbersTabular(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	fc := &fakeConnector{
+		query: func(query string, args []driver.NamedValue) (*fakeRows, error) {
+			if strings.HasPrefix(query, "SELECT value, id, created_at") {
+				return &fakeRows{
+					columns: []string{"value", "id", "created_at"},
+					values:  [][]driver.Value{{int64(-2), int64(7), createdAt}, {int64(5), int64(3), nil}},
+				}, nil
+			}
+			return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{int64(-2)}, {int64(5)}}}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	tests := []struct {
+		target, accept, want string
+	}{
+		{"/numbers", "text/csv", "value\n-2\n5\n"},
+		{"/numbers?verbose=true", "text/csv", "id,value,created_at\n7,-2,2024-01-01T10:00:00Z\n3,5,\n"},
+		{"/numbers", "application/xml", `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+			`<numbers><number>-2</number><number>5</number></numbers>`},
+		{"/numbers?verbose=true", "application/xml", `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+			`<numbers><number id="7" created_at="2024-01-01T10:00:00Z">-2</number><number id="3">5</number></numbers>`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		req.Header.Set("Accept", tt.accept)
+		w := serveChecked(t, app.routes(), req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s as %s: expected status %d, got %d", tt.target, tt.accept, http.StatusOK, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != tt.accept {
+			t.Errorf("%s as %s: unexpected Content-Type %q", tt.target, tt.accept, ct)
+		}
+		if w.Body.String() != tt.want {
+			t.Errorf("%s as %s: expected %q, got %q", tt.target, tt.accept, tt.want, w.Body.String())
+		}
+	}
+
+	// Альтернативные представления format= не табличные
+	req := httptest.NewRequest(http.MethodGet, "/numbers?format=rle", nil)
+	req.Header.Set("Accept", "text/csv")
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, req)
+	if w.Code != http.StatusNotAcceptable {
+		t.Errorf("Expected status %d for format=rle as CSV, got %d", http.StatusNotAcceptable, w.Code)
+	}
+}
@@ -0,0 +1,5 @@
+// Package numberspb содержит сгенерированные protobuf сообщения и gRPC сервис Numbers.
+// Код генерируется из proto/numbers.proto командой go generate
+package numberspb
+
+//go:generate protoc -I ../proto --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative numbers.proto
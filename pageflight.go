@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// pageFlight объединяет одновременные одинаковые запросы страниц keyset-пагинации: пока
+// страница с тем же курсором, limit и фильтрами читается из базы, остальные запросы ждут
+// ее результат, а не выполняют такой же запрос. Готовые страницы не хранятся
+type pageFlight struct {
+	mu    sync.Mutex
+	calls map[string]*pageCall
+}
+
+// pageCall - выполняющийся запрос страницы и его результат для ожидающих
+type pageCall struct {
+	numbers []int64
+	next    *pageCursor
+	err     error
+	done    chan struct{}
+}
+
+// newPageFlight создает объединение без выполняющихся запросов
+func newPageFlight() *pageFlight {
+	return &pageFlight{calls: make(map[string]*pageCall)}
+}
+
+// pageKey строит ключ страницы из текста запроса и его аргументов, в которые входят курсор,
+// limit и все фильтры
+func pageKey(query string, args []interface{}) string {
+	return fmt.Sprintf("%s %v", query, args)
+}
+
+// do выполняет fetch или ждет результат уже начатого запроса с тем же ключом. Результат общий
+// для всех ожидающих и не должен изменяться. Для nil fetch выполняется без объединения
+func (p *pageFlight) do(key string, fetch func() ([]int64, *pageCursor, error)) ([]int64, *pageCursor, error) {
+	if p == nil {
+		return fetch()
+	}
+
+	p.mu.Lock()
+	if c, ok := p.calls[key]; ok {
+		p.mu.Unlock()
+		<-c.done
+		return c.numbers, c.next, c.err
+	}
+	c := &pageCall{done: make(chan struct{})}
+	p.calls[key] = c
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.calls, key)
+		p.mu.Unlock()
+		close(c.done)
+	}()
+	c.numbers, c.next, c.err = fetch()
+	return c.numbers, c.next, c.err
+}
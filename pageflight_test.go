@@ -0,0 +1,99 @@
+package main
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestPageFlightConcurrent тестирует, что одновременные запросы одной страницы выполняют
+// один запрос к базе и получают общий результат, а другая страница читается отдельно
+func TestPageFlightConcurrent(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	fc := &fakeConnector{
+		query: func(query string, args []driver.NamedValue) (*fakeRows, error) {
+			started <- struct{}{}
+			<-release
+			return &fakeRows{
+				columns: []string{"value", "id"},
+				values:  [][]driver.Value{{int64(5), int64(1)}, {int64(8), int64(2)}},
+			}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc), Pages: newPageFlight()}
+	f := numberFilter{Limit: 2, Keyset: true, After: &pageCursor{Value: 3, ID: 9}}
+
+	const callers = 5
+	type page struct {
+		numbers []int64
+		next    *pageCursor
+		err     error
+	}
+	results := make([]page, callers)
+	var wg sync.WaitGroup
+	call := func(i int) {
+		defer wg.Done()
+		numbers, next, err := app.queryKeysetPage(f)
+		results[i] = page{numbers, next, err}
+	}
+
+	// Первый запрос занимает страницу, остальные подключаются, пока он ждет базу
+	wg.Add(callers)
+	go call(0)
+	<-started
+	for i := 1; i < callers; i++ {
+		go call(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := fc.queries.Load(); n != 1 {
+		t.Errorf("Expected 1 query for %d concurrent callers, got %d", callers, n)
+	}
+	for i, p := range results {
+		if p.err != nil {
+			t.Fatalf("Caller %d: unexpected error: %v", i, p.err)
+		}
+		if !reflect.DeepEqual(p.numbers, []int64{5, 8}) || p.next == nil || *p.next != (pageCursor{Value: 8, ID: 2}) {
+			t.Errorf("Caller %d: expected [5 8] with cursor (8, 2), got %v %v", i, p.numbers, p.next)
+		}
+	}
+
+	// Другой курсор - другая страница: запрос не объединяется с завершенным
+	f.After = &pageCursor{Value: 8, ID: 2}
+	if _, _, err := app.queryKeysetPage(f); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n := fc.queries.Load(); n != 2 {
+		t.Errorf("Expected a separate query for another cursor, got %d queries", n)
+	}
+}
+
+// TestPageKey тестирует, что ключ страницы различает курсор, limit и фильтры
+func TestPageKey(t *testing.T) {
+	min := int64(10)
+	base := numberFilter{Limit: 2, Keyset: true, After: &pageCursor{Value: 3, ID: 9}}
+	variants := []numberFilter{
+		{Limit: 3, Keyset: true, After: &pageCursor{Value: 3, ID: 9}},
+		{Limit: 2, Keyset: true, After: &pageCursor{Value: 3, ID: 10}},
+		{Limit: 2, Keyset: true, After: &pageCursor{Value: 3, ID: 9}, Min: &min},
+		{Limit: 2, Keyset: true, After: &pageCursor{Value: 3, ID: 9}, Parity: "even"},
+		{Limit: 2, Keyset: true, After: &pageCursor{Value: 3, ID: 9}, Desc: true},
+	}
+
+	key := pageKey(base.keysetSQL())
+	same := base
+	same.After = &pageCursor{Value: 3, ID: 9}
+	if pageKey(same.keysetSQL()) != key {
+		t.Errorf("Expected equal filters to share a key")
+	}
+	for _, v := range variants {
+		if pageKey(v.keysetSQL()) == key {
+			t.Errorf("Expected filter %+v to have its own key", v)
+		}
+	}
+}
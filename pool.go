@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// poolGate ограничивает число запросов, одновременно работающих с базой данных, размером
+// пула соединений. Запрос, не получивший место за отведенное время, завершается 503,
+// а не блокируется бесконечно в ожидании свободного соединения sql.DB
+type poolGate struct {
+	slots   chan struct{}
+	timeout time.Duration
+}
+
+// newPoolGate создает ограничитель на size одновременных запросов
+func newPoolGate(size int, timeout time.Duration) *poolGate {
+	return &poolGate{slots: make(chan struct{}, size), timeout: timeout}
+}
+
+// acquire занимает место в пуле до истечения контекста; возвращает false по таймауту
+func (g *poolGate) acquire(ctx context.Context) bool {
+	select {
+	case g.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// release освобождает место в пуле
+func (g *poolGate) release() {
+	<-g.slots
+}
+
+// limitPool пропускает запрос к обработчику только после получения места в пуле соединений.
+// Место в ограничителе не гарантирует свободное соединение: его могут занимать фоновые задачи
+// и запросы мимо ограничителя, поэтому соединение sql.DB ожидается с тем же сроком
+// POOL_WAIT_TIMEOUT и сразу возвращается в пул, откуда его берет обработчик. /health и
+// /numbers/stream пропускаются без ожидания, как и все запросы, если ограничитель не настроен
+func (app *App) limitPool(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g := app.PoolGate
+		// Поток /numbers/stream не обращается к базе, но держит запрос открытым часами, а
+		// проверка здоровья должна отвечать и при исчерпанном пуле
+		if g == nil || r.URL.Path == "/numbers/stream" || r.URL.Path == "/health" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		reject := func() {
+			log.Printf("Database pool exhausted, rejecting %s %s after %s", r.Method, r.URL.Path, g.timeout)
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(g.timeout)))
+			http.Error(w, "Database is busy, try again later", http.StatusServiceUnavailable)
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), g.timeout)
+		defer cancel()
+		if !g.acquire(ctx) {
+			reject()
+			return
+		}
+		defer g.release()
+
+		// Прочие ошибки соединения сообщает сам обработчик
+		conn, err := app.DB.Conn(ctx)
+		if err != nil && ctx.Err() != nil {
+			reject()
+			return
+		}
+		if err == nil {
+			conn.Close()
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// retryAfterSeconds округляет длительность вверх до целых секунд (не меньше одной)
+func retryAfterSeconds(d time.Duration) int {
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
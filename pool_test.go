@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestPoolGateTimeout тестирует ответ 503 с Retry-After, когда все соединения пула заняты
+func TestPoolGateTimeout(t *testing.T) {
+	app := &App{DB: newFakeDB(t, &fakeConnector{}), PoolGate: newPoolGate(1, 20*time.Millisecond)}
+	handler := app.routes()
+
+	// Единственное место в пуле занято долгим запросом
+	if !app.PoolGate.acquire(context.Background()) {
+		t.Fatal("Failed to saturate the pool")
+	}
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if w.Header().Get("Retry-After") != "1" {
+		t.Errorf("Expected Retry-After 1, got %q", w.Header().Get("Retry-After"))
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected fast failure, waited %s", elapsed)
+	}
+
+	// После освобождения места запрос обслуживается
+	app.PoolGate.release()
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d after release, got %d", http.StatusOK, w.Code)
+	}
+}
+
+// TestPoolGateHealth тестирует, что /health отвечает, когда все места в пуле заняты
+func TestPoolGateHealth(t *testing.T) {
+	app := &App{DB: newFakeDB(t, &fakeConnector{}), PoolGate: newPoolGate(1, 20*time.Millisecond)}
+	if !app.PoolGate.acquire(context.Background()) {
+		t.Fatal("Failed to saturate the pool")
+	}
+	defer app.PoolGate.release()
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+}
+
+// TestPoolGateConnectionTimeout тестирует 503 по истечении POOL_WAIT_TIMEOUT, когда место в
+// ограничителе свободно, но все соединения sql.DB заняты запросами мимо него
+func TestPoolGateConnectionTimeout(t *testing.T) {
+	db := newFakeDB(t, &fakeConnector{})
+	db.SetMaxOpenConns(1)
+	app := &App{DB: db, PoolGate: newPoolGate(1, 20*time.Millisecond)}
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to take the only connection: %v", err)
+	}
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers", nil))
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected status %d with Retry-After, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected fast failure, waited %s", elapsed)
+	}
+
+	// Место в ограничителе освобождено вместе с отказом
+	conn.Close()
+	w = httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d after the connection is returned, got %d", http.StatusOK, w.Code)
+	}
+}
+
+// TestRetryAfterSeconds тестирует округление длительности вверх до секунд
+func TestRetryAfterSeconds(t *testing.T) {
+	tests := map[time.Duration]int{
+		0:                       1,
+		20 * time.Millisecond:   1,
+		time.Second:             1,
+		1500 * time.Millisecond: 2,
+	}
+	for d, expected := range tests {
+		if got := retryAfterSeconds(d); got != expected {
+			t.Errorf("retryAfterSeconds(%s) = %d, expected %d", d, got, expected)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"numbers-service/numberspb"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// protobufContentType - тип тела запросов и ответов в формате Protocol Buffers по схеме
+// proto/numbers.proto
+const protobufContentType = "application/x-protobuf"
+
+// parseProtobufRequest читает тело numbers.v1.NumberRequest. Срок жизни и метки в схеме
+// не передаются
+func parseProtobufRequest(r *http.Request) (NumberRequest, error) {
+	var req NumberRequest
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return req, errors.New("Failed to read request body")
+	}
+	var msg numberspb.NumberRequest
+	if err := proto.Unmarshal(body, &msg); err != nil {
+		return req, errors.New("Invalid protobuf body")
+	}
+	req.Number = msg.GetNumber()
+	return req, nil
+}
+
+// encodeProtobuf кодирует список чисел в numbers.v1.NumbersResponse, а список записей -
+// в numbers.v1.RecordsResponse. Признак added режима UNIQUE_NUMBERS в схеме не передается
+func encodeProtobuf(w io.Writer, v interface{}) error {
+	var msg proto.Message
+	switch v := v.(type) {
+	case NumbersResponse:
+		msg = &numberspb.NumbersResponse{Numbers: v.Numbers}
+	case RecordsResponse:
+		records := make([]*numberspb.NumberRecord, len(v.Numbers))
+		for i, rec := range v.Numbers {
+			records[i] = &numberspb.NumberRecord{Id: rec.ID, Value: rec.Value}
+			if rec.CreatedAt != nil {
+				records[i].CreatedAt = timestamppb.New(*rec.CreatedAt)
+			}
+		}
+		msg = &numberspb.RecordsResponse{Numbers: records}
+	default:
+		return fmt.Errorf("unsupported protobuf value %T", v)
+	}
+
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"numbers-service/numberspb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// TestAddNumberProtobuf тестирует POST /numbers с телом и ответом application/x-protobuf
+func TestAddNumberProtobuf(t *testing.T) {
+	var inserted atomic.Value
+	fc := &fakeConnector{
+		exec: func(q string, args []driver.NamedValue) (driver.Result, error) {
+			if strings.HasPrefix(q, "INSERT INTO numbers") {
+				inserted.Store(args[0].Value)
+			}
+			return driver.RowsAffected(1), nil
+		},
+		query: func(q string, args []driver.NamedValue) (*fakeRows, error) {
+			if strings.HasPrefix(q, "INSERT INTO numbers") {
+				inserted.Store(args[0].Value)
+				return &fakeRows{columns: []string{"id"}, values: [][]driver.Value{{int64(1)}}}, nil
+			}
+			return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{int64(-4)}, {int64(1) << 40}}}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	body, err := proto.Marshal(&numberspb.NumberRequest{Number: 1 << 40})
+	if err != nil {
+		t.Fatalf("Failed to marshal request: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/numbers", bytes.NewReader(body))
+	req.Header.Set("Content-Type", protobufContentType)
+	req.Header.Set("Accept", protobufContentType)
+	w := serveChecked(t, app.routes(), req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if got := inserted.Load(); got != int64(1)<<40 {
+		t.Errorf("Expected %d to be inserted, got %v", int64(1)<<40, got)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != protobufContentType {
+		t.Errorf("Expected Content-Type %s, got %q", protobufContentType, ct)
+	}
+	var resp numberspb.NumbersResponse
+	if err := proto.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if !reflect.DeepEqual(resp.GetNumbers(), []int64{-4, 1 << 40}) {
+		t.Errorf("Expected [-4 %d], got %v", int64(1)<<40, resp.GetNumbers())
+	}
+
+	// Без Accept ответ остается JSON
+	req = httptest.NewRequest(http.MethodPost, "/numbers", bytes.NewReader(body))
+	req.Header.Set("Content-Type", protobufContentType)
+	w = serveChecked(t, app.routes(), req)
+	if ct := w.Header().Get("Content-Type"); w.Code != http.StatusOK || ct != "application/json" {
+		t.Errorf("Expected a JSON response, got %d %q", w.Code, ct)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/numbers", strings.NewReader("\xff\xff"))
+	req.Header.Set("Content-Type", protobufContentType)
+	w = httptest.NewRecorder()
+	app.routes().ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || strings.TrimSpace(w.Body.String()) != "Invalid protobuf body" {
+		t.Errorf("Expected status %d for a malformed body, got %d %q", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+// TestGetNumbersProtobuf тестирует GET /numbers с Accept: application/x-protobuf
+func TestGetNumbersProtobuf(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	fc := &fakeConnector{
+		query: func(query string, args []driver.NamedValue) (*fakeRows, error) {
+			if strings.HasPrefix(query, "SELECT value, id, created_at") {
+				return &fakeRows{
+					columns: []string{"value", "id", "created_at"},
+					values:  [][]driver.Value{{int64(-2), int64(7), createdAt}, {int64(5), int64(3), nil}},
+				}, nil
+			}
+			return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{int64(-2)}, {int64(5)}}}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	get := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Accept", protobufContentType)
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/numbers")
+	var numbers numberspb.NumbersResponse
+	if err := proto.Unmarshal(w.Body.Bytes(), &numbers); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Expected a protobuf list, got %d (%v)", w.Code, err)
+	}
+	if !reflect.DeepEqual(numbers.GetNumbers(), []int64{-2, 5}) {
+		t.Errorf("Expected [-2 5], got %v", numbers.GetNumbers())
+	}
+
+	w = get("/numbers?verbose=true")
+	var records numberspb.RecordsResponse
+	if err := proto.Unmarshal(w.Body.Bytes(), &records); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Expected protobuf records, got %d (%v)", w.Code, err)
+	}
+	if len(records.GetNumbers()) != 2 {
+		t.Fatalf("Expected 2 records, got %v", records.GetNumbers())
+	}
+	first, second := records.GetNumbers()[0], records.GetNumbers()[1]
+	if first.GetId() != 7 || first.GetValue() != -2 || !first.GetCreatedAt().AsTime().Equal(createdAt) {
+		t.Errorf("Unexpected first record %v", first)
+	}
+	if second.GetId() != 3 || second.GetValue() != 5 || second.GetCreatedAt() != nil {
+		t.Errorf("Unexpected second record %v", second)
+	}
+
+	if w := get("/numbers?format=rle"); w.Code != http.StatusNotAcceptable {
+		t.Errorf("Expected status %d for format=rle as protobuf, got %d", http.StatusNotAcceptable, w.Code)
+	}
+}
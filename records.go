@@ -0,0 +1,301 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NumberRecord представляет отдельную запись таблицы чисел. Version увеличивается при каждом
+// изменении значения и передается в ETag для условного PATCH
+type NumberRecord struct {
+	ID        int64      `json:"id"`
+	Value     int64      `json:"value"`
+	CreatedAt *time.Time `json:"created_at"`
+	Version   int        `json:"version,omitempty"`
+}
+
+// RecordsResponse представляет страницу записей GET /numbers?verbose=true
+type RecordsResponse struct {
+	Numbers []NumberRecord `json:"numbers"`
+}
+
+// recordColumns - столбцы записи, которые выбирает numberFilter в режиме Verbose; порядок
+// совпадает с keyset-запросом, чтобы курсор брался из тех же столбцов
+const recordColumns = "value, id, created_at"
+
+// NumberPatch представляет запрос PATCH /numbers/{id}. Ожидаемую версию записи можно передать
+// полем version вместо заголовка If-Match
+type NumberPatch struct {
+	Value   *int64 `json:"value"`
+	Version *int   `json:"version"`
+}
+
+// handleNumberByID обрабатывает запросы к отдельной записи /numbers/{id}. Зарегистрирован на
+// поддерево /numbers/, поэтому эндпоинты с фиксированным путем имеют приоритет, а путь, который
+// не является положительным целым id, получает 404
+func (app *App) handleNumberByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/numbers/"), 10, 32)
+	if err != nil || id <= 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		app.getNumber(w, r, id)
+	case http.MethodPatch:
+		app.patchNumber(w, r, id)
+	case http.MethodDelete:
+		app.deleteNumber(w, r, id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// queryRecordPage возвращает страницу записей для GET /numbers?verbose=true и, при
+// keyset-пагинации, курсор следующей страницы (nil, если страница неполная)
+func (app *App) queryRecordPage(f numberFilter) ([]NumberRecord, *pageCursor, error) {
+	query, args := f.sql()
+	if f.Keyset {
+		query, args = f.keysetSQL()
+	}
+	rows, err := app.DB.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	records := []NumberRecord{}
+	for rows.Next() {
+		var (
+			record    NumberRecord
+			createdAt sql.NullTime
+		)
+		if err := rows.Scan(&record.Value, &record.ID, &createdAt); err != nil {
+			return nil, nil, err
+		}
+		if createdAt.Valid {
+			record.CreatedAt = &createdAt.Time
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if !f.Keyset || len(records) < f.Limit {
+		return records, nil, nil
+	}
+	last := records[len(records)-1]
+	return records, &pageCursor{Value: last.Value, ID: last.ID}, nil
+}
+
+// getNumber возвращает запись по id; 404, если записи нет или ее срок жизни истек
+func (app *App) getNumber(w http.ResponseWriter, r *http.Request, id int64) {
+	var (
+		record    NumberRecord
+		createdAt sql.NullTime
+	)
+	err := app.DB.QueryRow("SELECT id, value, created_at, version FROM live_numbers WHERE id = $1", id).
+		Scan(&record.ID, &record.Value, &createdAt, &record.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Number not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error getting number %d: %v", id, err)
+		http.Error(w, "Failed to retrieve number", http.StatusInternalServerError)
+		return
+	}
+	if createdAt.Valid {
+		record.CreatedAt = &createdAt.Time
+	}
+
+	w.Header().Set("ETag", versionETag(record.Version))
+	writeJSON(w, record)
+}
+
+// patchNumber изменяет значение записи с оптимистичной блокировкой: ожидаемая версия из
+// If-Match (или поля version) сравнивается с текущей в том же UPDATE, поэтому из двух
+// одновременных изменений одной версии применяется только одно. Без версии запрос отклоняется
+// с 428, при устаревшей версии возвращается 412 с текущим ETag. If-Match: * изменяет запись
+// без проверки версии
+func (app *App) patchNumber(w http.ResponseWriter, r *http.Request, id int64) {
+	var req NumberPatch
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Value == nil {
+		http.Error(w, "value is required", http.StatusBadRequest)
+		return
+	}
+
+	version, wildcard, err := expectedVersion(r.Header.Get("If-Match"), req.Version)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if version == nil && !wildcard {
+		http.Error(w, "If-Match header or version is required", http.StatusPreconditionRequired)
+		return
+	}
+
+	// Изменение значения нарушило бы возрастающий порядок вставок
+	if app.Config.Monotonic {
+		http.Error(w, "Numbers cannot be changed in MONOTONIC mode", http.StatusConflict)
+		return
+	}
+	if err := app.validateNumber(*req.Value); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var (
+		record    NumberRecord
+		createdAt sql.NullTime
+	)
+	err = app.withRetry(func() error {
+		return app.DB.QueryRow(`
+			UPDATE numbers SET value = $2, version = version + 1
+			WHERE id = $1 AND ($3::int IS NULL OR version = $3) AND (expires_at IS NULL OR expires_at > now())
+			RETURNING id, value, created_at, version`, id, *req.Value, version).
+			Scan(&record.ID, &record.Value, &createdAt, &record.Version)
+	})
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		app.writePatchMiss(w, id)
+		return
+	case isUniqueViolation(err):
+		http.Error(w, fmt.Sprintf("value %d is already stored", *req.Value), http.StatusConflict)
+		return
+	case isReadOnlyError(err):
+		log.Printf("Error updating number %d: database is read-only: %v", id, maskError(err))
+		w.Header().Set("Retry-After", strconv.Itoa(app.readOnlyRetryAfter()))
+		http.Error(w, "Database is read-only, try again later", http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		log.Printf("Error updating number %d: %v", id, maskError(err))
+		http.Error(w, "Failed to update number", http.StatusInternalServerError)
+		return
+	}
+	if createdAt.Valid {
+		record.CreatedAt = &createdAt.Time
+	}
+
+	// Данные изменились: кэшированные ответы больше не актуальны, обновляется Last-Modified
+	app.dataChanged()
+	w.Header().Set("ETag", versionETag(record.Version))
+	writeJSON(w, record)
+}
+
+// writePatchMiss отвечает на PATCH, не изменивший ни одной строки: 404, если записи нет,
+// и 412 с текущим ETag, если версия устарела
+func (app *App) writePatchMiss(w http.ResponseWriter, id int64) {
+	var current int
+	err := app.DB.QueryRow("SELECT version FROM live_numbers WHERE id = $1", id).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Number not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error getting number %d version: %v", id, err)
+		http.Error(w, "Failed to update number", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", versionETag(current))
+	http.Error(w, "Number was modified, fetch the current version and retry", http.StatusPreconditionFailed)
+}
+
+// versionETag возвращает сильный ETag версии записи
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// expectedVersion разбирает ожидаемую версию из заголовка If-Match или поля version.
+// Возвращает wildcard = true для If-Match: *
+func expectedVersion(ifMatch string, field *int) (version *int, wildcard bool, err error) {
+	if ifMatch == "" {
+		return field, false, nil
+	}
+	if field != nil {
+		return nil, false, fmt.Errorf("If-Match and version cannot be combined")
+	}
+	if ifMatch == "*" {
+		return nil, true, nil
+	}
+
+	v, err := strconv.Atoi(strings.Trim(ifMatch, `"`))
+	if err != nil || !strings.HasPrefix(ifMatch, `"`) || !strings.HasSuffix(ifMatch, `"`) {
+		return nil, false, fmt.Errorf("If-Match must be an ETag from GET /numbers/{id}")
+	}
+	return &v, false, nil
+}
+
+// deleteNumber удаляет запись по id; 404, если записи нет или ее срок жизни истек
+func (app *App) deleteNumber(w http.ResponseWriter, r *http.Request, id int64) {
+	var deleted int64
+	err := app.withRetry(func() error {
+		res, err := app.DB.Exec("DELETE FROM numbers WHERE id = $1 AND (expires_at IS NULL OR expires_at > now())", id)
+		if err != nil {
+			return err
+		}
+		deleted, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		app.writeDeleteError(w, err)
+		return
+	}
+	if deleted == 0 {
+		http.Error(w, "Number not found", http.StatusNotFound)
+		return
+	}
+
+	// Данные изменились: кэшированные ответы больше не актуальны, обновляется Last-Modified
+	app.dataChanged()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// clearNumbers удаляет все записи (TRUNCATE). Требует подтверждения заголовком X-Confirm: true
+// или параметром confirm=true, чтобы таблицу нельзя было очистить случайным запросом
+func (app *App) clearNumbers(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Confirm") != "true" && r.URL.Query().Get("confirm") != "true" {
+		http.Error(w, "Set the X-Confirm: true header or confirm=true to delete all numbers", http.StatusBadRequest)
+		return
+	}
+
+	err := app.withRetry(func() error {
+		_, err := app.DB.Exec("TRUNCATE numbers, number_tags")
+		return err
+	})
+	if err != nil {
+		app.writeDeleteError(w, err)
+		return
+	}
+
+	log.Printf("All numbers deleted")
+	app.dataChanged()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeDeleteError преобразует ошибку удаления в HTTP ответ
+func (app *App) writeDeleteError(w http.ResponseWriter, err error) {
+	if isReadOnlyError(err) {
+		// База данных в режиме только чтения (реплика или переключение при отказе)
+		log.Printf("Error deleting numbers: database is read-only: %v", maskError(err))
+		w.Header().Set("Retry-After", strconv.Itoa(app.readOnlyRetryAfter()))
+		http.Error(w, "Database is read-only, try again later", http.StatusServiceUnavailable)
+		return
+	}
+	log.Printf("Error deleting numbers: %v", maskError(err))
+	http.Error(w, "Failed to delete numbers", http.StatusInternalServerError)
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestDeleteNumber тестирует удаление записи по id и 404 для отсутствующего id
+func TestDeleteNumber(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	var id int
+	if err := db.QueryRow("INSERT INTO numbers (value) VALUES (7) RETURNING id").Scan(&id); err != nil {
+		t.Fatalf("Failed to insert number: %v", err)
+	}
+	db.Exec("INSERT INTO numbers (value) VALUES (3)")
+
+	target := "/numbers/" + strconv.Itoa(id)
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodDelete, target, nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+
+	numbers, _ := app.getAllNumbers()
+	if !reflect.DeepEqual(numbers, []int64{3}) {
+		t.Errorf("Expected [3] after delete, got %v", numbers)
+	}
+
+	w = httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodDelete, target, nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a deleted id, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestDeleteNumberRouting тестирует разбор id и ответы без обращения к реальной базе
+func TestDeleteNumberRouting(t *testing.T) {
+	var affected int64
+	fc := &fakeConnector{
+		exec: func(q string, args []driver.NamedValue) (driver.Result, error) {
+			if len(args) != 1 || args[0].Value != int64(42) {
+				t.Errorf("Unexpected delete args %v", args)
+			}
+			return driver.RowsAffected(affected), nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	tests := []struct {
+		name     string
+		target   string
+		affected int64
+		status   int
+	}{
+		{"existing id", "/numbers/42", 1, http.StatusNoContent},
+		{"missing id", "/numbers/42", 0, http.StatusNotFound},
+		{"not an id", "/numbers/abc", 0, http.StatusNotFound},
+		{"zero id", "/numbers/0", 0, http.StatusNotFound},
+		{"id out of range", "/numbers/99999999999", 0, http.StatusNotFound},
+		{"nested path", "/numbers/42/extra", 0, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			affected = tt.affected
+			w := httptest.NewRecorder()
+			app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodDelete, tt.target, nil))
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/numbers/42", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d for PUT, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+// TestClearNumbers тестирует, что удаление всех чисел требует подтверждения
+func TestClearNumbers(t *testing.T) {
+	var truncates atomic.Int64
+	fc := &fakeConnector{
+		exec: func(q string, args []driver.NamedValue) (driver.Result, error) {
+			if strings.HasPrefix(q, "TRUNCATE") {
+				truncates.Add(1)
+			}
+			return driver.RowsAffected(0), nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	tests := []struct {
+		name      string
+		target    string
+		header    string
+		status    int
+		truncated bool
+	}{
+		{"no confirmation", "/numbers", "", http.StatusBadRequest, false},
+		{"wrong confirmation", "/numbers?confirm=yes", "false", http.StatusBadRequest, false},
+		{"header", "/numbers", "true", http.StatusNoContent, true},
+		{"query parameter", "/numbers?confirm=true", "", http.StatusNoContent, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := truncates.Load()
+			req := httptest.NewRequest(http.MethodDelete, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Confirm", tt.header)
+			}
+			w := httptest.NewRecorder()
+			app.routes().ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+			if got := truncates.Load() > before; got != tt.truncated {
+				t.Errorf("Expected truncated=%v, got %v", tt.truncated, got)
+			}
+		})
+	}
+}
+
+// TestGetNumber тестирует получение отдельной записи по id
+func TestGetNumber(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	var id int64
+	if err := db.QueryRow("INSERT INTO numbers (value) VALUES (7) RETURNING id").Scan(&id); err != nil {
+		t.Fatalf("Failed to insert number: %v", err)
+	}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers/"+strconv.FormatInt(id, 10), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var record NumberRecord
+	if err := json.NewDecoder(w.Body).Decode(&record); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if record.ID != id || record.Value != 7 || record.CreatedAt == nil {
+		t.Errorf("Unexpected record %+v", record)
+	}
+
+	w = httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/"+strconv.FormatInt(id+1, 10), nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a missing id, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestGetNumberFake тестирует ответ с записью и 404 без обращения к реальной базе
+func TestGetNumberFake(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	fc := &fakeConnector{
+		query: func(q string, args []driver.NamedValue) (*fakeRows, error) {
+			rows := &fakeRows{columns: []string{"id", "value", "created_at", "version"}}
+			if args[0].Value == int64(42) {
+				rows.values = [][]driver.Value{{int64(42), int64(-5), createdAt, int64(3)}}
+			}
+			return rows, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers/42", nil))
+	expected := `{"id":42,"value":-5,"created_at":"2024-01-01T10:00:00Z","version":3}`
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != expected {
+		t.Errorf("Expected %s, got %d %s", expected, w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("ETag"); got != `"3"` {
+		t.Errorf("Expected ETag \"3\", got %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/43", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestPatchNumber тестирует изменение значения с If-Match: текущая версия применяется,
+// устаревшая получает 412
+func TestPatchNumber(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	var id int64
+	if err := db.QueryRow("INSERT INTO numbers (value) VALUES (7) RETURNING id").Scan(&id); err != nil {
+		t.Fatalf("Failed to insert number: %v", err)
+	}
+	target := "/numbers/" + strconv.FormatInt(id, 10)
+
+	patch := func(value int, ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, target, strings.NewReader(`{"value":`+strconv.Itoa(value)+`}`))
+		req.Header.Set("If-Match", ifMatch)
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, req)
+		return w
+	}
+
+	w := patch(8, `"1"`)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("Expected status %d with ETag \"2\", got %d %q: %s", http.StatusOK, w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+
+	// Второй клиент прочитал запись до изменения
+	w = patch(9, `"1"`)
+	if w.Code != http.StatusPreconditionFailed || w.Header().Get("ETag") != `"2"` {
+		t.Errorf("Expected status %d with ETag \"2\", got %d %q", http.StatusPreconditionFailed, w.Code, w.Header().Get("ETag"))
+	}
+
+	var value int
+	if err := db.QueryRow("SELECT value FROM numbers WHERE id = $1", id).Scan(&value); err != nil || value != 8 {
+		t.Errorf("Expected value 8, got %d (%v)", value, err)
+	}
+}
+
+// TestPatchNumberFake тестирует условие версии в UPDATE и ответы без обращения к реальной базе
+func TestPatchNumberFake(t *testing.T) {
+	fc := &fakeConnector{
+		query: func(q string, args []driver.NamedValue) (*fakeRows, error) {
+			if strings.Contains(q, "UPDATE numbers") {
+				rows := &fakeRows{columns: []string{"id", "value", "created_at", "version"}}
+				if args[0].Value == int64(42) && (args[2].Value == nil || args[2].Value == int64(3)) {
+					rows.values = [][]driver.Value{{int64(42), args[1].Value, nil, int64(4)}}
+				}
+				return rows, nil
+			}
+			rows := &fakeRows{columns: []string{"version"}}
+			if args[0].Value == int64(42) {
+				rows.values = [][]driver.Value{{int64(3)}}
+			}
+			return rows, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	tests := []struct {
+		name    string
+		target  string
+		ifMatch string
+		body    string
+		want    int
+	}{
+		{"current version", "/numbers/42", `"3"`, `{"value":10}`, http.StatusOK},
+		{"version field", "/numbers/42", "", `{"value":10,"version":3}`, http.StatusOK},
+		{"wildcard", "/numbers/42", "*", `{"value":10}`, http.StatusOK},
+		{"stale version", "/numbers/42", `"2"`, `{"value":10}`, http.StatusPreconditionFailed},
+		{"missing record", "/numbers/43", `"3"`, `{"value":10}`, http.StatusNotFound},
+		{"no precondition", "/numbers/42", "", `{"value":10}`, http.StatusPreconditionRequired},
+		{"unquoted etag", "/numbers/42", "3", `{"value":10}`, http.StatusBadRequest},
+		{"missing value", "/numbers/42", `"3"`, `{}`, http.StatusBadRequest},
+		{"invalid json", "/numbers/42", `"3"`, `{`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, tt.target, strings.NewReader(tt.body))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := serveChecked(t, app.routes(), req)
+			if w.Code != tt.want {
+				t.Fatalf("Expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+			if tt.want == http.StatusOK {
+				expected := `{"id":42,"value":10,"created_at":null,"version":4}`
+				if strings.TrimSpace(w.Body.String()) != expected || w.Header().Get("ETag") != `"4"` {
+					t.Errorf("Expected %s with ETag \"4\", got %s %q", expected, w.Body.String(), w.Header().Get("ETag"))
+				}
+			}
+		})
+	}
+
+	// В режиме MONOTONIC значения не изменяются
+	app.Config.Monotonic = true
+	req := httptest.NewRequest(http.MethodPatch, "/numbers/42", strings.NewReader(`{"value":10}`))
+	req.Header.Set("If-Match", `"3"`)
+	if w := serveChecked(t, app.routes(), req); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d in MONOTONIC mode, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+// TestGetNumbersVerbose тестирует verbose=true: записи с id и временем добавления и курсор keyset-страницы
+func TestGetNumbersVerbose(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	var query atomic.Value
+	fc := &fakeConnector{
+		query: func(q string, args []driver.NamedValue) (*fakeRows, error) {
+			query.Store(q)
+			return &fakeRows{
+				columns: []string{"value", "id", "created_at"},
+				values:  [][]driver.Value{{int64(3), int64(12), createdAt}, {int64(5), int64(10), nil}},
+			}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers?verbose=true", nil))
+	expected := `{"numbers":[{"id":12,"value":3,"created_at":"2024-01-01T10:00:00Z"},{"id":10,"value":5,"created_at":null}]}`
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != expected {
+		t.Fatalf("Expected %s, got %d %s", expected, w.Code, w.Body.String())
+	}
+	if q, _ := query.Load().(string); !strings.HasPrefix(q, "SELECT value, id, created_at FROM live_numbers ORDER BY value ASC") {
+		t.Errorf("Expected a record query, got %q", q)
+	}
+	if got := w.Header().Get("X-Total-Count"); got != "2" {
+		t.Errorf("Expected X-Total-Count 2, got %q", got)
+	}
+
+	w = serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers?verbose=true&limit=2&after=", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("X-Next-Cursor"); got != (pageCursor{Value: 5, ID: 10}).encode() {
+		t.Errorf("Expected a cursor after the last record, got %q", got)
+	}
+
+	for _, target := range []string{"/numbers?verbose=true&distinct=true", "/numbers?verbose=true&format=rle"} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", target, http.StatusBadRequest, w.Code)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// defaultRecycleErrorCodes - ошибки, после которых соединение с PostgreSQL непригодно:
+// admin_shutdown и crash_shutdown (сервер завершил backend-процесс соединения)
+var defaultRecycleErrorCodes = []string{"57P01", "57P02"}
+
+// parseErrorCodes разбирает список SQLSTATE кодов, разделенных запятыми
+func parseErrorCodes(s string) []string {
+	var codes []string
+	for _, code := range strings.Split(s, ",") {
+		if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// recyclingConnector оборачивает соединения драйвера так, чтобы после ошибок из списка
+// RECYCLE_ERROR_CODES соединение не возвращалось в пул и sql.DB открыл новое
+type recyclingConnector struct {
+	driver.Connector
+	codes map[string]bool
+}
+
+// newRecyclingConnector создает обертку коннектора для заданных SQLSTATE кодов
+func newRecyclingConnector(c driver.Connector, codes []string) *recyclingConnector {
+	rc := &recyclingConnector{Connector: c, codes: make(map[string]bool, len(codes))}
+	for _, code := range codes {
+		rc.codes[code] = true
+	}
+	return rc
+}
+
+// Connect открывает соединение и оборачивает его
+func (rc *recyclingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := rc.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &recyclingConn{Conn: conn, codes: rc.codes}, nil
+}
+
+// Close закрывает вложенный коннектор (например, останавливает проверку failoverConnector)
+func (rc *recyclingConnector) Close() error {
+	if c, ok := rc.Connector.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// recyclingConn помечает себя негодным после ошибки из списка кодов. Ошибка возвращается
+// вызывающему как есть, а sql.DB закрывает соединение вместо возврата в пул
+type recyclingConn struct {
+	driver.Conn
+	codes map[string]bool
+	bad   atomic.Bool
+}
+
+// observe помечает соединение негодным, если ошибка входит в список кодов
+func (c *recyclingConn) observe(err error) error {
+	if code := pgErrorCode(err); code != "" && c.codes[code] && !c.bad.Swap(true) {
+		log.Printf("Discarding database connection after error %s", code)
+	}
+	return err
+}
+
+// IsValid не дает вернуть в пул соединение, помеченное негодным
+func (c *recyclingConn) IsValid() bool {
+	if c.bad.Load() {
+		return false
+	}
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// ResetSession отбрасывает негодное соединение перед повторным использованием
+func (c *recyclingConn) ResetSession(ctx context.Context) error {
+	if c.bad.Load() {
+		return driver.ErrBadConn
+	}
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *recyclingConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return c.observe(p.Ping(ctx))
+	}
+	return nil
+}
+
+func (c *recyclingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if e, ok := c.Conn.(driver.ExecerContext); ok {
+		res, err := e.ExecContext(ctx, query, args)
+		return res, c.observe(err)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *recyclingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if q, ok := c.Conn.(driver.QueryerContext); ok {
+		rows, err := q.QueryContext(ctx, query, args)
+		return rows, c.observe(err)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *recyclingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err := p.PrepareContext(ctx, query)
+		return stmt, c.observe(err)
+	}
+	stmt, err := c.Conn.Prepare(query)
+	return stmt, c.observe(err)
+}
+
+func (c *recyclingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err := b.BeginTx(ctx, opts)
+		return tx, c.observe(err)
+	}
+	tx, err := c.Conn.Begin()
+	return tx, c.observe(err)
+}
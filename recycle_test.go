@@ -0,0 +1,60 @@
+package main
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+// TestRecycleConnectionAfterAdminShutdown тестирует, что соединение, получившее 57P01, не
+// используется повторно, а после прочих ошибок остается в пуле
+func TestRecycleConnectionAfterAdminShutdown(t *testing.T) {
+	var failWith atomic.Value
+	failWith.Store("")
+	fc := &fakeConnector{
+		exec: func(string, []driver.NamedValue) (driver.Result, error) {
+			if code := failWith.Swap("").(string); code != "" {
+				return nil, &pq.Error{Code: pq.ErrorCode(code)}
+			}
+			return driver.RowsAffected(1), nil
+		},
+	}
+	db := sql.OpenDB(newRecyclingConnector(fc, defaultRecycleErrorCodes))
+	defer db.Close()
+
+	// Ошибка не из списка: соединение возвращается в пул
+	failWith.Store("23505")
+	if _, err := db.Exec("INSERT INTO numbers (value) VALUES (1)"); err == nil {
+		t.Fatal("Expected unique violation error")
+	}
+	if _, err := db.Exec("INSERT INTO numbers (value) VALUES (1)"); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if got := fc.opens.Load(); got != 1 {
+		t.Fatalf("Expected the connection to be reused after 23505, got %d opens", got)
+	}
+
+	// admin_shutdown: ошибка возвращается, а соединение закрывается
+	failWith.Store("57P01")
+	_, err := db.Exec("INSERT INTO numbers (value) VALUES (2)")
+	if pgErrorCode(err) != "57P01" {
+		t.Fatalf("Expected admin shutdown error, got %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO numbers (value) VALUES (2)"); err != nil {
+		t.Fatalf("Exec after admin shutdown failed: %v", err)
+	}
+	if got := fc.opens.Load(); got != 2 {
+		t.Errorf("Expected a new connection after 57P01, got %d opens", got)
+	}
+}
+
+// TestParseErrorCodes тестирует разбор списка RECYCLE_ERROR_CODES
+func TestParseErrorCodes(t *testing.T) {
+	if got := parseErrorCodes(" 57p01, ,57P02,08006 "); !reflect.DeepEqual(got, []string{"57P01", "57P02", "08006"}) {
+		t.Errorf("Unexpected codes %v", got)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// debugResponseChecks включает проверку ответов перед сериализацией (режим DEBUG)
+var debugResponseChecks bool
+
+// checkResponse в режиме отладки сообщает о nil-срезах в ответе, которые
+// сериализуются в JSON как null вместо []
+func checkResponse(v interface{}) {
+	if !debugResponseChecks {
+		return
+	}
+	if paths := nilSlicePaths(v); len(paths) > 0 {
+		log.Printf("BUG: response contains null arrays at %s", strings.Join(paths, ", "))
+	}
+}
+
+// nilSlicePaths возвращает пути ко всем nil-срезам в значении, обходя указатели,
+// структуры и элементы срезов. Верхний уровень обозначается как "$"
+func nilSlicePaths(v interface{}) []string {
+	var paths []string
+	walkNilSlices(reflect.ValueOf(v), "$", &paths)
+	return paths
+}
+
+// walkNilSlices рекурсивно обходит значение и добавляет пути к nil-срезам
+func walkNilSlices(v reflect.Value, path string, paths *[]string) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			walkNilSlices(v.Elem(), path, paths)
+		}
+	case reflect.Slice:
+		if v.IsNil() {
+			*paths = append(*paths, path)
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			walkNilSlices(v.Index(i), path+"["+strconv.Itoa(i)+"]", paths)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			// Пустой срез с omitempty не попадает в JSON, а не сериализуется как null
+			if strings.Contains(","+opts+",", ",omitempty,") && v.Field(i).Kind() == reflect.Slice && v.Field(i).Len() == 0 {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			walkNilSlices(v.Field(i), path+"."+name, paths)
+		}
+	}
+}
+
+// headerOrderWriter отслеживает порядок вызовов ResponseWriter и сообщает о WriteHeader после
+// начала тела или о повторном WriteHeader. net/http в таких случаях лишь пишет в лог
+// "superfluous WriteHeader", а клиент получает ответ с уже отправленным статусом
+type headerOrderWriter struct {
+	http.ResponseWriter
+	status    int
+	wroteBody bool
+	report    func(problem string)
+}
+
+func (h *headerOrderWriter) WriteHeader(status int) {
+	switch {
+	case h.wroteBody:
+		h.report(fmt.Sprintf("WriteHeader(%d) called after the body was written with status %d", status, h.status))
+	case h.status != 0:
+		h.report(fmt.Sprintf("WriteHeader(%d) called after WriteHeader(%d)", status, h.status))
+	default:
+		h.status = status
+	}
+	h.ResponseWriter.WriteHeader(status)
+}
+
+func (h *headerOrderWriter) Write(b []byte) (int, error) {
+	if h.status == 0 {
+		h.status = http.StatusOK
+	}
+	h.wroteBody = true
+	return h.ResponseWriter.Write(b)
+}
+
+// Unwrap открывает исходный ResponseWriter для http.ResponseController
+func (h *headerOrderWriter) Unwrap() http.ResponseWriter {
+	return h.ResponseWriter
+}
+
+// checkHeaderOrder в режиме отладки сообщает в лог о нарушении порядка записи заголовков
+func checkHeaderOrder(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !debugResponseChecks {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(&headerOrderWriter{ResponseWriter: w, report: func(problem string) {
+			log.Printf("BUG: %s %s: %s", r.Method, r.URL.Path, problem)
+		}}, r)
+	})
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// assertNoNullArrays проверяет декодированный ответ на наличие null вместо массивов.
+// Поле-срез, пришедшее в JSON как null, декодируется в nil, а [] - в пустой срез
+func assertNoNullArrays(t *testing.T, v interface{}) {
+	t.Helper()
+	if paths := nilSlicePaths(v); len(paths) > 0 {
+		t.Fatalf("Response contains null arrays at %v", paths)
+	}
+}
+
+// serveChecked выполняет запрос и проваливает тест, если обработчик нарушил порядок
+// записи заголовков (WriteHeader после тела или повторный WriteHeader)
+func serveChecked(t *testing.T, h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(&headerOrderWriter{ResponseWriter: w, report: func(problem string) {
+		t.Errorf("%s %s: %s", req.Method, req.URL.Path, problem)
+	}}, req)
+	return w
+}
+
+// TestNilSlicePaths тестирует, что проверка находит nil-срез, проскочивший в ответ
+func TestNilSlicePaths(t *testing.T) {
+	if got := nilSlicePaths(NumbersResponse{}); !reflect.DeepEqual(got, []string{"$.numbers"}) {
+		t.Errorf("Expected [$.numbers], got %v", got)
+	}
+	if got := nilSlicePaths([]DecadeBucket(nil)); !reflect.DeepEqual(got, []string{"$"}) {
+		t.Errorf("Expected [$], got %v", got)
+	}
+	if got := nilSlicePaths(&NumbersResponse{Numbers: []int64{}}); len(got) != 0 {
+		t.Errorf("Expected no null arrays, got %v", got)
+	}
+
+	// Ответ с null, декодированный в типизированную структуру, тоже обнаруживается
+	var decoded NumbersResponse
+	json.Unmarshal([]byte(`{"numbers": null}`), &decoded)
+	if got := nilSlicePaths(&decoded); len(got) != 1 {
+		t.Errorf("Expected decoded null array to be detected, got %v", got)
+	}
+
+	// Пустые срезы с omitempty не сериализуются вовсе
+	if got := nilSlicePaths(graphqlResponse{Errors: []graphqlError{{Message: "x"}}}); len(got) != 0 {
+		t.Errorf("Expected omitempty slices to be skipped, got %v", got)
+	}
+}
+
+// TestGetNumbersEmptyIsArray тестирует, что пустая таблица возвращается как [], а не null
+func TestGetNumbersEmptyIsArray(t *testing.T) {
+	app := &App{DB: newFakeDB(t, &fakeConnector{})}
+
+	w := serveChecked(t, http.HandlerFunc(app.handleNumbers), httptest.NewRequest(http.MethodGet, "/numbers", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response NumbersResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &response)
+}
+
+// TestHeaderOrderWriter тестирует обнаружение WriteHeader после тела и повторного WriteHeader
+func TestHeaderOrderWriter(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		wantBug bool
+	}{
+		{"status then body", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("ok"))
+		}, false},
+		{"body then status", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+			w.WriteHeader(http.StatusInternalServerError)
+		}, true},
+		{"status twice", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			http.Error(w, "failed", http.StatusInternalServerError)
+		}, true},
+	}
+	for _, tt := range tests {
+		var problems []string
+		w := &headerOrderWriter{ResponseWriter: httptest.NewRecorder(), report: func(problem string) {
+			problems = append(problems, problem)
+		}}
+		tt.handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if got := len(problems) > 0; got != tt.wantBug {
+			t.Errorf("%s: expected bug detected = %v, got problems %v", tt.name, tt.wantBug, problems)
+		}
+	}
+}
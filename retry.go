@@ -0,0 +1,102 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultDBRetries - количество повторов операции после временной ошибки по умолчанию
+	defaultDBRetries = 2
+
+	// defaultRetryBudgetMax и defaultRetryBudgetRatio задают бюджет повторов по умолчанию:
+	// не больше 10 повторов подряд и в среднем один повтор на 10 операций
+	defaultRetryBudgetMax   = 10
+	defaultRetryBudgetRatio = 0.1
+
+	// retryBackoff - пауза перед повтором, растущая линейно с номером попытки
+	retryBackoff = 50 * time.Millisecond
+)
+
+// retryBudget - общий для приложения бюджет повторов (token bucket). Каждая операция пополняет
+// бюджет на ratio токенов, каждый повтор расходует один токен. Пока база работает, бюджета хватает
+// на редкие повторы; при массовых отказах он быстро исчерпывается, повторы прекращаются, и ошибки
+// возвращаются клиентам сразу, не умножая нагрузку на отказавшую базу
+type retryBudget struct {
+	max     float64
+	ratio   float64
+	backoff time.Duration
+
+	mu         sync.Mutex
+	tokens     float64
+	retries    int64
+	suppressed int64
+}
+
+// RetryBudgetState представляет текущее состояние бюджета повторов
+type RetryBudgetState struct {
+	Tokens     float64 `json:"tokens"`
+	Max        float64 `json:"max"`
+	Retries    int64   `json:"retries"`
+	Suppressed int64   `json:"suppressed"`
+}
+
+// newRetryBudget создает заполненный бюджет на max повторов с пополнением ratio за операцию
+func newRetryBudget(max, ratio float64) *retryBudget {
+	return &retryBudget{max: max, ratio: ratio, backoff: retryBackoff, tokens: max}
+}
+
+// deposit пополняет бюджет за одну операцию
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += b.ratio
+	if b.tokens > b.max {
+		b.tokens = b.max
+	}
+}
+
+// withdraw расходует токен на повтор; возвращает false, если бюджет исчерпан
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		b.suppressed++
+		return false
+	}
+	b.tokens--
+	b.retries++
+	return true
+}
+
+// state возвращает снимок состояния бюджета
+func (b *retryBudget) state() RetryBudgetState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return RetryBudgetState{Tokens: b.tokens, Max: b.max, Retries: b.retries, Suppressed: b.suppressed}
+}
+
+// withRetry выполняет операцию и повторяет ее после временных ошибок (см. isTransientError),
+// пока позволяет бюджет повторов. Без бюджета (RetryBudget не задан) операция не повторяется
+func (app *App) withRetry(op func() error) error {
+	b := app.RetryBudget
+	if b == nil {
+		return op()
+	}
+
+	b.deposit()
+	err := op()
+	for attempt := 1; err != nil && isTransientError(err) && attempt <= app.Config.DBRetries; attempt++ {
+		if !b.withdraw() {
+			log.Printf("Retry budget exhausted, not retrying: %v", maskError(err))
+			break
+		}
+		time.Sleep(b.backoff * time.Duration(attempt))
+		err = op()
+	}
+	return err
+}
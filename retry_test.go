@@ -0,0 +1,63 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+// TestRetryBudgetThrottlesRetries тестирует, что при массовых отказах повторы прекращаются
+// после исчерпания бюджета, а ошибки возвращаются сразу
+func TestRetryBudgetThrottlesRetries(t *testing.T) {
+	fc := &fakeConnector{
+		exec: func(query string, args []driver.NamedValue) (driver.Result, error) {
+			return nil, &pq.Error{Code: "40001"}
+		},
+	}
+	budget := newRetryBudget(2, 0)
+	budget.backoff = 0
+	app := &App{DB: newFakeDB(t, fc), Config: Config{DBRetries: 3}, RetryBudget: budget}
+
+	for i := 0; i < 10; i++ {
+		if err := app.storeNumber(int64(i)); err == nil {
+			t.Fatal("Expected storeNumber to fail")
+		}
+	}
+
+	// 10 операций и только 2 повтора, на которые хватило бюджета
+	if got := fc.execs.Load(); got != 12 {
+		t.Errorf("Expected 12 exec attempts, got %d", got)
+	}
+	state := budget.state()
+	if state.Retries != 2 || state.Suppressed != 10 || state.Tokens != 0 {
+		t.Errorf("Unexpected budget state %+v", state)
+	}
+}
+
+// TestWithRetryRecoversTransientError тестирует повтор после временной ошибки и отсутствие
+// повторов для остальных ошибок
+func TestWithRetryRecoversTransientError(t *testing.T) {
+	budget := newRetryBudget(10, 0.1)
+	budget.backoff = 0
+	app := &App{Config: Config{DBRetries: 2}, RetryBudget: budget}
+
+	calls := 0
+	err := app.withRetry(func() error {
+		calls++
+		if calls == 1 {
+			return &pq.Error{Code: "40P01"}
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("Expected success on second attempt, got err=%v after %d calls", err, calls)
+	}
+
+	calls = 0
+	permanent := errors.New("syntax error")
+	if err := app.withRetry(func() error { calls++; return permanent }); err != permanent || calls != 1 {
+		t.Errorf("Expected permanent error without retries, got err=%v after %d calls", err, calls)
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Коды ошибок JSON-RPC 2.0: стандартные и серверные из диапазона -32000..-32099
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+	rpcConflict       = -32001
+	rpcUnavailable    = -32002
+)
+
+const (
+	// maxRPCBodyBytes ограничивает тело /rpc, которое читается в память целиком (1 MiB)
+	maxRPCBodyBytes = 1 << 20
+
+	// maxRPCBatchSize ограничивает количество вызовов в одном пакете
+	maxRPCBatchSize = 100
+)
+
+// rpcRequest - вызов JSON-RPC 2.0. ID равен nil для уведомления (поле id отсутствует)
+// и "null", если id передан как null
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// rpcResponse - ответ на вызов: содержит либо result, либо error
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// rpcError - объект ошибки JSON-RPC; Data уточняет причину Invalid params и содержит
+// retry_after для базы только для чтения
+type rpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// rpcMethod выполняет метод с параметрами params (nil, если они не переданы)
+type rpcMethod func(app *App, params json.RawMessage) (interface{}, *rpcError)
+
+// rpcMethods - методы /rpc
+var rpcMethods = map[string]rpcMethod{
+	"numbers.add":   (*App).rpcAdd,
+	"numbers.list":  (*App).rpcList,
+	"numbers.stats": (*App).rpcStats,
+}
+
+// handleRPC обрабатывает вызов или пакет вызовов JSON-RPC 2.0. Ответ всегда отправляется со
+// статусом 200, ошибки передаются объектами error. Уведомления (без id) выполняются без
+// ответа; если пакет состоит только из уведомлений, возвращается 204. Тело больше 1 MiB
+// отклоняется с 413, пакет больше maxRPCBatchSize вызовов - ошибкой Invalid Request
+func (app *App) handleRPC(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRPCBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	body = bytes.TrimSpace(body)
+	if !json.Valid(body) {
+		writeJSON(w, rpcErrorResponse(nil, &rpcError{Code: rpcParseError, Message: "Parse error"}))
+		return
+	}
+
+	if body[0] != '[' {
+		if resp := app.rpcCall(body); resp != nil {
+			writeJSON(w, resp)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var calls []json.RawMessage
+	json.Unmarshal(body, &calls)
+	if len(calls) == 0 {
+		writeJSON(w, rpcErrorResponse(nil, &rpcError{Code: rpcInvalidRequest, Message: "Invalid Request"}))
+		return
+	}
+	if len(calls) > maxRPCBatchSize {
+		writeJSON(w, rpcErrorResponse(nil, &rpcError{Code: rpcInvalidRequest, Message: "Invalid Request",
+			Data: fmt.Sprintf("batch must contain at most %d calls", maxRPCBatchSize)}))
+		return
+	}
+	responses := []*rpcResponse{}
+	for _, call := range calls {
+		if resp := app.rpcCall(call); resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, responses)
+}
+
+// rpcCall выполняет один вызов и возвращает ответ или nil для уведомления
+func (app *App) rpcCall(raw json.RawMessage) *rpcResponse {
+	var req rpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil || req.JSONRPC != "2.0" || req.Method == "" || !validRPCID(req.ID) {
+		return rpcErrorResponse(nil, &rpcError{Code: rpcInvalidRequest, Message: "Invalid Request"})
+	}
+
+	var (
+		result interface{}
+		rerr   *rpcError
+	)
+	if method, ok := rpcMethods[req.Method]; ok {
+		result, rerr = method(app, req.Params)
+	} else {
+		rerr = &rpcError{Code: rpcMethodNotFound, Message: "Method not found"}
+	}
+	if req.ID == nil {
+		return nil
+	}
+	if rerr != nil {
+		return rpcErrorResponse(req.ID, rerr)
+	}
+	return &rpcResponse{JSONRPC: "2.0", Result: result, ID: req.ID}
+}
+
+// rpcErrorResponse возвращает ответ с ошибкой; id равен null, если его не удалось прочитать
+func rpcErrorResponse(id json.RawMessage, rerr *rpcError) *rpcResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &rpcResponse{JSONRPC: "2.0", Error: rerr, ID: id}
+}
+
+// validRPCID проверяет, что id - строка, число или null, либо отсутствует
+func validRPCID(id json.RawMessage) bool {
+	if id == nil {
+		return true
+	}
+	switch c := id[0]; {
+	case c == '"' || c == '-' || c >= '0' && c <= '9':
+		return true
+	}
+	return string(id) == "null"
+}
+
+// invalidRPCParams возвращает ошибку Invalid params с причиной в data
+func invalidRPCParams(reason string) *rpcError {
+	return &rpcError{Code: rpcInvalidParams, Message: "Invalid params", Data: reason}
+}
+
+// rpcAdd сохраняет число и возвращает отсортированный список всех чисел, как POST /numbers.
+// Параметры передаются по имени, как JSON тело POST /numbers ({"number": 3, "ttl_seconds": 60,
+// "tags": ["lab"]}), или по позиции ([3])
+func (app *App) rpcAdd(params json.RawMessage) (interface{}, *rpcError) {
+	var req NumberRequest
+	switch {
+	case bytes.HasPrefix(params, []byte("[")):
+		var args []json.RawMessage
+		if err := json.Unmarshal(params, &args); err != nil || len(args) != 1 {
+			return nil, invalidRPCParams("expected [number]")
+		}
+		n, err := parseJSONNumber(args[0])
+		if err != nil {
+			return nil, invalidRPCParams(err.Error())
+		}
+		req.Number = n
+	case bytes.HasPrefix(params, []byte("{")):
+		var probe struct {
+			Number json.RawMessage `json:"number"`
+		}
+		if json.Unmarshal(params, &probe); probe.Number == nil || string(probe.Number) == "null" {
+			return nil, invalidRPCParams("number is required")
+		}
+		if err := json.Unmarshal(params, &req); err != nil {
+			if errors.Is(err, errInvalidNumber) || errors.Is(err, errNumberRange) {
+				return nil, invalidRPCParams(err.Error())
+			}
+			return nil, invalidRPCParams("invalid params object")
+		}
+	default:
+		return nil, invalidRPCParams("number is required")
+	}
+
+	var ttl time.Duration
+	if req.TTLSeconds != nil {
+		if *req.TTLSeconds <= 0 {
+			return nil, invalidRPCParams("ttl_seconds must be positive")
+		}
+		ttl = time.Duration(*req.TTLSeconds) * time.Second
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, invalidRPCParams(err.Error())
+	}
+
+	added, err := app.storeExpiringNumber(req.Number, ttl, tags...)
+	if err != nil {
+		return nil, app.rpcStoreError(err)
+	}
+	resp, rerr := app.rpcNumbers()
+	if rerr == nil && app.Config.UniqueNumbers {
+		resp.Added = &added
+	}
+	return resp, rerr
+}
+
+// rpcList возвращает отсортированный список всех чисел; метод не принимает параметров
+func (app *App) rpcList(params json.RawMessage) (interface{}, *rpcError) {
+	if !emptyRPCParams(params) {
+		return nil, invalidRPCParams("numbers.list takes no params")
+	}
+	return app.rpcNumbers()
+}
+
+// rpcStats возвращает статистику значений, как GET /numbers/stats; метод не принимает параметров
+func (app *App) rpcStats(params json.RawMessage) (interface{}, *rpcError) {
+	if !emptyRPCParams(params) {
+		return nil, invalidRPCParams("numbers.stats takes no params")
+	}
+	stats, err := app.computeStats("live_numbers")
+	if err != nil {
+		log.Printf("Error computing stats: %v", err)
+		return nil, &rpcError{Code: rpcInternalError, Message: "Failed to compute stats"}
+	}
+	return stats, nil
+}
+
+// rpcNumbers получает отсортированный список всех чисел
+func (app *App) rpcNumbers() (NumbersResponse, *rpcError) {
+	numbers, err := app.getAllNumbers()
+	if err != nil {
+		log.Printf("Error getting numbers: %v", err)
+		return NumbersResponse{}, &rpcError{Code: rpcInternalError, Message: "Failed to retrieve numbers"}
+	}
+	return NumbersResponse{Numbers: numbers}, nil
+}
+
+// emptyRPCParams проверяет, что параметры не переданы или пусты ([] или {})
+func emptyRPCParams(params json.RawMessage) bool {
+	if params == nil || string(params) == "null" {
+		return true
+	}
+	var v interface{}
+	json.Unmarshal(params, &v)
+	switch v := v.(type) {
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// rpcStoreError преобразует ошибку сохранения числа в объект ошибки JSON-RPC
+func (app *App) rpcStoreError(err error) *rpcError {
+	var verr validationError
+	var merr monotonicError
+	switch {
+	case errors.As(err, &verr):
+		return invalidRPCParams(err.Error())
+	case errors.As(err, &merr):
+		return &rpcError{Code: rpcConflict, Message: err.Error()}
+	case isReadOnlyError(err):
+		log.Printf("Error inserting number: database is read-only: %v", maskError(err))
+		return &rpcError{
+			Code:    rpcUnavailable,
+			Message: "Database is read-only, try again later",
+			Data:    map[string]int{"retry_after": app.readOnlyRetryAfter()},
+		}
+	default:
+		log.Printf("Error inserting number: %v", maskError(err))
+		return &rpcError{Code: rpcInternalError, Message: "Failed to save number"}
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// postRPC отправляет тело на /rpc и возвращает ответ
+func postRPC(t *testing.T, app *App, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return serveChecked(t, app.routes(), req)
+}
+
+// TestRPCMethods тестирует методы numbers.add, numbers.list и numbers.stats
+func TestRPCMethods(t *testing.T) {
+	var inserted atomic.Value
+	app := graphqlFakeDB(t, &inserted)
+
+	tests := []struct {
+		name, body, want string
+	}{
+		{"add by name", `{"jsonrpc": "2.0", "method": "numbers.add", "params": {"number": "9223372036854775807"}, "id": 1}`,
+			`{"jsonrpc":"2.0","result":{"numbers":[-2,5]},"id":1}`},
+		{"add by position", `{"jsonrpc": "2.0", "method": "numbers.add", "params": [7], "id": "a"}`,
+			`{"jsonrpc":"2.0","result":{"numbers":[-2,5]},"id":"a"}`},
+		{"list", `{"jsonrpc": "2.0", "method": "numbers.list", "id": null}`,
+			`{"jsonrpc":"2.0","result":{"numbers":[-2,5]},"id":null}`},
+		{"stats", `{"jsonrpc": "2.0", "method": "numbers.stats", "params": {}, "id": 2}`,
+			`{"jsonrpc":"2.0","result":{"count":2,"min":-2,"max":5,"sum":3,"mean":1.5,"median":1.5},"id":2}`},
+	}
+	for _, tt := range tests {
+		w := postRPC(t, app, tt.body)
+		if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != tt.want {
+			t.Errorf("%s: expected %s, got %d %s", tt.name, tt.want, w.Code, w.Body.String())
+		}
+	}
+	if got := inserted.Load(); got != int64(7) {
+		t.Errorf("Expected 7 to be inserted last, got %v", got)
+	}
+}
+
+// TestRPCErrors тестирует стандартные объекты ошибок JSON-RPC
+func TestRPCErrors(t *testing.T) {
+	app := graphqlFakeDB(t, &atomic.Value{})
+	app.Validators = []Validator{NonNegativeValidator{}}
+
+	tests := []struct {
+		name, body string
+		code       int
+		id         string
+	}{
+		{"parse error", `{"jsonrpc": "2.0", "method"`, rpcParseError, "null"},
+		{"not an object", `1`, rpcInvalidRequest, "null"},
+		{"wrong version", `{"jsonrpc": "1.0", "method": "numbers.list", "id": 1}`, rpcInvalidRequest, "null"},
+		{"object id", `{"jsonrpc": "2.0", "method": "numbers.list", "id": {}}`, rpcInvalidRequest, "null"},
+		{"empty batch", `[]`, rpcInvalidRequest, "null"},
+		{"unknown method", `{"jsonrpc": "2.0", "method": "numbers.delete", "id": 3}`, rpcMethodNotFound, "3"},
+		{"missing number", `{"jsonrpc": "2.0", "method": "numbers.add", "params": {"tags": ["a"]}, "id": 4}`, rpcInvalidParams, "4"},
+		{"too many params", `{"jsonrpc": "2.0", "method": "numbers.add", "params": [1, 2], "id": 5}`, rpcInvalidParams, "5"},
+		{"number range", `{"jsonrpc": "2.0", "method": "numbers.add", "params": [1e30], "id": 6}`, rpcInvalidParams, "6"},
+		{"validator", `{"jsonrpc": "2.0", "method": "numbers.add", "params": {"number": -1}, "id": 7}`, rpcInvalidParams, "7"},
+		{"list params", `{"jsonrpc": "2.0", "method": "numbers.list", "params": [1], "id": 8}`, rpcInvalidParams, "8"},
+	}
+	for _, tt := range tests {
+		w := postRPC(t, app, tt.body)
+		var resp struct {
+			Result json.RawMessage `json:"result"`
+			Error  *rpcError       `json:"error"`
+			ID     json.RawMessage `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+			t.Errorf("%s: expected a JSON-RPC response, got %d %s", tt.name, w.Code, w.Body.String())
+			continue
+		}
+		if resp.Error == nil || resp.Error.Code != tt.code || resp.Result != nil || string(resp.ID) != tt.id {
+			t.Errorf("%s: expected error %d with id %s, got %s", tt.name, tt.code, tt.id, w.Body.String())
+		}
+	}
+}
+
+// TestRPCBatch тестирует пакет вызовов с уведомлением и ошибочным элементом
+func TestRPCBatch(t *testing.T) {
+	var inserted atomic.Value
+	app := graphqlFakeDB(t, &inserted)
+
+	w := postRPC(t, app, `[
+		{"jsonrpc": "2.0", "method": "numbers.add", "params": [3]},
+		{"jsonrpc": "2.0", "method": "numbers.list", "id": 1},
+		42,
+		{"jsonrpc": "2.0", "method": "nope", "id": 2}
+	]`)
+	want := `[{"jsonrpc":"2.0","result":{"numbers":[-2,5]},"id":1},` +
+		`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null},` +
+		`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":2}]`
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != want {
+		t.Errorf("Expected %s, got %d %s", want, w.Code, w.Body.String())
+	}
+	if got := inserted.Load(); got != int64(3) {
+		t.Errorf("Expected the notification to insert 3, got %v", got)
+	}
+
+	// Только уведомления: ответа нет
+	w = postRPC(t, app, `[{"jsonrpc": "2.0", "method": "numbers.list"}]`)
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Errorf("Expected status %d without body, got %d %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+}
+
+// TestRPCLimits тестирует отклонение слишком большого тела и пакета без выполнения вызовов
+func TestRPCLimits(t *testing.T) {
+	var inserted atomic.Value
+	app := graphqlFakeDB(t, &inserted)
+
+	calls := make([]string, maxRPCBatchSize+1)
+	for i := range calls {
+		calls[i] = `{"jsonrpc": "2.0", "method": "numbers.add", "params": [3]}`
+	}
+	w := postRPC(t, app, "["+strings.Join(calls, ",")+"]")
+	want := `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"batch must contain at most 100 calls"},"id":null}`
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != want {
+		t.Errorf("Expected %s, got %d %s", want, w.Code, w.Body.String())
+	}
+	if got := inserted.Load(); got != nil {
+		t.Errorf("Expected no calls to run, got insert of %v", got)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(strings.Repeat(" ", maxRPCBodyBytes+1)))
+	w = httptest.NewRecorder()
+	app.routes().ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status %d, got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+}
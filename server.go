@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// defaultShutdownTimeout ограничивает время ожидания завершения активных запросов при остановке
+const defaultShutdownTimeout = 10 * time.Second
+
+// listenAddrs возвращает адреса HTTP сервера: список из LISTEN_ADDRS или ":PORT"
+func listenAddrs(cfg Config) []string {
+	if len(cfg.ListenAddrs) > 0 {
+		return cfg.ListenAddrs
+	}
+	return []string{":" + cfg.Port}
+}
+
+// parseAddrList разбирает список адресов, разделенных запятыми, пропуская пустые элементы
+func parseAddrList(s string) []string {
+	var addrs []string
+	for _, addr := range strings.Split(s, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// listenAll открывает слушатели на всех адресах до запуска серверов. Если хотя бы один адрес
+// занят, уже открытые слушатели закрываются и возвращается ошибка с подсказкой
+func listenAll(addrs []string) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, listenError(addr, "PORT or LISTEN_ADDRS", err)
+		}
+		listeners = append(listeners, lis)
+	}
+	return listeners, nil
+}
+
+// listenError поясняет ошибку занятого адреса: называет адрес и переменную окружения, которой
+// его можно изменить. Прочие ошибки возвращаются как есть
+func listenError(addr, envVars string, err error) error {
+	if errors.Is(err, syscall.EADDRINUSE) {
+		return fmt.Errorf("address %s is already in use by another process; stop that process or choose a free port with the %s environment variable: %w", addr, envVars, err)
+	}
+	return err
+}
+
+// serveAll обслуживает запросы на всех слушателях общим обработчиком до отмены контекста
+// или ошибки одного из серверов, после чего согласованно останавливает все серверы,
+// дожидаясь завершения активных запросов не дольше shutdownTimeout
+func serveAll(ctx context.Context, listeners []net.Listener, handler http.Handler, shutdownTimeout time.Duration) error {
+	servers := make([]*http.Server, len(listeners))
+	errCh := make(chan error, len(listeners))
+
+	for i, lis := range listeners {
+		srv := &http.Server{Handler: handler}
+		servers[i] = srv
+		go func(lis net.Listener) {
+			log.Printf("Server listening on %s", lis.Addr())
+			if err := srv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errCh <- err
+			}
+		}(lis)
+	}
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		log.Printf("Shutting down server")
+	case serveErr = <-errCh:
+		log.Printf("Server failed, shutting down: %v", serveErr)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, srv := range servers {
+		wg.Add(1)
+		go func(srv *http.Server) {
+			defer wg.Done()
+			if err := srv.Shutdown(shutdownCtx); err != nil {
+				log.Printf("Error shutting down server: %v", err)
+			}
+		}(srv)
+	}
+	wg.Wait()
+
+	return serveErr
+}
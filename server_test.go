@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestServeAllMultipleAddresses тестирует обслуживание запросов на двух адресах и общую остановку
+func TestServeAllMultipleAddresses(t *testing.T) {
+	listeners, err := listenAll([]string{"127.0.0.1:0", "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- serveAll(ctx, listeners, handler, time.Second) }()
+
+	for _, lis := range listeners {
+		resp, err := http.Get("http://" + lis.Addr().String() + "/numbers")
+		if err != nil {
+			t.Fatalf("Request to %s failed: %v", lis.Addr(), err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "ok" {
+			t.Errorf("Expected body %q from %s, got %q", "ok", lis.Addr(), body)
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Servers did not shut down")
+	}
+
+	// После остановки оба адреса больше не принимают соединения
+	for _, lis := range listeners {
+		if _, err := http.Get("http://" + lis.Addr().String() + "/numbers"); err == nil {
+			t.Errorf("Expected %s to be closed after shutdown", lis.Addr())
+		}
+	}
+}
+
+// TestListenAddrs тестирует выбор адресов из LISTEN_ADDRS или PORT
+func TestListenAddrs(t *testing.T) {
+	if got := listenAddrs(Config{Port: "8080"}); !reflect.DeepEqual(got, []string{":8080"}) {
+		t.Errorf("Expected [:8080], got %v", got)
+	}
+
+	cfg := Config{Port: "8080", ListenAddrs: parseAddrList("127.0.0.1:8080, [::1]:8080,")}
+	if got := listenAddrs(cfg); !reflect.DeepEqual(got, []string{"127.0.0.1:8080", "[::1]:8080"}) {
+		t.Errorf("Expected both addresses, got %v", got)
+	}
+}
+
+// TestListenAllAddressInUse тестирует понятную ошибку, если порт уже занят другим процессом
+func TestListenAllAddressInUse(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer busy.Close()
+
+	addr := busy.Addr().String()
+	listeners, err := listenAll([]string{"127.0.0.1:0", addr})
+	if err == nil {
+		for _, l := range listeners {
+			l.Close()
+		}
+		t.Fatalf("Expected an error for busy address %s", addr)
+	}
+	if msg := err.Error(); !strings.Contains(msg, addr) || !strings.Contains(msg, "already in use") || !strings.Contains(msg, "PORT") {
+		t.Errorf("Expected an actionable message naming %s and PORT, got %q", addr, msg)
+	}
+
+	// Прочие ошибки не подменяются
+	other := errors.New("permission denied")
+	if got := listenError(addr, "PORT", other); got != other {
+		t.Errorf("Expected the original error, got %v", got)
+	}
+	if got := listenError(addr, "PORT", &net.OpError{Op: "listen", Err: syscall.EADDRINUSE}); !strings.Contains(got.Error(), "already in use") {
+		t.Errorf("Expected EADDRINUSE to be explained, got %v", got)
+	}
+}
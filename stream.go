@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultStreamHeartbeatInterval - период комментариев-пульсов в /numbers/stream по умолчанию
+const defaultStreamHeartbeatInterval = 15 * time.Second
+
+// streamBufferSize - количество значений, которые подписчик может не прочитать до отключения
+const streamBufferSize = 256
+
+// numberStream рассылает значения, добавленные этим экземпляром сервиса, подписчикам
+// GET /numbers/stream. Вставки не ждут подписчиков: канал подписчика, который не успевает
+// читать, закрывается
+type numberStream struct {
+	mu          sync.Mutex
+	subscribers map[chan int64]struct{}
+	closed      bool
+}
+
+// newNumberStream создает рассылку без подписчиков
+func newNumberStream() *numberStream {
+	return &numberStream{subscribers: make(map[chan int64]struct{})}
+}
+
+// subscribe возвращает канал новых значений и функцию отписки. Канал закрывается при
+// переполнении и при остановке рассылки
+func (s *numberStream) subscribe() (<-chan int64, func()) {
+	ch := make(chan int64, streamBufferSize)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	s.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subscribers[ch]; ok {
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// publish отправляет значения подписчикам без ожидания. Безопасно вызывать для nil
+func (s *numberStream) publish(values ...int64) {
+	if s == nil || len(values) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subscribers {
+		for _, v := range values {
+			select {
+			case ch <- v:
+				continue
+			default:
+			}
+			delete(s.subscribers, ch)
+			close(ch)
+			break
+		}
+	}
+}
+
+// close отключает всех подписчиков, чтобы открытые потоки не задерживали остановку сервера
+func (s *numberStream) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for ch := range s.subscribers {
+		delete(s.subscribers, ch)
+		close(ch)
+	}
+}
+
+// handleStream держит соединение открытым и отправляет каждое значение, добавленное этим
+// экземпляром сервиса после подключения, событием Server-Sent Events:
+//
+//	event: number
+//	data: {"value":5}
+//
+// Значения, добавленные другими экземплярами, в поток не попадают. Каждые
+// STREAM_HEARTBEAT_INTERVAL отправляется комментарий, чтобы прокси не закрывали простаивающее
+// соединение. Если клиент не успевает читать или сервис останавливается, отправляется событие
+// reset и поток завершается: события могли быть пропущены, поэтому после переподключения
+// состояние нужно перечитать через GET /numbers
+func (app *App) handleStream(w http.ResponseWriter, r *http.Request) {
+	if app.Stream == nil {
+		http.Error(w, "Stream is not available", http.StatusServiceUnavailable)
+		return
+	}
+	events, unsubscribe := app.Stream.subscribe()
+	defer unsubscribe()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Отключает буферизацию ответа в nginx
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(app.streamHeartbeatInterval())
+	defer heartbeat.Stop()
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		case n, ok := <-events:
+			if !ok {
+				fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+				rc.Flush()
+				return
+			}
+			_, err = fmt.Fprintf(w, "event: number\ndata: {\"value\":%d}\n\n", n)
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// streamHeartbeatInterval возвращает период пульсов /numbers/stream
+func (app *App) streamHeartbeatInterval() time.Duration {
+	if app.Config.StreamHeartbeatInterval <= 0 {
+		return defaultStreamHeartbeatInterval
+	}
+	return app.Config.StreamHeartbeatInterval
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNumberStream тестирует рассылку, отключение отстающего подписчика и остановку
+func TestNumberStream(t *testing.T) {
+	var nilStream *numberStream
+	nilStream.publish(1)
+
+	s := newNumberStream()
+	fast, unsubscribeFast := s.subscribe()
+	slow, _ := s.subscribe()
+
+	s.publish(1, 2)
+	if got := []int64{<-fast, <-fast}; !reflect.DeepEqual(got, []int64{1, 2}) {
+		t.Errorf("Expected [1 2], got %v", got)
+	}
+
+	// Второй подписчик не читает: его буфер переполняется, и канал закрывается
+	for i := 0; i < streamBufferSize; i++ {
+		s.publish(int64(i))
+		<-fast
+	}
+	count := 0
+	for range slow {
+		count++
+	}
+	if count != streamBufferSize {
+		t.Errorf("Expected %d buffered values before close, got %d", streamBufferSize, count)
+	}
+
+	unsubscribeFast()
+	unsubscribeFast()
+	if _, ok := <-fast; ok {
+		t.Error("Expected the unsubscribed channel to be closed")
+	}
+
+	late, _ := s.subscribe()
+	s.close()
+	if _, ok := <-late; ok {
+		t.Error("Expected close to disconnect subscribers")
+	}
+	if closed, _ := s.subscribe(); closed != nil {
+		if _, ok := <-closed; ok {
+			t.Error("Expected subscribe after close to return a closed channel")
+		}
+	}
+}
+
+// readEvent читает одно событие SSE, пропуская комментарии-пульсы
+func readEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+	var event, data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && event != "":
+			return event, data
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// TestStreamEndpoint тестирует доставку значений из POST /numbers и пакетной вставки в
+// открытый поток и его завершение при остановке
+func TestStreamEndpoint(t *testing.T) {
+	fc := &fakeConnector{
+		exec: func(string, []driver.NamedValue) (driver.Result, error) {
+			return driver.RowsAffected(1), nil
+		},
+		query: func(string, []driver.NamedValue) (*fakeRows, error) {
+			return &fakeRows{columns: []string{"value"}}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc), Stream: newNumberStream(), Config: Config{StreamHeartbeatInterval: 10 * time.Millisecond}}
+	srv := httptest.NewServer(app.routes())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/numbers/stream")
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); resp.StatusCode != http.StatusOK || ct != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %d %q", resp.StatusCode, ct)
+	}
+
+	if _, err := http.Post(srv.URL+"/numbers?number=4", "", nil); err != nil {
+		t.Fatalf("Failed to add number: %v", err)
+	}
+	if _, err := http.Post(srv.URL+"/numbers/batch", "application/json", strings.NewReader("[5, -6]")); err != nil {
+		t.Fatalf("Failed to add batch: %v", err)
+	}
+
+	r := bufio.NewReader(resp.Body)
+	for _, want := range []string{`{"value":4}`, `{"value":5}`, `{"value":-6}`} {
+		if event, data := readEvent(t, r); event != "number" || data != want {
+			t.Errorf("Expected number event %s, got %s %s", want, event, data)
+		}
+	}
+
+	app.Stream.close()
+	if event, _ := readEvent(t, r); event != "reset" {
+		t.Errorf("Expected a reset event on shutdown, got %s", event)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/numbers/stream", nil)
+	w := httptest.NewRecorder()
+	(&App{}).routes().ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d without a stream, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// maxTagsPerNumber ограничивает количество меток одного числа
+	maxTagsPerNumber = 16
+
+	// maxTagLength ограничивает длину метки в байтах
+	maxTagLength = 64
+)
+
+// normalizeTags проверяет метки добавляемого числа и убирает повторы, сохраняя порядок
+func normalizeTags(tags []string) ([]string, error) {
+	var result []string
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if tag == "" || len(tag) > maxTagLength {
+			return nil, fmt.Errorf("tags must be non-empty strings of at most %d bytes", maxTagLength)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+	if len(result) > maxTagsPerNumber {
+		return nil, fmt.Errorf("a number can have at most %d tags", maxTagsPerNumber)
+	}
+	return result, nil
+}
+
+// parseTagFilter разбирает повторяемый параметр tag: остаются числа со всеми указанными метками.
+// Метки есть только у чисел основной таблицы, поэтому для списков фильтр отклоняется
+func parseTagFilter(q url.Values, f *numberFilter) error {
+	tags := q["tag"]
+	if len(tags) == 0 {
+		return nil
+	}
+	if f.List != "" {
+		return fmt.Errorf("tag filter is not supported for lists")
+	}
+	for _, tag := range tags {
+		if tag == "" {
+			return fmt.Errorf("tag must not be empty")
+		}
+	}
+	f.Tags = tags
+	return nil
+}
+
+// insertTags сохраняет метки числа с указанным id в транзакции tx
+func insertTags(tx *sql.Tx, id int64, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	_, err := tx.Exec("INSERT INTO number_tags (number_id, tag) SELECT $1, unnest($2::text[])", id, pq.Array(tags))
+	return err
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// TestNormalizeTags тестирует удаление повторов и проверку меток
+func TestNormalizeTags(t *testing.T) {
+	tags, err := normalizeTags([]string{"lab", "field", "lab"})
+	if err != nil || !reflect.DeepEqual(tags, []string{"lab", "field"}) {
+		t.Errorf("Expected [lab field], got %v (%v)", tags, err)
+	}
+
+	tooMany := make([]string, maxTagsPerNumber+1)
+	for i := range tooMany {
+		tooMany[i] = strings.Repeat("t", i+1)
+	}
+	for _, invalid := range [][]string{{""}, {strings.Repeat("x", maxTagLength+1)}, tooMany} {
+		if _, err := normalizeTags(invalid); err == nil {
+			t.Errorf("Expected an error for %d tags %q", len(invalid), invalid[0])
+		}
+	}
+}
+
+// TestTagsFilter тестирует сохранение меток и фильтр GET /numbers?tag=
+func TestTagsFilter(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.Exec("DELETE FROM numbers")
+	app := &App{DB: db}
+
+	for _, body := range []string{
+		`{"number": 1, "tags": ["lab"]}`,
+		`{"number": 2, "tags": ["field", "lab"]}`,
+		`{"number": 3}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/numbers", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+	}
+
+	for target, expected := range map[string][]int64{
+		"/numbers?tag=lab":           {1, 2},
+		"/numbers?tag=lab&tag=field": {2},
+		"/numbers?tag=other":         {},
+	} {
+		w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, target, nil))
+		var response NumbersResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("%s: failed to decode response: %v", target, err)
+		}
+		if !reflect.DeepEqual(response.Numbers, expected) {
+			t.Errorf("%s: expected %v, got %v", target, expected, response.Numbers)
+		}
+	}
+}
+
+// TestAddNumberWithTagsFake тестирует вставку меток в транзакции с числом и условие фильтра
+func TestAddNumberWithTagsFake(t *testing.T) {
+	var (
+		tagArgs atomic.Value
+		query   atomic.Value
+	)
+	fc := &fakeConnector{
+		exec: func(q string, args []driver.NamedValue) (driver.Result, error) {
+			if strings.HasPrefix(q, "INSERT INTO number_tags") {
+				tagArgs.Store([]driver.Value{args[0].Value, args[1].Value})
+			}
+			return driver.RowsAffected(1), nil
+		},
+		query: func(q string, args []driver.NamedValue) (*fakeRows, error) {
+			if strings.HasPrefix(q, "INSERT INTO numbers") {
+				return &fakeRows{columns: []string{"id"}, values: [][]driver.Value{{int64(7)}}}, nil
+			}
+			query.Store(q)
+			return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{int64(5)}}}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	req := httptest.NewRequest(http.MethodPost, "/numbers", strings.NewReader(`{"number": 5, "tags": ["lab", "field", "lab"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := serveChecked(t, app.routes(), req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if got, _ := tagArgs.Load().([]driver.Value); !reflect.DeepEqual(got, []driver.Value{int64(7), "{\"lab\",\"field\"}"}) {
+		t.Errorf("Expected tags lab and field for id 7, got %v", got)
+	}
+
+	w = serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers?tag=lab", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if q, _ := query.Load().(string); !strings.Contains(q, "t.number_id = live_numbers.id AND t.tag = $1") {
+		t.Errorf("Expected a tag condition, got %q", q)
+	}
+
+	for _, tt := range []struct {
+		method, target, body string
+	}{
+		{http.MethodPost, "/numbers", `{"number": 5, "tags": [""]}`},
+		{http.MethodGet, "/numbers?tag=", ""},
+		{http.MethodGet, "/lists/a/numbers?tag=lab", ""},
+		{http.MethodPost, "/lists/a/numbers?number=1&tag=lab", ""},
+	} {
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		if tt.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s %s %s: expected status %d, got %d", tt.method, tt.target, tt.body, http.StatusBadRequest, w.Code)
+		}
+	}
+}
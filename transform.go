@@ -0,0 +1,672 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// MirroredValue представляет значение, отраженное относительно среднего: 2*mean - value
+type MirroredValue struct {
+	Value    int64   `json:"value"`
+	Mirrored float64 `json:"mirrored"`
+}
+
+// handleMirror возвращает каждое значение вместе с его отражением относительно среднего
+// по всей таблице. Хранимые данные не изменяются; среднее отраженных значений совпадает с исходным
+func (app *App) handleMirror(w http.ResponseWriter, r *http.Request) {
+	rows, err := app.DB.Query(`
+		SELECT value, (2 * AVG(value) OVER () - value)::float8
+		FROM live_numbers
+		ORDER BY value ASC, id ASC`)
+	if err != nil {
+		log.Printf("Error mirroring numbers: %v", err)
+		http.Error(w, "Failed to mirror numbers", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	result := []MirroredValue{}
+	for rows.Next() {
+		var item MirroredValue
+		if err := rows.Scan(&item.Value, &item.Mirrored); err != nil {
+			log.Printf("Error scanning mirrored value: %v", err)
+			http.Error(w, "Failed to mirror numbers", http.StatusInternalServerError)
+			return
+		}
+		result = append(result, item)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating mirrored values: %v", err)
+		http.Error(w, "Failed to mirror numbers", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+// RebasedValue представляет значение, сдвинутое так, чтобы минимум стал нулем
+type RebasedValue struct {
+	Value   int64  `json:"value"`
+	Rebased uint64 `json:"rebased"`
+}
+
+// handleRebase возвращает каждое значение за вычетом минимального: value - MIN(value).
+// Разность считается в numeric, так как для крайних значений bigint она не помещается в bigint,
+// но всегда помещается в uint64. Хранимые данные не изменяются
+func (app *App) handleRebase(w http.ResponseWriter, r *http.Request) {
+	rows, err := app.DB.Query(`
+		SELECT value, value::numeric - MIN(value) OVER ()
+		FROM live_numbers
+		ORDER BY value ASC, id ASC`)
+	if err != nil {
+		log.Printf("Error rebasing numbers: %v", err)
+		http.Error(w, "Failed to rebase numbers", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	result := []RebasedValue{}
+	for rows.Next() {
+		var item RebasedValue
+		if err := rows.Scan(&item.Value, &item.Rebased); err != nil {
+			log.Printf("Error scanning rebased value: %v", err)
+			http.Error(w, "Failed to rebase numbers", http.StatusInternalServerError)
+			return
+		}
+		result = append(result, item)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating rebased values: %v", err)
+		http.Error(w, "Failed to rebase numbers", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+// ValueShare представляет долю значения в общей сумме, в процентах
+type ValueShare struct {
+	Value   int64   `json:"value"`
+	Percent float64 `json:"percent"`
+}
+
+// handleShares возвращает долю каждого значения в сумме всех значений: value / SUM(value) * 100,
+// округленную до двух знаков. Если сумма равна нулю (пустая таблица или одни нули), доли равны 0.
+// Доля считается от алгебраической суммы, поэтому у отрицательных значений она отрицательна,
+// а доли положительных значений могут превышать 100; сумма всех долей по-прежнему равна 100
+func (app *App) handleShares(w http.ResponseWriter, r *http.Request) {
+	rows, err := app.DB.Query(`
+		SELECT value, COALESCE(ROUND(value * 100.0 / NULLIF(SUM(value) OVER (), 0), 2), 0)::float8
+		FROM live_numbers
+		ORDER BY value ASC, id ASC`)
+	if err != nil {
+		log.Printf("Error computing shares: %v", err)
+		http.Error(w, "Failed to compute shares", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	result := []ValueShare{}
+	for rows.Next() {
+		var item ValueShare
+		if err := rows.Scan(&item.Value, &item.Percent); err != nil {
+			log.Printf("Error scanning share: %v", err)
+			http.Error(w, "Failed to compute shares", http.StatusInternalServerError)
+			return
+		}
+		result = append(result, item)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating shares: %v", err)
+		http.Error(w, "Failed to compute shares", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+// ScaledValue представляет значение, умноженное на коэффициент
+type ScaledValue struct {
+	Value  int64   `json:"value"`
+	Scaled float64 `json:"scaled"`
+}
+
+// handleScale возвращает отсортированные значения, умноженные на коэффициент factor.
+// Преобразование выполняется только в ответе, хранимые данные не изменяются. Ответ
+// передается потоково через streamPreview
+func (app *App) handleScale(w http.ResponseWriter, r *http.Request) {
+	factor, err := strconv.ParseFloat(r.URL.Query().Get("factor"), 64)
+	if err != nil || math.IsNaN(factor) || math.IsInf(factor, 0) {
+		http.Error(w, "factor must be a finite number", http.StatusBadRequest)
+		return
+	}
+
+	err = app.streamPreview(w, r, func(n int64) (interface{}, error) {
+		return ScaledValue{Value: n, Scaled: float64(n) * factor}, nil
+	})
+	if err != nil {
+		log.Printf("Error getting numbers: %v", err)
+		http.Error(w, "Failed to retrieve numbers", http.StatusInternalServerError)
+	}
+}
+
+// streamPreview отправляет JSON массив convert(n) для всех чисел по мере их чтения через
+// scanNumbers, не собирая предпросмотр в памяти. Ошибка до отправки первой части возвращается
+// вызывающему; после начала ответа статус уже отправлен, поэтому ошибка записывается в журнал,
+// а массив обрывается
+func (app *App) streamPreview(w http.ResponseWriter, r *http.Request, convert func(n int64) (interface{}, error)) error {
+	bw := bufio.NewWriter(w)
+	started := false
+
+	// start отправляет заголовки ответа и начало массива
+	start := func() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		bw.WriteByte('[')
+		started = true
+	}
+
+	var part bytes.Buffer
+	err := app.scanNumbers(r.Context(), func(chunk []int64) error {
+		// Часть преобразуется целиком до записи, чтобы ошибка в первой части пришла до ответа
+		part.Reset()
+		for _, n := range chunk {
+			v, err := convert(n)
+			if err != nil {
+				return err
+			}
+			data, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			if started || part.Len() > 0 {
+				part.WriteByte(',')
+			}
+			part.Write(data)
+		}
+		if !started {
+			start()
+		}
+		bw.Write(part.Bytes())
+		return bw.Flush()
+	})
+	if err != nil && !started {
+		return err
+	}
+	if err != nil {
+		log.Printf("Preview aborted after the response started: %v", maskError(err))
+		return nil
+	}
+
+	if !started {
+		start()
+	}
+	bw.WriteString("]\n")
+	if err := bw.Flush(); err != nil {
+		log.Printf("Error writing preview: %v", err)
+	}
+	return nil
+}
+
+// AdjustedValue представляет значение, сдвинутое на константу
+type AdjustedValue struct {
+	Value    int64 `json:"value"`
+	Adjusted int64 `json:"adjusted"`
+}
+
+// errValueOverflow сообщает, что сдвиг дает значение вне диапазона bigint: такой же UPDATE
+// завершился бы ошибкой PostgreSQL
+var errValueOverflow = errors.New("value out of bigint range")
+
+// handleOffset возвращает значения, сдвинутые на целое число by. По умолчанию это только
+// предпросмотр; с commit=true (только методом POST) сдвиг сохраняется одним UPDATE в транзакции.
+// Переполнение дает 422, новое значение, не прошедшее валидаторы, - 400
+func (app *App) handleOffset(w http.ResponseWriter, r *http.Request) {
+	by, err := strconv.ParseInt(r.URL.Query().Get("by"), 10, 64)
+	if err != nil {
+		http.Error(w, "by must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	commit := r.URL.Query().Get("commit") == "true"
+	if !commitMethodAllowed(w, r, commit) {
+		http.Error(w, "Use POST with commit=true to persist the offset and GET to preview it", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var result []AdjustedValue
+	if commit {
+		result, err = app.commitOffset(by)
+	} else {
+		err = app.previewOffset(w, r, by)
+	}
+	var verr validationError
+	if errors.As(err, &verr) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if isOutOfRangeError(err) || errors.Is(err, errValueOverflow) {
+		http.Error(w, "Offset would overflow stored values", http.StatusUnprocessableEntity)
+		return
+	}
+	if isUniqueViolation(err) {
+		http.Error(w, "Offset values would repeat in UNIQUE_NUMBERS mode", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("Error applying offset: %v", maskError(err))
+		http.Error(w, "Failed to apply offset", http.StatusInternalServerError)
+		return
+	}
+
+	if commit {
+		writeJSON(w, result)
+	}
+}
+
+// commitMethodAllowed проверяет, что сохранение (commit=true) запрошено методом POST, а
+// предпросмотр - методом GET. Иначе устанавливает заголовок Allow с подходящим методом
+func commitMethodAllowed(w http.ResponseWriter, r *http.Request, commit bool) bool {
+	method := http.MethodGet
+	if commit {
+		method = http.MethodPost
+	}
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	return false
+}
+
+// previewOffset отправляет сдвинутые значения через streamPreview, не изменяя данные.
+// Переполнение проверяется по крайним значениям до начала ответа, чтобы вернуть ошибку, а не
+// оборвать массив
+func (app *App) previewOffset(w http.ResponseWriter, r *http.Request, by int64) error {
+	var min, max sql.NullInt64
+	if err := app.DB.QueryRowContext(r.Context(), "SELECT MIN(value), MAX(value) FROM live_numbers").Scan(&min, &max); err != nil {
+		return err
+	}
+	for _, bound := range []sql.NullInt64{min, max} {
+		if _, err := offsetValue(bound.Int64, by); bound.Valid && err != nil {
+			return err
+		}
+	}
+
+	return app.streamPreview(w, r, func(n int64) (interface{}, error) {
+		adjusted, err := offsetValue(n, by)
+		return AdjustedValue{Value: n, Adjusted: adjusted}, err
+	})
+}
+
+// offsetValue возвращает n + by или errValueOverflow, если сумма не помещается в int64
+func offsetValue(n, by int64) (int64, error) {
+	adjusted := n + by
+	if by > 0 && adjusted < n || by < 0 && adjusted > n {
+		return 0, errValueOverflow
+	}
+	return adjusted, nil
+}
+
+// commitOffset сдвигает все хранимые значения в одной транзакции и возвращает старые и новые значения.
+// Сдвиг отклоняется целиком, если хотя бы одно новое значение не помещается в int64 или не проходит
+// валидаторы. Версия каждой записи увеличивается, как при PATCH, и ETag, полученный до сдвига,
+// устаревает
+func (app *App) commitOffset(by int64) ([]AdjustedValue, error) {
+	tx, err := app.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := app.purgeExpiredForUpdate(tx); err != nil {
+		return nil, err
+	}
+	if err := app.checkNewValues(tx, func(n int64) (int64, error) { return offsetValue(n, by) }); err != nil {
+		return nil, err
+	}
+
+	var result []AdjustedValue
+	if app.Config.UniqueNumbers {
+		result, err = shiftValuesInOrder(tx, by)
+	} else {
+		result, err = shiftValues(tx, by)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	app.dataChanged()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Value < result[j].Value })
+	return result, nil
+}
+
+// checkNewValues проверяет в транзакции валидаторами новое значение каждой живой строки,
+// вычисленное change, и останавливается на первом нарушении. Строки блокируются до конца
+// транзакции, поэтому проверенные значения не изменятся до UPDATE
+func (app *App) checkNewValues(tx *sql.Tx, change func(int64) (int64, error)) error {
+	rows, err := tx.Query("SELECT value FROM numbers WHERE expires_at IS NULL OR expires_at > now() FOR UPDATE")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var n int64
+		if err := rows.Scan(&n); err != nil {
+			return err
+		}
+		changed, err := change(n)
+		if err != nil {
+			return err
+		}
+		if err := app.validateNumber(changed); err != nil {
+			return validationError{fmt.Errorf("value %d would become %d: %w", n, changed, err)}
+		}
+	}
+	return rows.Err()
+}
+
+// shiftValues сдвигает все живые значения одним UPDATE
+func shiftValues(tx *sql.Tx, by int64) ([]AdjustedValue, error) {
+	rows, err := tx.Query(`
+		UPDATE numbers SET value = value + $1, version = version + 1
+		WHERE expires_at IS NULL OR expires_at > now()
+		RETURNING value - $1, value`, by)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []AdjustedValue{}
+	for rows.Next() {
+		var item AdjustedValue
+		if err := rows.Scan(&item.Value, &item.Adjusted); err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	return result, rows.Err()
+}
+
+// shiftValuesInOrder сдвигает значения по одной строке, начиная с края, в сторону которого идет
+// сдвиг. Уникальный индекс проверяется после каждой строки и не откладывается до конца
+// транзакции, поэтому один UPDATE по соседним значениям (1 и 2 при by=1) столкнулся бы на
+// промежуточном состоянии. В этом порядке новое значение строки всегда уже освобождено
+func shiftValuesInOrder(tx *sql.Tx, by int64) ([]AdjustedValue, error) {
+	order := "ASC"
+	if by > 0 {
+		order = "DESC"
+	}
+	// Истекшие строки уже удалены purgeExpiredForUpdate
+	rows, err := tx.Query("SELECT id FROM numbers ORDER BY value " + order + " FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	rows.Close()
+
+	result := make([]AdjustedValue, len(ids))
+	for i, id := range ids {
+		err := tx.QueryRow("UPDATE numbers SET value = value + $1, version = version + 1 WHERE id = $2 RETURNING value - $1, value", by, id).
+			Scan(&result[i].Value, &result[i].Adjusted)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// ReducedValue представляет значение, приведенное по модулю
+type ReducedValue struct {
+	Value   int64 `json:"value"`
+	Reduced int64 `json:"reduced"`
+}
+
+// handleMod возвращает значения, приведенные по положительному модулю m. Остаток всегда
+// неотрицателен (от 0 до m-1), в том числе для отрицательных значений: -3 mod 10 = 7.
+// По умолчанию это только предпросмотр; с commit=true (только методом POST) приведение
+// сохраняется одним UPDATE в транзакции. Сохранение отклоняется с 409 в режиме MONOTONIC и
+// в режиме UNIQUE_NUMBERS, если два значения дают один остаток, и с 400, если остаток не
+// проходит валидаторы
+func (app *App) handleMod(w http.ResponseWriter, r *http.Request) {
+	m, err := strconv.ParseInt(r.URL.Query().Get("m"), 10, 64)
+	if err != nil || m <= 0 {
+		http.Error(w, "m must be a positive integer", http.StatusBadRequest)
+		return
+	}
+
+	commit := r.URL.Query().Get("commit") == "true"
+	if !commitMethodAllowed(w, r, commit) {
+		http.Error(w, "Use POST with commit=true to persist the reduction and GET to preview it", http.StatusMethodNotAllowed)
+		return
+	}
+	// Приведение нарушило бы возрастающий порядок вставок, как изменение через PATCH
+	if commit && app.Config.Monotonic {
+		http.Error(w, "Numbers cannot be changed in MONOTONIC mode", http.StatusConflict)
+		return
+	}
+
+	var result []ReducedValue
+	if commit {
+		result, err = app.commitMod(m)
+	} else {
+		err = app.previewMod(w, r, m)
+	}
+	var verr validationError
+	if errors.As(err, &verr) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if isOutOfRangeError(err) {
+		http.Error(w, "Reduced values would not fit the stored integer type", http.StatusUnprocessableEntity)
+		return
+	}
+	if isUniqueViolation(err) {
+		http.Error(w, "Reduced values would repeat in UNIQUE_NUMBERS mode", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("Error applying modulo reduction: %v", maskError(err))
+		http.Error(w, "Failed to apply modulo reduction", http.StatusInternalServerError)
+		return
+	}
+
+	if commit {
+		writeJSON(w, result)
+	}
+}
+
+// previewMod отправляет приведенные значения через streamPreview, не изменяя данные
+func (app *App) previewMod(w http.ResponseWriter, r *http.Request, m int64) error {
+	return app.streamPreview(w, r, func(n int64) (interface{}, error) {
+		return ReducedValue{Value: n, Reduced: euclideanMod(n, m)}, nil
+	})
+}
+
+// commitMod приводит все хранимые значения по модулю в одной транзакции и возвращает старые и
+// новые значения. Оператор % в PostgreSQL сохраняет знак делимого, поэтому к отрицательному
+// остатку прибавляется m. Приведение отклоняется целиком, если хотя бы один остаток не проходит
+// валидаторы. Версия каждой записи увеличивается, как в commitOffset
+func (app *App) commitMod(m int64) ([]ReducedValue, error) {
+	tx, err := app.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := app.purgeExpiredForUpdate(tx); err != nil {
+		return nil, err
+	}
+	if err := app.checkNewValues(tx, func(n int64) (int64, error) { return euclideanMod(n, m), nil }); err != nil {
+		return nil, err
+	}
+
+	// Приведение необратимо, поэтому старое значение берется из самосоединения
+	rows, err := tx.Query(`
+		UPDATE numbers n
+		SET value = n.value % $1 + CASE WHEN n.value % $1 < 0 THEN $1 ELSE 0 END, version = n.version + 1
+		FROM numbers old
+		WHERE old.id = n.id AND (n.expires_at IS NULL OR n.expires_at > now())
+		RETURNING old.value, n.value`, m)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []ReducedValue{}
+	for rows.Next() {
+		var item ReducedValue
+		if err := rows.Scan(&item.Value, &item.Reduced); err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	app.dataChanged()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Value < result[j].Value })
+	return result, nil
+}
+
+// euclideanMod возвращает неотрицательный остаток от деления n на положительное m
+func euclideanMod(n, m int64) int64 {
+	r := n % m
+	if r < 0 {
+		r += m
+	}
+	return r
+}
+
+// maxFactorizeValue ограничивает значения для разложения: пробное деление выполняет не больше
+// sqrt(maxFactorizeValue) шагов на значение
+const maxFactorizeValue = 1_000_000_000_000
+
+// maxFactorizeRows ограничивает количество значений, раскладываемых за один запрос
+const maxFactorizeRows = 10000
+
+// FactorizedValue представляет значение и его простые множители по возрастанию
+type FactorizedValue struct {
+	Value   int64   `json:"value"`
+	Factors []int64 `json:"factors"`
+}
+
+// handleFactorize возвращает разложение на простые множители для каждого положительного
+// значения не больше maxFactorizeValue. Неположительные и слишком большие значения пропускаются.
+// Параметры limit (не больше maxFactorizeRows) и offset задают страницу; без limit запрос,
+// в котором больше maxFactorizeRows значений, отклоняется с 413
+func (app *App) handleFactorize(w http.ResponseWriter, r *http.Request) {
+	limit, offset := maxFactorizeRows, 0
+	paged := r.URL.Query().Has("limit")
+	if paged {
+		n, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil || n <= 0 || n > maxFactorizeRows {
+			http.Error(w, fmt.Sprintf("limit must be an integer between 1 and %d", maxFactorizeRows), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "offset must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		offset = n
+	}
+
+	numbers, err := app.queryNumbers("SELECT value FROM live_numbers WHERE value > 0 AND value <= $1::bigint ORDER BY value ASC LIMIT $2 OFFSET $3", maxFactorizeValue, limit+1, offset)
+	if err != nil {
+		log.Printf("Error getting numbers for factorization: %v", err)
+		http.Error(w, "Failed to factorize numbers", http.StatusInternalServerError)
+		return
+	}
+	if len(numbers) > limit {
+		if !paged {
+			http.Error(w, fmt.Sprintf("Too many values to factorize (more than %d), use limit and offset", maxFactorizeRows), http.StatusRequestEntityTooLarge)
+			return
+		}
+		numbers = numbers[:limit]
+	}
+
+	result := make([]FactorizedValue, len(numbers))
+	for i, n := range numbers {
+		result[i] = FactorizedValue{Value: n, Factors: primeFactors(n)}
+	}
+
+	writeJSON(w, result)
+}
+
+// primeFactors раскладывает положительное число на простые множители пробным делением.
+// Для 1 возвращает пустой срез
+func primeFactors(n int64) []int64 {
+	factors := []int64{}
+	for p := int64(2); p*p <= n; p++ {
+		for n%p == 0 {
+			factors = append(factors, p)
+			n /= p
+		}
+	}
+	if n > 1 {
+		factors = append(factors, n)
+	}
+	return factors
+}
+
+// handleGrayOrder возвращает неотрицательные значения в порядке кода Грея: значение v стоит на
+// позиции grayRank(v) последовательности 0, 1, 3, 2, 6, 7, 5, 4, ..., где соседние коды
+// отличаются одним битом. Если хранятся все коды подряд, соседние значения ответа тоже
+// отличаются одним битом; пропуски в данных нарушают это свойство. Отрицательные значения
+// исключаются, повторы идут подряд
+func (app *App) handleGrayOrder(w http.ResponseWriter, r *http.Request) {
+	numbers, err := app.queryNumbers("SELECT value FROM live_numbers WHERE value >= 0 ORDER BY value ASC")
+	if err != nil {
+		log.Printf("Error getting numbers for Gray code order: %v", err)
+		http.Error(w, "Failed to retrieve numbers", http.StatusInternalServerError)
+		return
+	}
+
+	sort.SliceStable(numbers, func(i, j int) bool { return grayRank(numbers[i]) < grayRank(numbers[j]) })
+	writeJSON(w, NumbersResponse{Numbers: numbers})
+}
+
+// grayRank возвращает позицию неотрицательного числа в последовательности кодов Грея, то есть
+// обратное преобразование к g = n ^ (n >> 1): n - это XOR всех сдвигов g вправо
+func grayRank(g int64) int64 {
+	n := g
+	for shift := 1; shift < 64; shift <<= 1 {
+		n ^= n >> shift
+	}
+	return n
+}
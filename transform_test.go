@@ -0,0 +1,901 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+// TestMirror тестирует отражение значений относительно среднего
+func TestMirror(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	// Среднее равно 5
+	for _, num := range []int{3, 5, 7, 1, 9} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/mirror", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result []MirroredValue
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &result)
+
+	expected := []MirroredValue{{1, 9}, {3, 7}, {5, 5}, {7, 3}, {9, 1}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+
+	// Среднее отраженных значений совпадает с исходным
+	var sum float64
+	for _, item := range result {
+		sum += item.Mirrored
+	}
+	if mean := sum / float64(len(result)); mean != 5 {
+		t.Errorf("Expected mirrored mean 5, got %v", mean)
+	}
+}
+
+// TestMirrorEmpty тестирует пустой ответ для пустой таблицы
+func TestMirrorEmpty(t *testing.T) {
+	app := &App{DB: newFakeDB(t, &fakeConnector{})}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/mirror", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if body := w.Body.String(); body != "[]\n" {
+		t.Errorf("Expected empty array, got %q", body)
+	}
+}
+
+// TestScale тестирует умножение значений на коэффициент без изменения хранимых данных
+func TestScale(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{4, -2, 0} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/scale?factor=2.5", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result []ScaledValue
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &result)
+
+	expected := []ScaledValue{{-2, -5}, {0, 0}, {4, 10}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+
+	// Хранимые данные не изменились
+	numbers, err := app.getAllNumbers()
+	if err != nil {
+		t.Fatalf("Failed to get numbers: %v", err)
+	}
+	if !reflect.DeepEqual(numbers, []int64{-2, 0, 4}) {
+		t.Errorf("Expected storage to be untouched, got %v", numbers)
+	}
+}
+
+// TestScaleInvalidFactor тестирует проверку коэффициента
+func TestScaleInvalidFactor(t *testing.T) {
+	app := &App{}
+
+	for _, factor := range []string{"", "abc", "NaN", "Inf"} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/scale?factor="+factor, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("factor=%q: expected status %d, got %d", factor, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestOffsetPreview тестирует предпросмотр сдвига без изменения данных
+func TestOffsetPreview(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{3, -1} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/offset?by=5", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result []AdjustedValue
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &result)
+
+	expected := []AdjustedValue{{-1, 4}, {3, 8}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+
+	numbers, _ := app.getAllNumbers()
+	if !reflect.DeepEqual(numbers, []int64{-1, 3}) {
+		t.Errorf("Expected storage to be untouched, got %v", numbers)
+	}
+}
+
+// TestOffsetCommit тестирует сохранение сдвига
+func TestOffsetCommit(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{3, -1} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers/offset?by=-2&commit=true", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result []AdjustedValue
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	expected := []AdjustedValue{{-1, -3}, {3, 1}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+
+	numbers, _ := app.getAllNumbers()
+	if !reflect.DeepEqual(numbers, []int64{-3, 1}) {
+		t.Errorf("Expected stored values [-3 1], got %v", numbers)
+	}
+}
+
+// TestOffsetCommitUnique тестирует сдвиг соседних значений под уникальным индексом
+func TestOffsetCommitUnique(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.Exec("DELETE FROM numbers")
+	if err := createUniqueValueIndex(db); err != nil {
+		t.Fatalf("Failed to create unique index: %v", err)
+	}
+	defer db.Exec("DROP INDEX IF EXISTS idx_numbers_value_unique")
+
+	app := &App{DB: db, Config: Config{UniqueNumbers: true}}
+	for _, num := range []int{1, 2, 3} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	for _, by := range []string{"1", "-1"} {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers/offset?by="+by+"&commit=true", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("by=%s: expected status %d, got %d: %s", by, http.StatusOK, w.Code, w.Body.String())
+		}
+	}
+
+	numbers, _ := app.getAllNumbers()
+	if !reflect.DeepEqual(numbers, []int64{1, 2, 3}) {
+		t.Errorf("Expected stored values [1 2 3], got %v", numbers)
+	}
+}
+
+// TestOffsetCommitOrder тестирует порядок построчного сдвига в режиме UNIQUE_NUMBERS и ответ 409
+// на совпадение значений
+func TestOffsetCommitOrder(t *testing.T) {
+	var (
+		selects []string
+		updates int
+	)
+	fc := &fakeConnector{
+		query: func(q string, args []driver.NamedValue) (*fakeRows, error) {
+			if strings.HasPrefix(q, "SELECT value") {
+				return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{int64(1)}, {int64(2)}}}, nil
+			}
+			if strings.HasPrefix(q, "SELECT id") {
+				selects = append(selects, q)
+				return &fakeRows{columns: []string{"id"}, values: [][]driver.Value{{int64(2)}, {int64(1)}}}, nil
+			}
+			updates++
+			if updates == 2 {
+				return nil, &pq.Error{Code: pgUniqueViolation}
+			}
+			return &fakeRows{columns: []string{"old", "new"}, values: [][]driver.Value{{int64(2), int64(3)}}}, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc), Config: Config{UniqueNumbers: true}}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers/offset?by=1&commit=true", nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+	}
+	if len(selects) != 1 || !strings.Contains(selects[0], "ORDER BY value DESC") {
+		t.Errorf("Expected rows to be shifted from the largest value, got %v", selects)
+	}
+}
+
+// TestOffsetCommitChecks тестирует проверку новых значений в транзакции сохранения: переполнение
+// дает 422, значение, не прошедшее валидаторы, - 400, и UPDATE не выполняется
+func TestOffsetCommitChecks(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []int64
+		by     string
+		cfg    Config
+		status int
+	}{
+		{"overflow", []int64{1, math.MaxInt64 - 5}, "10", Config{}, http.StatusUnprocessableEntity},
+		{"underflow", []int64{math.MinInt64 + 5}, "-10", Config{}, http.StatusUnprocessableEntity},
+		{"negative", []int64{7, 3}, "-5", Config{RejectNegative: true}, http.StatusBadRequest},
+		{"parity", []int64{2}, "1", Config{Parity: "even"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updates int
+			fc := &fakeConnector{
+				query: func(q string, _ []driver.NamedValue) (*fakeRows, error) {
+					if !strings.HasPrefix(q, "SELECT value") {
+						updates++
+						return &fakeRows{columns: []string{"old", "new"}}, nil
+					}
+					rows := &fakeRows{columns: []string{"value"}}
+					for _, v := range tt.values {
+						rows.values = append(rows.values, []driver.Value{v})
+					}
+					return rows, nil
+				},
+			}
+			app := &App{DB: newFakeDB(t, fc), Config: tt.cfg, Validators: buildValidators(tt.cfg)}
+
+			w := httptest.NewRecorder()
+			app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers/offset?by="+tt.by+"&commit=true", nil))
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if updates != 0 {
+				t.Errorf("Expected no UPDATE after a failed check, got %d", updates)
+			}
+		})
+	}
+}
+
+// TestCommitBumpsVersion тестирует, что сохранение сдвига и приведения по модулю увеличивает
+// версию записей: PATCH с ETag, полученным до сохранения, получает 412
+func TestCommitBumpsVersion(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	tests := []struct {
+		name   string
+		target string
+		unique bool
+	}{
+		{"offset", "/numbers/offset?by=1&commit=true", false},
+		{"offset in order", "/numbers/offset?by=1&commit=true", true},
+		{"mod", "/numbers/mod?m=10&commit=true", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db.Exec("DELETE FROM numbers")
+			app := &App{DB: db, Config: Config{UniqueNumbers: tt.unique}}
+
+			var id int64
+			if err := db.QueryRow("INSERT INTO numbers (value) VALUES (23) RETURNING id").Scan(&id); err != nil {
+				t.Fatalf("Failed to insert number: %v", err)
+			}
+			target := "/numbers/" + strconv.FormatInt(id, 10)
+
+			w := httptest.NewRecorder()
+			app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+			etag := w.Header().Get("ETag")
+
+			w = httptest.NewRecorder()
+			app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.target, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+
+			req := httptest.NewRequest(http.MethodPatch, target, strings.NewReader(`{"value":5}`))
+			req.Header.Set("If-Match", etag)
+			w = httptest.NewRecorder()
+			app.routes().ServeHTTP(w, req)
+			if w.Code != http.StatusPreconditionFailed {
+				t.Errorf("Expected status %d for the pre-commit ETag %s, got %d", http.StatusPreconditionFailed, etag, w.Code)
+			}
+		})
+	}
+}
+
+// TestCommitBumpsVersionFake тестирует увеличение версии во всех UPDATE сохранения без
+// обращения к реальной базе
+func TestCommitBumpsVersionFake(t *testing.T) {
+	var updates []string
+	fc := &fakeConnector{
+		query: func(q string, _ []driver.NamedValue) (*fakeRows, error) {
+			if strings.HasPrefix(q, "SELECT id") {
+				return &fakeRows{columns: []string{"id"}, values: [][]driver.Value{{int64(1)}}}, nil
+			}
+			if strings.HasPrefix(q, "SELECT value") {
+				return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{int64(23)}}}, nil
+			}
+			updates = append(updates, q)
+			return &fakeRows{columns: []string{"old", "new"}, values: [][]driver.Value{{int64(23), int64(24)}}}, nil
+		},
+	}
+
+	for _, tt := range []struct {
+		target string
+		unique bool
+	}{
+		{"/numbers/offset?by=1&commit=true", false},
+		{"/numbers/offset?by=1&commit=true", true},
+		{"/numbers/mod?m=10&commit=true", false},
+	} {
+		updates = nil
+		app := &App{DB: newFakeDB(t, fc), Config: Config{UniqueNumbers: tt.unique}}
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d: %s", tt.target, http.StatusOK, w.Code, w.Body.String())
+		}
+		if len(updates) != 1 || !strings.Contains(updates[0], "version = ") {
+			t.Errorf("%s (unique=%v): expected the UPDATE to bump version, got %v", tt.target, tt.unique, updates)
+		}
+	}
+}
+
+// TestOffsetValidation тестирует проверку параметров сдвига
+func TestOffsetValidation(t *testing.T) {
+	app := &App{}
+
+	tests := []struct {
+		method string
+		target string
+		status int
+		allow  string
+	}{
+		{http.MethodGet, "/numbers/offset?by=1.5", http.StatusBadRequest, ""},
+		{http.MethodGet, "/numbers/offset", http.StatusBadRequest, ""},
+		{http.MethodGet, "/numbers/offset?by=1&commit=true", http.StatusMethodNotAllowed, "POST"},
+		{http.MethodPost, "/numbers/offset?by=1", http.StatusMethodNotAllowed, "GET"},
+		{http.MethodDelete, "/numbers/offset?by=1", http.StatusMethodNotAllowed, "GET, POST"},
+		{http.MethodPut, "/numbers/offset?by=1", http.StatusMethodNotAllowed, "GET, POST"},
+		{http.MethodPatch, "/numbers/offset?by=1", http.StatusMethodNotAllowed, "GET, POST"},
+		{http.MethodHead, "/numbers/offset?by=1", http.StatusMethodNotAllowed, "GET, POST"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+		if w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.target, tt.status, w.Code)
+		}
+		if allow := w.Header().Get("Allow"); allow != tt.allow {
+			t.Errorf("%s %s: expected Allow %q, got %q", tt.method, tt.target, tt.allow, allow)
+		}
+	}
+}
+
+// TestModPreview тестирует предпросмотр приведения по модулю без изменения данных
+func TestModPreview(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{23, -3, 10} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/mod?m=10", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result []ReducedValue
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &result)
+
+	expected := []ReducedValue{{-3, 7}, {10, 0}, {23, 3}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+
+	numbers, _ := app.getAllNumbers()
+	if !reflect.DeepEqual(numbers, []int64{-3, 10, 23}) {
+		t.Errorf("Expected storage to be untouched, got %v", numbers)
+	}
+}
+
+// TestModCommit тестирует сохранение приведения по модулю
+func TestModCommit(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{23, -3, 10} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers/mod?m=10&commit=true", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result []ReducedValue
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	expected := []ReducedValue{{-3, 7}, {10, 0}, {23, 3}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+
+	numbers, _ := app.getAllNumbers()
+	if !reflect.DeepEqual(numbers, []int64{0, 3, 7}) {
+		t.Errorf("Expected stored values [0 3 7], got %v", numbers)
+	}
+}
+
+// TestModCommitConflicts тестирует отказ в сохранении приведения в режиме MONOTONIC и при
+// совпадении остатков в режиме UNIQUE_NUMBERS
+func TestModCommitConflicts(t *testing.T) {
+	fc := &fakeConnector{
+		query: func(string, []driver.NamedValue) (*fakeRows, error) {
+			return nil, &pq.Error{Code: pgUniqueViolation, Message: `duplicate key value violates unique constraint "idx_numbers_value_unique"`}
+		},
+	}
+
+	for _, cfg := range []Config{{Monotonic: true}, {UniqueNumbers: true}} {
+		app := &App{DB: newFakeDB(t, fc), Config: cfg}
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers/mod?m=10&commit=true", nil))
+		if w.Code != http.StatusConflict {
+			t.Errorf("%+v: expected status %d, got %d: %s", cfg, http.StatusConflict, w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestModCommitChecks тестирует отказ в сохранении приведения, если остаток не проходит
+// валидаторы: UPDATE не выполняется
+func TestModCommitChecks(t *testing.T) {
+	var updates int
+	fc := &fakeConnector{
+		query: func(q string, _ []driver.NamedValue) (*fakeRows, error) {
+			if !strings.HasPrefix(q, "SELECT value") {
+				updates++
+				return &fakeRows{columns: []string{"old", "new"}}, nil
+			}
+			return &fakeRows{columns: []string{"value"}, values: [][]driver.Value{{int64(22)}, {int64(23)}}}, nil
+		},
+	}
+	cfg := Config{Parity: "even"}
+	app := &App{DB: newFakeDB(t, fc), Config: cfg, Validators: buildValidators(cfg)}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers/mod?m=10&commit=true", nil))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "value 23 would become 3") {
+		t.Errorf("Expected status %d for the odd remainder of 23, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+	if updates != 0 {
+		t.Errorf("Expected no UPDATE after a failed check, got %d", updates)
+	}
+}
+
+// TestModValidation тестирует отклонение нулевого и некорректного модуля
+func TestModValidation(t *testing.T) {
+	app := &App{}
+
+	tests := []struct {
+		method string
+		target string
+		status int
+		allow  string
+	}{
+		{http.MethodGet, "/numbers/mod?m=0", http.StatusBadRequest, ""},
+		{http.MethodPost, "/numbers/mod?m=0&commit=true", http.StatusBadRequest, ""},
+		{http.MethodGet, "/numbers/mod?m=-10", http.StatusBadRequest, ""},
+		{http.MethodGet, "/numbers/mod?m=2.5", http.StatusBadRequest, ""},
+		{http.MethodGet, "/numbers/mod", http.StatusBadRequest, ""},
+		{http.MethodGet, "/numbers/mod?m=10&commit=true", http.StatusMethodNotAllowed, "POST"},
+		{http.MethodPost, "/numbers/mod?m=10", http.StatusMethodNotAllowed, "GET"},
+		{http.MethodDelete, "/numbers/mod?m=10", http.StatusMethodNotAllowed, "GET, POST"},
+		{http.MethodPut, "/numbers/mod?m=10", http.StatusMethodNotAllowed, "GET, POST"},
+		{http.MethodHead, "/numbers/mod?m=10", http.StatusMethodNotAllowed, "GET, POST"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+		if w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.target, tt.status, w.Code)
+		}
+		if allow := w.Header().Get("Allow"); allow != tt.allow {
+			t.Errorf("%s %s: expected Allow %q, got %q", tt.method, tt.target, tt.allow, allow)
+		}
+	}
+}
+
+// TestEuclideanMod тестирует неотрицательный остаток для отрицательных значений
+func TestEuclideanMod(t *testing.T) {
+	tests := []struct{ n, m, want int64 }{
+		{23, 10, 3},
+		{-3, 10, 7},
+		{-10, 10, 0},
+		{0, 1, 0},
+		{-1, 1 << 40, 1<<40 - 1},
+	}
+	for _, tt := range tests {
+		if got := euclideanMod(tt.n, tt.m); got != tt.want {
+			t.Errorf("euclideanMod(%d, %d) = %d, expected %d", tt.n, tt.m, got, tt.want)
+		}
+	}
+}
+
+// TestPrimeFactors тестирует разложение простых, составных и граничных значений
+func TestPrimeFactors(t *testing.T) {
+	tests := map[int64][]int64{
+		1:         {},
+		2:         {2},
+		7:         {7},
+		12:        {2, 2, 3},
+		97:        {97},
+		360:       {2, 2, 2, 3, 3, 5},
+		999999937: {999999937},
+		1 << 20:   {2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2},
+	}
+	for n, expected := range tests {
+		if got := primeFactors(n); !reflect.DeepEqual(got, expected) {
+			t.Errorf("primeFactors(%d) = %v, expected %v", n, got, expected)
+		}
+	}
+}
+
+// TestFactorize тестирует эндпоинт разложения с пропуском неположительных значений
+func TestFactorize(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{12, 7, 0, -4} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/factorize", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result []FactorizedValue
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &result)
+
+	expected := []FactorizedValue{{Value: 7, Factors: []int64{7}}, {Value: 12, Factors: []int64{2, 2, 3}}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
+// TestFactorizeRowLimit тестирует отказ без limit при слишком большом количестве значений и
+// постраничное разложение с limit и offset
+func TestFactorizeRowLimit(t *testing.T) {
+	fc := &fakeConnector{
+		query: func(_ string, args []driver.NamedValue) (*fakeRows, error) {
+			limit, offset := args[1].Value.(int64), args[2].Value.(int64)
+			rows := &fakeRows{columns: []string{"value"}}
+			for v := offset + 1; v <= offset+limit && v <= maxFactorizeRows+5; v++ {
+				rows.values = append(rows.values, []driver.Value{v})
+			}
+			return rows, nil
+		},
+	}
+	app := &App{DB: newFakeDB(t, fc)}
+
+	tests := []struct {
+		target string
+		status int
+		values []int64
+	}{
+		{"/numbers/factorize", http.StatusRequestEntityTooLarge, nil},
+		{"/numbers/factorize?limit=2&offset=3", http.StatusOK, []int64{4, 5}},
+		{"/numbers/factorize?limit=0", http.StatusBadRequest, nil},
+		{"/numbers/factorize?limit=10001", http.StatusBadRequest, nil},
+		{"/numbers/factorize?limit=5&offset=-1", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.target, tt.status, w.Code, w.Body.String())
+			continue
+		}
+		if tt.values == nil {
+			continue
+		}
+		var result []FactorizedValue
+		if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		var values []int64
+		for _, item := range result {
+			values = append(values, item.Value)
+		}
+		if !reflect.DeepEqual(values, tt.values) {
+			t.Errorf("%s: expected values %v, got %v", tt.target, tt.values, values)
+		}
+	}
+}
+
+// TestShares тестирует доли значений, в сумме дающие 100
+func TestShares(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{4, 1, 3, 2} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/shares", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result []ValueShare
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &result)
+
+	expected := []ValueShare{{1, 10}, {2, 20}, {3, 30}, {4, 40}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+
+	var total float64
+	for _, item := range result {
+		total += item.Percent
+	}
+	if total != 100 {
+		t.Errorf("Expected shares to sum to 100, got %v", total)
+	}
+}
+
+// TestSharesZeroTotal тестирует нулевые доли, если сумма значений равна нулю
+func TestSharesZeroTotal(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{0, 0, 5, -5} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/shares", nil))
+
+	var result []ValueShare
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(result) != 4 {
+		t.Fatalf("Expected 4 values, got %v", result)
+	}
+	for _, item := range result {
+		if item.Percent != 0 {
+			t.Errorf("Expected zero share for a zero total, got %v", item)
+		}
+	}
+}
+
+// TestGrayRank тестирует обратное преобразование кода Грея
+func TestGrayRank(t *testing.T) {
+	for i := int64(0); i < 1<<12; i++ {
+		if got := grayRank(i ^ (i >> 1)); got != i {
+			t.Fatalf("grayRank(gray(%d)) = %d", i, got)
+		}
+	}
+	if got := grayRank(maxFactorizeValue ^ (maxFactorizeValue >> 1)); got != maxFactorizeValue {
+		t.Errorf("grayRank for a large value = %d, want %d", got, maxFactorizeValue)
+	}
+}
+
+// TestGrayOrder тестирует порядок кода Грея, в котором соседние значения отличаются одним битом
+func TestGrayOrder(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{7, 6, 5, 4, -1, 3, 2, 1, 0} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/gray-order", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response NumbersResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &response)
+
+	expected := []int64{0, 1, 3, 2, 6, 7, 5, 4}
+	if !reflect.DeepEqual(response.Numbers, expected) {
+		t.Fatalf("Expected %v, got %v", expected, response.Numbers)
+	}
+	for i := 1; i < len(response.Numbers); i++ {
+		if diff := response.Numbers[i] ^ response.Numbers[i-1]; diff&(diff-1) != 0 {
+			t.Errorf("Values %d and %d differ by more than one bit", response.Numbers[i-1], response.Numbers[i])
+		}
+	}
+}
+
+// TestRebase тестирует сдвиг значений к нулевому минимуму
+func TestRebase(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	app := &App{DB: db}
+
+	for _, num := range []int{5, 3, 10, 3} {
+		app.DB.Exec("INSERT INTO numbers (value) VALUES ($1)", num)
+	}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/rebase", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result []RebasedValue
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &result)
+
+	expected := []RebasedValue{{3, 0}, {3, 0}, {5, 2}, {10, 7}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+	if result[0].Rebased != 0 {
+		t.Errorf("Expected the minimum rebased value to be 0, got %d", result[0].Rebased)
+	}
+
+	// Одно значение сдвигается к нулю
+	app.DB.Exec("DELETE FROM numbers")
+	app.DB.Exec("INSERT INTO numbers (value) VALUES (42)")
+	w = httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/rebase", nil))
+	result = nil
+	json.NewDecoder(w.Body).Decode(&result)
+	if !reflect.DeepEqual(result, []RebasedValue{{42, 0}}) {
+		t.Errorf("Expected [{42 0}], got %v", result)
+	}
+
+	// Крайние значения integer
+	app.DB.Exec("DELETE FROM numbers")
+	app.DB.Exec("INSERT INTO numbers (value) VALUES (-2147483648), (2147483647)")
+	w = httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/numbers/rebase", nil))
+	result = nil
+	json.NewDecoder(w.Body).Decode(&result)
+	if len(result) != 2 || result[1].Rebased != 4294967295 {
+		t.Errorf("Expected a bigint difference for extreme values, got %v", result)
+	}
+}
+
+// TestRebaseEmpty тестирует пустой ответ для пустой таблицы
+func TestRebaseEmpty(t *testing.T) {
+	app := &App{DB: newFakeDB(t, &fakeConnector{query: func(string, []driver.NamedValue) (*fakeRows, error) {
+		return &fakeRows{columns: []string{"value", "rebased"}}, nil
+	}})}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodGet, "/numbers/rebase", nil))
+
+	var result []RebasedValue
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertNoNullArrays(t, &result)
+	if len(result) != 0 {
+		t.Errorf("Expected an empty array, got %v", result)
+	}
+}
+
+// TestPreviewStreaming тестирует потоковые предпросмотры поверх курсора: все значения приходят
+// частями, переполнение сдвига отклоняется до ответа, ошибка в первой части дает 500, а
+// ошибка после начала ответа обрывает массив
+func TestPreviewStreaming(t *testing.T) {
+	values := []int64{-3, 1, 4, 10, 23}
+	tests := []struct {
+		name      string
+		target    string
+		failFetch int
+		status    int
+		expected  string
+	}{
+		{"scale", "/numbers/scale?factor=2", 0, http.StatusOK,
+			`[{"value":-3,"scaled":-6},{"value":1,"scaled":2},{"value":4,"scaled":8},{"value":10,"scaled":20},{"value":23,"scaled":46}]`},
+		{"offset", "/numbers/offset?by=1", 0, http.StatusOK,
+			`[{"value":-3,"adjusted":-2},{"value":1,"adjusted":2},{"value":4,"adjusted":5},{"value":10,"adjusted":11},{"value":23,"adjusted":24}]`},
+		{"mod", "/numbers/mod?m=10", 0, http.StatusOK,
+			`[{"value":-3,"reduced":7},{"value":1,"reduced":1},{"value":4,"reduced":4},{"value":10,"reduced":0},{"value":23,"reduced":3}]`},
+		{"offset overflow", "/numbers/offset?by=" + strconv.FormatInt(math.MaxInt64, 10), 0, http.StatusUnprocessableEntity, ""},
+		{"first chunk error", "/numbers/scale?factor=2", 1, http.StatusInternalServerError, ""},
+		{"later chunk error", "/numbers/scale?factor=2", 2, http.StatusOK, `[{"value":-3,"scaled":-6},{"value":1,"scaled":2}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &App{DB: newFakeDB(t, cursorConnector(values, 2, tt.failFetch)), Config: Config{CursorFetchSize: 2}}
+
+			w := httptest.NewRecorder()
+			app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.expected != "" && strings.TrimSuffix(w.Body.String(), "\n") != tt.expected {
+				t.Errorf("Expected body %s, got %s", tt.expected, w.Body.String())
+			}
+		})
+	}
+}
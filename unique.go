@@ -0,0 +1,37 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// createUniqueValueIndex создает уникальный индекс по value для режима UNIQUE_NUMBERS.
+// Истекшие строки удаляются заранее, чтобы не мешать созданию индекса; если в таблице
+// остались повторы живых значений, запуск завершается ошибкой
+func createUniqueValueIndex(db *sql.DB) error {
+	if _, err := db.Exec("DELETE FROM numbers WHERE expires_at <= now()"); err != nil {
+		return fmt.Errorf("unique value index: purge expired numbers: %w", err)
+	}
+	if _, err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_numbers_value_unique ON numbers (value)"); err != nil {
+		return fmt.Errorf("unique value index (remove duplicate values first): %w", err)
+	}
+	return nil
+}
+
+// purgeExpiredValues удаляет в транзакции истекшие строки с указанными значениями
+func purgeExpiredValues(tx *sql.Tx, values ...int64) error {
+	_, err := tx.Exec("DELETE FROM numbers WHERE value = ANY($1::bigint[]) AND expires_at <= now()", pq.Array(values))
+	return err
+}
+
+// purgeExpiredForUpdate в режиме UNIQUE_NUMBERS удаляет в транзакции все истекшие строки перед
+// изменением живых значений, чтобы новые значения не конфликтовали с ними в индексе
+func (app *App) purgeExpiredForUpdate(tx *sql.Tx) error {
+	if !app.Config.UniqueNumbers {
+		return nil
+	}
+	_, err := tx.Exec("DELETE FROM numbers WHERE expires_at <= now()")
+	return err
+}
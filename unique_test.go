@@ -0,0 +1,142 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// uniqueConnector возвращает фиктивную базу, в которой INSERT добавляет affected строк,
+// и запоминает текст последней вставки
+func uniqueConnector(affected int64, insert *atomic.Value) *fakeConnector {
+	return &fakeConnector{
+		exec: func(q string, args []driver.NamedValue) (driver.Result, error) {
+			if strings.HasPrefix(q, "INSERT") {
+				insert.Store(q)
+			}
+			return driver.RowsAffected(affected), nil
+		},
+		query: func(q string, args []driver.NamedValue) (*fakeRows, error) {
+			if !strings.HasPrefix(q, "INSERT") {
+				return &fakeRows{columns: []string{"value"}}, nil
+			}
+			insert.Store(q)
+			rows := &fakeRows{columns: []string{"id"}}
+			if affected > 0 {
+				rows.values = [][]driver.Value{{int64(1)}}
+			}
+			return rows, nil
+		},
+	}
+}
+
+// TestUniqueInsert тестирует ON CONFLICT DO NOTHING и поле added в режиме UNIQUE_NUMBERS
+func TestUniqueInsert(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		affected int64
+		added    bool
+	}{
+		{"new value", 1, true},
+		{"already present", 0, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var insert atomic.Value
+			app := &App{DB: newFakeDB(t, uniqueConnector(tt.affected, &insert)), Config: Config{UniqueNumbers: true}}
+			app.Cache = newResponseCache(0)
+			version := app.Cache.version.Load()
+
+			w := httptest.NewRecorder()
+			app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers?number=5", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			if q, _ := insert.Load().(string); !strings.Contains(q, "ON CONFLICT (value) DO NOTHING") {
+				t.Errorf("Expected an ON CONFLICT insert, got %q", q)
+			}
+
+			var resp NumbersResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.Added == nil || *resp.Added != tt.added {
+				t.Errorf("Expected added %v, got %v", tt.added, resp.Added)
+			}
+			if changed := app.Cache.version.Load() != version; changed != tt.added {
+				t.Errorf("Expected cache invalidation %v, got %v", tt.added, changed)
+			}
+		})
+	}
+}
+
+// TestAddedOmittedWithoutUnique тестирует, что без UNIQUE_NUMBERS поле added не возвращается
+func TestAddedOmittedWithoutUnique(t *testing.T) {
+	var insert atomic.Value
+	app := &App{DB: newFakeDB(t, uniqueConnector(1, &insert))}
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/numbers?number=5", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "added") {
+		t.Errorf("Expected no added field, got %s", w.Body.String())
+	}
+	if q, _ := insert.Load().(string); strings.Contains(q, "ON CONFLICT") {
+		t.Errorf("Expected a plain insert, got %q", q)
+	}
+}
+
+// dedupConnector возвращает фиктивную базу с уже сохраненными значениями stored: многострочный
+// INSERT ... RETURNING возвращает только значения, которых еще нет
+func dedupConnector(stored ...int64) *fakeConnector {
+	seen := map[int64]bool{}
+	for _, v := range stored {
+		seen[v] = true
+	}
+	return &fakeConnector{
+		query: func(q string, args []driver.NamedValue) (*fakeRows, error) {
+			rows := &fakeRows{columns: []string{"value"}}
+			if !strings.HasPrefix(q, "INSERT") {
+				return rows, nil
+			}
+			for _, a := range args {
+				if v := a.Value.(int64); !seen[v] {
+					seen[v] = true
+					rows.values = append(rows.values, []driver.Value{v})
+				}
+			}
+			return rows, nil
+		},
+	}
+}
+
+// TestUniqueBatchAndImportCounts тестирует, что в режиме UNIQUE_NUMBERS пропущенные повторы
+// не входят в inserted пакетной вставки и импорта
+func TestUniqueBatchAndImportCounts(t *testing.T) {
+	app := &App{DB: newFakeDB(t, dedupConnector(5)), Config: Config{UniqueNumbers: true, BatchChunkSize: 2}}
+
+	w := serveChecked(t, app.routes(), httptest.NewRequest(http.MethodPost, "/numbers/batch", strings.NewReader("[5, 6, 7, 7]")))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var batch BatchResult
+	if err := json.NewDecoder(w.Body).Decode(&batch); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if expected := (BatchResult{Inserted: 2, Skipped: 2, Chunks: 2}); batch != expected {
+		t.Errorf("Expected batch result %+v, got %+v", expected, batch)
+	}
+
+	w = serveChecked(t, app.routes(), importRequest(t, "file", "6\n8\n9\n"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if result := decodeImportResult(t, w); result.Inserted != 2 || result.Skipped != 1 || result.Failed != 0 {
+		t.Errorf("Expected 2 inserted and 1 skipped, got %+v", result)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Validator проверяет число перед сохранением в базу данных
+type Validator interface {
+	Validate(n int64) error
+}
+
+// validationError оборачивает ошибку валидатора, чтобы отличать ее от ошибок базы данных
+type validationError struct {
+	err error
+}
+
+func (e validationError) Error() string { return e.err.Error() }
+func (e validationError) Unwrap() error { return e.err }
+
+// RangeValidator допускает только числа из диапазона [Min, Max]
+type RangeValidator struct {
+	Min int64
+	Max int64
+}
+
+// Validate проверяет, что число попадает в диапазон
+func (v RangeValidator) Validate(n int64) error {
+	if n < v.Min || n > v.Max {
+		return fmt.Errorf("number must be between %d and %d", v.Min, v.Max)
+	}
+	return nil
+}
+
+// NonNegativeValidator отклоняет отрицательные числа
+type NonNegativeValidator struct{}
+
+// Validate проверяет, что число не отрицательное
+func (NonNegativeValidator) Validate(n int64) error {
+	if n < 0 {
+		return fmt.Errorf("number must not be negative")
+	}
+	return nil
+}
+
+// ParityValidator допускает только четные (Even = true) или только нечетные числа
+type ParityValidator struct {
+	Even bool
+}
+
+// Validate проверяет четность числа
+func (v ParityValidator) Validate(n int64) error {
+	isEven := n%2 == 0
+	if v.Even && !isEven {
+		return fmt.Errorf("number must be even")
+	}
+	if !v.Even && isEven {
+		return fmt.Errorf("number must be odd")
+	}
+	return nil
+}
+
+// buildValidators собирает цепочку валидаторов из конфигурации в порядке их применения
+func buildValidators(cfg Config) []Validator {
+	var validators []Validator
+
+	if cfg.MinValue != nil || cfg.MaxValue != nil {
+		rv := RangeValidator{Min: math.MinInt64, Max: math.MaxInt64}
+		if cfg.MinValue != nil {
+			rv.Min = int64(*cfg.MinValue)
+		}
+		if cfg.MaxValue != nil {
+			rv.Max = int64(*cfg.MaxValue)
+		}
+		validators = append(validators, rv)
+	}
+	if cfg.RejectNegative {
+		validators = append(validators, NonNegativeValidator{})
+	}
+	if cfg.Parity != "" {
+		validators = append(validators, ParityValidator{Even: cfg.Parity == "even"})
+	}
+
+	return validators
+}
+
+// validateNumber последовательно применяет валидаторы и возвращает первую ошибку
+func (app *App) validateNumber(n int64) error {
+	for _, v := range app.Validators {
+		if err := v.Validate(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
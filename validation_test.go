@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestValidatorChain тестирует цепочку из валидаторов диапазона и четности
+func TestValidatorChain(t *testing.T) {
+	app := &App{Validators: []Validator{
+		RangeValidator{Min: 0, Max: 100},
+		ParityValidator{Even: true},
+	}}
+
+	tests := []struct {
+		name    string
+		number  int64
+		wantErr bool
+	}{
+		{name: "Even number in range", number: 42, wantErr: false},
+		{name: "Lower bound", number: 0, wantErr: false},
+		{name: "Odd number in range", number: 43, wantErr: true},
+		{name: "Even number above range", number: 102, wantErr: true},
+		{name: "Even number below range", number: -2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := app.validateNumber(tt.number)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateNumber(%d) error = %v, wantErr %v", tt.number, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestBuildValidators тестирует сборку цепочки валидаторов из конфигурации
+func TestBuildValidators(t *testing.T) {
+	lo, hi := 10, 20
+	cfg := Config{MinValue: &lo, MaxValue: &hi, RejectNegative: true, Parity: "odd"}
+
+	validators := buildValidators(cfg)
+	if len(validators) != 3 {
+		t.Fatalf("Expected 3 validators, got %d", len(validators))
+	}
+	if rv, ok := validators[0].(RangeValidator); !ok || rv.Min != 10 || rv.Max != 20 {
+		t.Errorf("Expected RangeValidator{10, 20} first, got %#v", validators[0])
+	}
+	if pv, ok := validators[2].(ParityValidator); !ok || pv.Even {
+		t.Errorf("Expected odd ParityValidator last, got %#v", validators[2])
+	}
+
+	if len(buildValidators(Config{})) != 0 {
+		t.Error("Expected no validators for empty config")
+	}
+}
+
+// TestAddNumberRejectedByValidator тестирует, что число, не прошедшее валидацию, не сохраняется
+func TestAddNumberRejectedByValidator(t *testing.T) {
+	// База данных не нужна: запрос должен быть отклонен до вставки
+	app := &App{Validators: []Validator{ParityValidator{Even: true}}}
+
+	req := httptest.NewRequest(http.MethodPost, "/numbers", bytes.NewBufferString(`{"number": 3}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	app.addNumber(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}